@@ -0,0 +1,119 @@
+package log
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// combinedTimeLayout is the time layout of the Common and Combined Log
+// Formats.
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// combinedFormatter writes entries in the Apache Combined Log Format,
+//
+//	host - user [time] "method url protocol" status size "referer" "user-agent"
+//
+// taking the request from a GCPHTTPRequest field and the user from a "user"
+// field. Entries without a request have the message as the request line;
+// missing values are written as "-".
+type combinedFormatter struct{}
+
+func (f *combinedFormatter) Format(entry *Entry) ([]byte, error) {
+	var req GCPHTTPRequest
+	var user string
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case GCPHTTPRequest:
+			req = v
+		case *GCPHTTPRequest:
+			if v != nil {
+				req = *v
+			}
+		default:
+			if k == "user" {
+				user = stringValue(v)
+			}
+		}
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	writeCombinedField(b, req.RemoteIP)
+	b.WriteString(" - ")
+	writeCombinedField(b, user)
+	b.WriteString(" [")
+	b.WriteString(entry.Time.Format(combinedTimeLayout))
+	b.WriteString("] ")
+	if req.RequestMethod != "" || req.RequestURL != "" {
+		line := req.RequestMethod + " " + req.RequestURL
+		if req.Protocol != "" {
+			line += " " + req.Protocol
+		}
+		writeCombinedQuoted(b, line)
+	} else {
+		writeCombinedQuoted(b, entry.Message)
+	}
+	b.WriteByte(' ')
+	if req.Status > 0 {
+		b.WriteString(strconv.Itoa(req.Status))
+	} else {
+		b.WriteByte('-')
+	}
+	b.WriteByte(' ')
+	if req.ResponseSize > 0 {
+		b.WriteString(strconv.FormatInt(req.ResponseSize, 10))
+	} else {
+		b.WriteByte('-')
+	}
+	b.WriteByte(' ')
+	writeCombinedQuoted(b, req.Referer)
+	b.WriteByte(' ')
+	writeCombinedQuoted(b, req.UserAgent)
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// writeCombinedField writes an unquoted field, with spaces and control
+// characters replaced so that the field stays one token.
+func writeCombinedField(b *bytes.Buffer, s string) {
+	if s == "" {
+		b.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == 0x7f {
+			b.WriteByte('_')
+		} else {
+			b.WriteByte(c)
+		}
+	}
+}
+
+// writeCombinedQuoted writes a quoted field, escaping quotes, backslashes and
+// control characters the way Apache does.
+func writeCombinedQuoted(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	if s == "" {
+		b.WriteByte('-')
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < ' ' || c == 0x7f:
+			b.WriteString(`\x`)
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombinedFormatter(t *testing.T) {
+	assert.Equal(t, CombinedFormatter, FormatterFromName("Combined"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"request": &GCPHTTPRequest{
+			RequestMethod: "GET",
+			RequestURL:    "/a?q=\"x\"",
+			Protocol:      "HTTP/1.1",
+			Status:        200,
+			ResponseSize:  2326,
+			RemoteIP:      "10.0.0.1",
+			Referer:       "http://example.com/",
+			UserAgent:     "curl/8.0",
+		},
+		"user": "jane doe",
+	})
+	entry.Time = time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	entry.Message = "handled"
+	got, err := new(combinedFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `10.0.0.1 - jane_doe [10/Oct/2000:13:55:36 -0700] "GET /a?q=\"x\" HTTP/1.1" 200 2326 "http://example.com/" "curl/8.0"`+"\n", string(got))
+
+	entry.Data = logrus.Fields{}
+	entry.Message = "started\x01"
+	got, err = new(combinedFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `- - - [10/Oct/2000:13:55:36 -0700] "started\x01" - - "-" "-"`+"\n", string(got))
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+var droppedFields uint64
+
+// WithAllowedKeys turns on strict allowlist mode: only fields with the given
+// names, compared case-insensitively, are kept, context fields included, and
+// all others are removed before entries are recorded, formatted or handed to
+// sinks. This includes the fields the package adds itself, such as
+// SampleRateKey, SequenceKey or RepeatCountKey, which must be allowed to be
+// kept. Only top-level fields are checked. Removed fields are counted in
+// DroppedFields. Calls add to the allowed keys.
+func WithAllowedKeys(keys ...string) Option {
+	return func(c *config) {
+		c.allowedKeys = addKeys(c.allowedKeys, keys)
+	}
+}
+
+// DroppedFields returns the number of fields removed in allowlist mode.
+func DroppedFields() uint64 {
+	return atomic.LoadUint64(&droppedFields)
+}
+
+// dropDisallowed removes the fields of the entry that are not allowed.
+func (c *config) dropDisallowed(entry *Entry) {
+	for k := range entry.Data {
+		if !c.allowedKeys[strings.ToLower(k)] {
+			delete(entry.Data, k)
+			atomic.AddUint64(&droppedFields, 1)
+		}
+	}
+}
+
+// addKeys returns a copy of keys with the lowercased names added.
+func addKeys(keys map[string]bool, names []string) map[string]bool {
+	added := make(map[string]bool, len(keys)+len(names))
+	for k := range keys {
+		added[k] = true
+	}
+	for _, name := range names {
+		added[strings.ToLower(name)] = true
+	}
+	return added
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAllowedKeys(t *testing.T) {
+	Init(JSONFormatter, InfoLevel, key("requestId"))
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithAllowedKeys("RequestID"), WithAllowedKeys("status"))
+	defer Configure(func(c *config) { c.allowedKeys = nil })
+
+	dropped := DroppedFields()
+	ctx := context.WithValue(context.Background(), key("requestId"), "request-id")
+	Info(ctx, "handled", Field("status", 200), Field("email", "jane@example.com"), Field("user", map[string]interface{}{"id": 1}))
+
+	assert.Equal(t, logrus.Fields{"requestId": "request-id", "status": 200}, sink.entries[0].Data)
+	assert.Equal(t, dropped+2, DroppedFields())
+}
+
+func TestWithAllowedKeysAddedFields(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithAllowedKeys("status"), WithSequenceNumbers(), WithDedup(time.Hour))
+	defer Configure(WithDedup(0), func(c *config) {
+		c.allowedKeys = nil
+		c.sequence = false
+	})
+
+	ctx := context.Background()
+	Info(ctx, "handled", Field("status", 200))
+	Info(ctx, "handled", Field("status", 200))
+	Info(ctx, "done")
+
+	if assert.Len(t, sink.entries, 3) {
+		assert.Equal(t, logrus.Fields{"status": 200}, sink.entries[0].Data)
+		assert.Equal(t, logrus.Fields{"status": 200}, sink.entries[1].Data)
+		assert.Equal(t, logrus.Fields{}, sink.entries[2].Data)
+	}
+
+	Configure(WithAllowedKeys(SequenceKey))
+	Info(ctx, "stopped")
+	assert.Contains(t, sink.entries[3].Data, SequenceKey)
+}
@@ -0,0 +1,231 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultAsyncQueueSize is the number of entries the async queue holds.
+	DefaultAsyncQueueSize = 4096
+
+	asyncStackFrames = 64
+)
+
+// Backpressure decides what a logging call does when the async queue is full.
+type Backpressure int
+
+const (
+	// BackpressureBlock waits until the queue has room.
+	BackpressureBlock Backpressure = iota
+	// BackpressureDropNewest discards the entry being logged.
+	BackpressureDropNewest
+	// BackpressureDropOldest discards the oldest queued entry to make room.
+	BackpressureDropOldest
+)
+
+// AsyncConfig configures asynchronous logging.
+type AsyncConfig struct {
+	// QueueSize defaults to DefaultAsyncQueueSize.
+	QueueSize int
+	// Workers is the number of goroutines writing entries, by default one. With
+	// more than one worker entries may be written out of order.
+	Workers int
+	// Backpressure sets the policy per level. Levels not in the map block,
+	// except Debug and Trace, whose entries are dropped when the queue is full.
+	Backpressure map[Level]Backpressure
+}
+
+var defaultBackpressure = map[Level]Backpressure{
+	DebugLevel: BackpressureDropNewest,
+	TraceLevel: BackpressureDropNewest,
+}
+
+type asyncEntry struct {
+	seq   uint64
+	entry *Entry
+	level Level
+	msg   string
+}
+
+// asyncLogger hands entries to worker goroutines which format and write them
+// and fire the sinks.
+type asyncLogger struct {
+	dropped      uint64 // accessed atomically, first for 64-bit alignment
+	queue        chan asyncEntry
+	backpressure map[Level]Backpressure
+	workers      sync.WaitGroup
+
+	// seq numbers the queued entries; written is the highest seq up to which
+	// all entries have been written, and done holds those written out of order.
+	mu      sync.Mutex
+	cond    *sync.Cond
+	seq     uint64
+	written uint64
+	done    map[uint64]bool
+}
+
+var (
+	asyncMu sync.RWMutex
+	async   *asyncLogger
+)
+
+// EnableAsync makes the logging functions return as soon as the entry is
+// queued, so slow outputs and sinks do not hold up the caller. What happens
+// while the queue is full depends on the Backpressure config. Fatal entries
+// are written synchronously after the queue has drained. Call Close before
+// the program exits to write what is still queued.
+func EnableAsync(cfg AsyncConfig) {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultAsyncQueueSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	a := &asyncLogger{
+		queue:        make(chan asyncEntry, cfg.QueueSize),
+		backpressure: map[Level]Backpressure{},
+		done:         map[uint64]bool{},
+	}
+	for level, policy := range defaultBackpressure {
+		a.backpressure[level] = policy
+	}
+	for level, policy := range cfg.Backpressure {
+		a.backpressure[level] = policy
+	}
+	a.cond = sync.NewCond(&a.mu)
+	a.workers.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go a.run()
+	}
+
+	asyncMu.Lock()
+	prev := async
+	async = a
+	asyncMu.Unlock()
+	if prev != nil {
+		prev.close()
+	}
+}
+
+// disableAsync stops asynchronous logging after writing every queued entry.
+func disableAsync() {
+	asyncMu.Lock()
+	a := async
+	async = nil
+	asyncMu.Unlock()
+	if a != nil {
+		a.close()
+	}
+}
+
+func (a *asyncLogger) run() {
+	defer a.workers.Done()
+	for e := range a.queue {
+		e.entry.Log(e.level, e.msg)
+		a.finish(e.seq)
+	}
+}
+
+// finish marks an entry as written or discarded.
+func (a *asyncLogger) finish(seq uint64) {
+	a.mu.Lock()
+	a.done[seq] = true
+	for a.done[a.written+1] {
+		delete(a.done, a.written+1)
+		a.written++
+	}
+	a.cond.Broadcast()
+	a.mu.Unlock()
+}
+
+func (a *asyncLogger) discard(e asyncEntry) {
+	atomic.AddUint64(&a.dropped, 1)
+	countDrop(dropQueueFull, e.level)
+	a.finish(e.seq)
+}
+
+func (a *asyncLogger) enqueue(e asyncEntry) {
+	a.mu.Lock()
+	a.seq++
+	e.seq = a.seq
+	a.mu.Unlock()
+
+	switch a.backpressure[e.level] {
+	case BackpressureDropNewest:
+		select {
+		case a.queue <- e:
+		default:
+			a.discard(e)
+		}
+	case BackpressureDropOldest:
+		for {
+			select {
+			case a.queue <- e:
+				return
+			default:
+			}
+			select {
+			case old := <-a.queue:
+				a.discard(old)
+			default:
+			}
+		}
+	default:
+		a.queue <- e
+	}
+}
+
+// drain waits until every entry queued before the call has been written.
+// Entries queued meanwhile by other goroutines are not waited for.
+func (a *asyncLogger) drain() {
+	a.mu.Lock()
+	for target := a.seq; a.written < target; {
+		a.cond.Wait()
+	}
+	a.mu.Unlock()
+}
+
+func (a *asyncLogger) close() {
+	close(a.queue)
+	a.workers.Wait()
+}
+
+// enqueue queues an entry if asynchronous logging is enabled.
+func enqueue(entry *Entry, level Level, msg string) bool {
+	asyncMu.RLock()
+	defer asyncMu.RUnlock()
+	if async == nil {
+		return false
+	}
+	if level <= ErrorLevel {
+		entry.Context = withCallerStack(entry.Context)
+	}
+	async.enqueue(asyncEntry{entry: entry, level: level, msg: msg})
+	return true
+}
+
+// drainAsync writes everything queued so far.
+func drainAsync() {
+	asyncMu.RLock()
+	defer asyncMu.RUnlock()
+	if async != nil {
+		async.drain()
+	}
+}
+
+type callerStackKey struct{}
+
+// withCallerStack records the stack of the logging call in ctx, so that sinks
+// which report stack traces see the caller's stack rather than the async
+// worker's.
+func withCallerStack(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pcs := make([]uintptr, asyncStackFrames)
+	n := runtime.Callers(4, pcs)
+	return context.WithValue(ctx, callerStackKey{}, pcs[:n])
+}
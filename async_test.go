@@ -0,0 +1,124 @@
+package log
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type slowSink struct {
+	memorySink
+	delay time.Duration
+}
+
+func (s *slowSink) Write(entry *Entry) error {
+	time.Sleep(s.delay)
+	return s.memorySink.Write(entry)
+}
+
+func TestAsync(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &slowSink{delay: 20 * time.Millisecond}
+	AddSink("slow", sink)
+	defer func() { _ = RemoveSink("slow") }()
+
+	EnableAsync(AsyncConfig{QueueSize: 10})
+	start := time.Now()
+	Info(ctx, "Informational Message 1", Field("field1", "value1"))
+	Debug(ctx, "Debug Message 1")
+	Infof(ctx, "Informational Message %d", 2)
+	assert.True(t, time.Since(start) < sink.delay)
+
+	assert.NoError(t, Close())
+	sink.mu.Lock()
+	assert.Len(t, sink.entries, 2)
+	assert.Equal(t, "Informational Message 1", sink.entries[0].Message)
+	assert.Equal(t, "value1", sink.entries[0].Data["field1"])
+	assert.True(t, sink.entries[0].Time.Before(start.Add(sink.delay)))
+	assert.Equal(t, "Informational Message 2", sink.entries[1].Message)
+	assert.True(t, sink.closed)
+	sink.mu.Unlock()
+
+	// closed sinks are detached
+	Info(ctx, "Informational Message 3")
+	assert.Len(t, sink.entries, 2)
+}
+
+func TestAsyncStack(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	EnableAsync(AsyncConfig{})
+	Error(ctx, "Error Message 1")
+	assert.NoError(t, Close())
+
+	// the stack is the caller's rather than the worker's, whose outermost frame
+	// is this package's
+	stack := loggingStack(sink.entries[0], 0, 10)
+	assert.Equal(t, "testing.tRunner", stack[0].Function)
+}
+
+func TestAsyncDrain(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &slowSink{delay: 5 * time.Millisecond}
+	AddSink("slow", sink)
+	defer func() { _ = RemoveSink("slow") }()
+
+	EnableAsync(AsyncConfig{Workers: 4})
+	defer Close()
+	for i := 0; i < 20; i++ {
+		Infof(ctx, "Informational Message %d", i)
+	}
+	drainAsync()
+	sink.mu.Lock()
+	assert.Len(t, sink.entries, 20)
+	sink.mu.Unlock()
+}
+
+type gateSink struct {
+	memorySink
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (s *gateSink) Write(entry *Entry) error {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-s.gate
+	return s.memorySink.Write(entry)
+}
+
+func TestAsyncBackpressure(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, DebugLevel)
+	sink := &gateSink{started: make(chan struct{}, 1), gate: make(chan struct{})}
+	AddSink("gate", sink)
+	defer func() { _ = RemoveSink("gate") }()
+
+	SetDropSummaryInterval(0)
+	defer SetDropSummaryInterval(DefaultDropSummaryInterval)
+	EnableAsync(AsyncConfig{QueueSize: 1, Backpressure: map[Level]Backpressure{InfoLevel: BackpressureDropOldest}})
+	a := async
+	Info(ctx, "Informational Message 1")
+	<-sink.started
+	Info(ctx, "Informational Message 2")
+	Info(ctx, "Informational Message 3")
+	Debug(ctx, "Debug Message 1")
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&a.dropped))
+
+	close(sink.gate)
+	assert.NoError(t, Close())
+	assert.Len(t, sink.entries, 2)
+	assert.Equal(t, "Informational Message 1", sink.entries[0].Message)
+	assert.Equal(t, "Informational Message 3", sink.entries[1].Message)
+}
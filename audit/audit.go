@@ -0,0 +1,175 @@
+// Package audit writes tamper-evident audit trails: append-only records, one
+// JSON object per line, each carrying an HMAC-SHA256 over its content and the
+// previous record's MAC. Changing, removing or reordering records breaks the
+// chain, which Verify detects. Removing records from the end can only be
+// detected against a last MAC kept elsewhere, such as in the application log.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record is a single audit record.
+type Record struct {
+	Seq    uint64                 `json:"seq"`
+	Time   time.Time              `json:"time"`
+	Action string                 `json:"action"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	// Prev is the MAC of the previous record, empty for the first.
+	Prev string `json:"prev"`
+	// MAC authenticates the record, Prev included.
+	MAC string `json:"-"`
+}
+
+// macPrefix and macSuffix surround the MAC appended to a record's JSON.
+const (
+	macPrefix = `,"mac":"`
+	macSuffix = `"}`
+	macLen    = sha256.Size * 2
+)
+
+// Trail appends records to a writer, chaining their MACs.
+type Trail struct {
+	key []byte
+
+	mu   sync.Mutex
+	w    io.Writer
+	seq  uint64
+	last string
+	now  func() time.Time
+}
+
+// New starts a trail on w with the HMAC key, which must be kept secret.
+func New(w io.Writer, key []byte) *Trail {
+	return &Trail{key: key, w: w, now: time.Now}
+}
+
+// Open continues the trail in the file at path, creating it if needed. The
+// existing records are verified first.
+func Open(path string, key []byte) (*Trail, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Verify(f, key)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("audit: %s: %w", path, err)
+	}
+	t := New(f, key)
+	t.seq, t.last = res.Records, res.Last
+	return t, nil
+}
+
+// Append writes a record of the action and returns it.
+func (t *Trail) Append(action string, fields map[string]interface{}) (Record, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := Record{
+		Seq:    t.seq + 1,
+		Time:   t.now().UTC(),
+		Action: action,
+		Fields: fields,
+		Prev:   t.last,
+	}
+	body, err := json.Marshal(&r)
+	if err != nil {
+		return Record{}, fmt.Errorf("audit: %w", err)
+	}
+	r.MAC = sign(t.key, body)
+	line := make([]byte, 0, len(body)+len(macPrefix)+macLen+len(macSuffix)+1)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, macPrefix...)
+	line = append(line, r.MAC...)
+	line = append(line, macSuffix...)
+	line = append(line, '\n')
+	if _, err := t.w.Write(line); err != nil {
+		return Record{}, fmt.Errorf("audit: %w", err)
+	}
+	t.seq, t.last = r.Seq, r.MAC
+	return r, nil
+}
+
+// Last returns the sequence number and MAC of the last record, to be kept
+// elsewhere so truncation of the trail can be detected.
+func (t *Trail) Last() (uint64, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.seq, t.last
+}
+
+// Sync commits the trail to stable storage if it is written to a file.
+func (t *Trail) Sync() error {
+	if s, ok := t.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it is a closer.
+func (t *Trail) Close() error {
+	if c, ok := t.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func sign(key, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Result describes a verified trail.
+type Result struct {
+	// Records is the number of records.
+	Records uint64
+	// Last is the MAC of the last record.
+	Last string
+}
+
+// ErrTampered is wrapped by the errors of Verify for records that were
+// changed, removed or reordered.
+var ErrTampered = errors.New("audit trail tampered with")
+
+// Verify checks every record read from r against the key and the chain.
+func Verify(r io.Reader, key []byte) (Result, error) {
+	var res Result
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		n := len(data) - len(macSuffix) - macLen - len(macPrefix)
+		if n < 1 || !bytes.HasPrefix(data[n:], []byte(macPrefix)) || !bytes.HasSuffix(data, []byte(macSuffix)) {
+			return res, fmt.Errorf("line %d: %w: malformed record", line, ErrTampered)
+		}
+		body := append(append([]byte(nil), data[:n]...), '}')
+		mac := string(data[n+len(macPrefix) : len(data)-len(macSuffix)])
+		if !hmac.Equal([]byte(sign(key, body)), []byte(mac)) {
+			return res, fmt.Errorf("line %d: %w: MAC mismatch", line, ErrTampered)
+		}
+		var rec struct {
+			Seq  uint64 `json:"seq"`
+			Prev string `json:"prev"`
+		}
+		if err := json.Unmarshal(body, &rec); err != nil {
+			return res, fmt.Errorf("line %d: %w: %v", line, ErrTampered, err)
+		}
+		if rec.Seq != res.Records+1 || rec.Prev != res.Last {
+			return res, fmt.Errorf("line %d: %w: broken chain at record %d", line, ErrTampered, rec.Seq)
+		}
+		res.Records, res.Last = rec.Seq, mac
+	}
+	return res, scanner.Err()
+}
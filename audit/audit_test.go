@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("audit-test-key")
+
+func testTrail(w *bytes.Buffer) *Trail {
+	t := New(w, testKey)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	t.now = func() time.Time { return now }
+	return t
+}
+
+func TestTrail(t *testing.T) {
+	out := &bytes.Buffer{}
+	trail := testTrail(out)
+	first, err := trail.Append("user.login", map[string]interface{}{"user": "jane"})
+	assert.NoError(t, err)
+	second, err := trail.Append("user.delete", map[string]interface{}{"user": "joe", "by": "jane"})
+	assert.NoError(t, err)
+	_, err = trail.Append("user.logout", nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(1), first.Seq)
+	assert.Empty(t, first.Prev)
+	assert.Equal(t, first.MAC, second.Prev)
+	lines := strings.SplitAfter(out.String(), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], `{"seq":1,"time":"2024-01-02T03:04:05Z","action":"user.login","fields":{"user":"jane"},"prev":"","mac":"`))
+
+	res, err := Verify(strings.NewReader(out.String()), testKey)
+	assert.NoError(t, err)
+	seq, last := trail.Last()
+	assert.Equal(t, Result{Records: 3, Last: last}, res)
+	assert.Equal(t, uint64(3), seq)
+
+	tampered := []string{
+		strings.Replace(out.String(), `"joe"`, `"bob"`, 1),
+		lines[0] + lines[2],
+		lines[1] + lines[0] + lines[2],
+		strings.Replace(out.String(), `,"mac":"`, `,"mac": "`, 1),
+	}
+	for _, s := range tampered {
+		_, err := Verify(strings.NewReader(s), testKey)
+		assert.True(t, errors.Is(err, ErrTampered), "%v", err)
+	}
+	_, err = Verify(strings.NewReader(out.String()), []byte("other key"))
+	assert.True(t, errors.Is(err, ErrTampered))
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	trail, err := Open(path, testKey)
+	assert.NoError(t, err)
+	_, err = trail.Append("first", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, trail.Close())
+
+	trail, err = Open(path, testKey)
+	assert.NoError(t, err)
+	r, err := trail.Append("second", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), r.Seq)
+	assert.NoError(t, trail.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	res, err := Verify(bytes.NewReader(data), testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, r.MAC, res.Last)
+
+	assert.NoError(t, os.WriteFile(path, bytes.Replace(data, []byte("first"), []byte("FIRST"), 1), 0o600))
+	_, err = Open(path, testKey)
+	assert.True(t, errors.Is(err, ErrTampered))
+}
@@ -0,0 +1,60 @@
+// Command auditverify checks audit trails written by the audit package.
+//
+//	AUDIT_KEY=<hex key> auditverify audit.log
+//
+// It prints the number of records and the last MAC, and exits with status 1 if
+// a trail was tampered with.
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andyday/go-log/audit"
+)
+
+func main() {
+	keyEnv := flag.String("key-env", "AUDIT_KEY", "environment variable holding the hex-encoded HMAC key")
+	flag.Parse()
+
+	key, err := hex.DecodeString(os.Getenv(*keyEnv))
+	if err != nil || len(key) == 0 {
+		fmt.Fprintf(os.Stderr, "auditverify: %s must hold the hex-encoded key\n", *keyEnv)
+		os.Exit(2)
+	}
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	failed := false
+	for _, name := range files {
+		if err := verify(name, key); err != nil {
+			fmt.Fprintf(os.Stderr, "auditverify: %s: %v\n", name, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func verify(name string, key []byte) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	res, err := audit.Verify(r, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d records, last MAC %s\n", name, res.Records, res.Last)
+	return nil
+}
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	log "github.com/andyday/go-log"
+)
+
+// LevelKey is the record field holding the level of entries written by Sink.
+const LevelKey = "level"
+
+// Sink records log entries in a trail, using the message as the action. Add it
+// for the levels or under the name audit events are logged with.
+type Sink struct {
+	trail *Trail
+}
+
+// NewSink returns a sink appending to the trail.
+func NewSink(trail *Trail) *Sink {
+	return &Sink{trail: trail}
+}
+
+func (s *Sink) Write(entry *log.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[k] = v
+	}
+	fields[LevelKey] = entry.Level.String()
+	_, err := s.trail.Append(entry.Message, fields)
+	return err
+}
+
+// Flush commits the trail to stable storage.
+func (s *Sink) Flush() error {
+	return s.trail.Sync()
+}
+
+func (s *Sink) Close() error {
+	if err := s.trail.Sync(); err != nil {
+		_ = s.trail.Close()
+		return err
+	}
+	return s.trail.Close()
+}
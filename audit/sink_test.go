@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	log "github.com/andyday/go-log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSink(t *testing.T) {
+	out := &bytes.Buffer{}
+	log.Init(log.JSONFormatter, log.InfoLevel)
+	log.AddSink("audit", NewSink(testTrail(out)), log.WarnLevel)
+	defer func() { _ = log.RemoveSink("audit") }()
+
+	log.Info(context.Background(), "not audited")
+	log.Warn(context.Background(), "permission changed", log.Field("user", "jane"), log.Field("err", errors.New("denied")))
+	assert.NoError(t, log.Flush(context.Background()))
+
+	res, err := Verify(bytes.NewReader(out.Bytes()), testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), res.Records)
+	assert.Contains(t, out.String(), `"action":"permission changed","fields":{"err":"denied","level":"warning","user":"jane"}`)
+}
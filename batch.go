@@ -0,0 +1,295 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultBatchSize is the number of entries after which a batching sink sends.
+	DefaultBatchSize = 500
+	// DefaultFlushInterval is how often a batching sink sends a partial batch.
+	DefaultFlushInterval = time.Second
+	// DefaultQueueSize bounds the entries a batching sink holds in memory.
+	DefaultQueueSize = 10000
+)
+
+// batchItem is an encoded entry waiting to be sent by a batching sink. The key is
+// sink specific, e.g. a stream label set or an index name.
+type batchItem struct {
+	key  string
+	time time.Time
+	data []byte
+}
+
+// batcher collects items and hands them to send in batches of at most size
+// items, and at least every interval. Sends happen on a background goroutine,
+// except for explicit flushes. When limit is positive at most limit items are
+// queued; beyond that the oldest are dropped. With a spool, batches that cannot
+// be sent are persisted and resent first on later flushes.
+type batcher struct {
+	dropped  uint64 // accessed atomically, first for 64-bit alignment
+	size     int
+	interval time.Duration
+	limit    int
+	send     func(items []batchItem) error
+	spool    *Spool
+
+	mu        sync.Mutex
+	items     []batchItem
+	lastErr   error
+	lastErrAt time.Time
+	lastFlush time.Time
+	sendMu    sync.Mutex
+	kick      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func newBatcher(size int, interval time.Duration, limit int, spool *Spool, send func(items []batchItem) error) *batcher {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	b := &batcher{
+		size:     size,
+		interval: interval,
+		limit:    limit,
+		send:     send,
+		spool:    spool,
+		kick:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *batcher) add(item batchItem) {
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	if b.limit > 0 && len(b.items) > b.limit {
+		n := len(b.items) - b.limit
+		b.items = append(b.items[:0], b.items[n:]...)
+		atomic.AddUint64(&b.dropped, uint64(n))
+	}
+	full := len(b.items) >= b.size
+	b.mu.Unlock()
+	if full {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		case <-b.kick:
+		}
+		var reported *reportedError
+		if err := b.flush(); err != nil && !errors.As(err, &reported) {
+			reportError(err)
+		}
+	}
+}
+
+// partialSendError is returned by a send function when only some of the items
+// failed, so that the rest is not dropped or spooled again.
+type partialSendError struct {
+	items []batchItem
+	err   error
+}
+
+func (e *partialSendError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialSendError) Unwrap() error {
+	return e.err
+}
+
+// reportedError marks a send failure the sink has already reported itself. The
+// batcher records it but does not pass it to the error handler again.
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+// failedItems returns the items of batch that a send failed to deliver.
+func failedItems(batch []batchItem, err error) []batchItem {
+	var partial *partialSendError
+	if errors.As(err, &partial) {
+		return partial.items
+	}
+	return batch
+}
+
+// flush sends everything queued, one batch at a time, after anything spooled.
+// Batches that fail permanently are dropped; after a transient failure the rest
+// is spooled, or kept queued without a spool.
+func (b *batcher) flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	failed, err := b.replay()
+	if err != nil {
+		return b.spoolQueued(nil, err)
+	}
+	for {
+		b.mu.Lock()
+		n := len(b.items)
+		if n > b.size {
+			n = b.size
+		}
+		batch := b.items[:n:n]
+		b.items = b.items[n:]
+		b.mu.Unlock()
+		if len(batch) == 0 {
+			return failed
+		}
+		if err := b.send(batch); err != nil {
+			batch = failedItems(batch, err)
+			if retryable(err) {
+				return b.spoolQueued(batch, err)
+			}
+			if derr := b.drop(batch, err); failed == nil {
+				failed = derr
+			}
+			continue
+		}
+		b.mu.Lock()
+		b.lastFlush = time.Now()
+		b.mu.Unlock()
+	}
+}
+
+// replay sends the spooled items and removes them from the spool. Batches that
+// fail permanently are dropped and the first such failure returned as failed;
+// a transient failure stops the replay and is returned as err.
+func (b *batcher) replay() (failed, err error) {
+	if b.spool == nil {
+		return nil, nil
+	}
+	items, err := b.spool.load()
+	if err != nil {
+		return nil, err
+	}
+	done := 0
+	delivered := false
+	remaining := items[:0:0]
+	for done < len(items) {
+		n := len(items) - done
+		if n > b.size {
+			n = b.size
+		}
+		batch := items[done : done+n : done+n]
+		if err = b.send(batch); err != nil {
+			if retryable(err) {
+				remaining = append(append(remaining, failedItems(batch, err)...), items[done+n:]...)
+				break
+			}
+			batch = failedItems(batch, err)
+			if derr := b.drop(batch, err); failed == nil {
+				failed = derr
+			}
+			err = nil
+		} else {
+			delivered = true
+		}
+		done += n
+	}
+	if delivered {
+		b.mu.Lock()
+		b.lastFlush = time.Now()
+		b.mu.Unlock()
+	}
+	if len(remaining) < len(items) {
+		if rerr := b.spool.replace(remaining); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return failed, err
+}
+
+// drop records that items could not be delivered.
+func (b *batcher) drop(items []batchItem, err error) error {
+	b.mu.Lock()
+	b.lastErr, b.lastErrAt = err, time.Now()
+	b.mu.Unlock()
+	atomic.AddUint64(&b.dropped, uint64(len(items)))
+	return fmt.Errorf("%w; %d entries dropped", err, len(items))
+}
+
+// spoolQueued moves failed and everything still queued behind it to the spool,
+// if there is one, and returns the send error. Without a spool only failed is
+// lost.
+func (b *batcher) spoolQueued(failed []batchItem, err error) error {
+	if b.spool == nil {
+		if len(failed) == 0 {
+			return err
+		}
+		return b.drop(failed, err)
+	}
+	b.mu.Lock()
+	b.lastErr, b.lastErrAt = err, time.Now()
+	items := append(failed, b.items...)
+	b.items = nil
+	b.mu.Unlock()
+	if len(items) == 0 {
+		return err
+	}
+	if serr := b.spool.append(items); serr != nil {
+		atomic.AddUint64(&b.dropped, uint64(len(items)))
+		return fmt.Errorf("%w; %d entries lost: %v", err, len(items), serr)
+	}
+	return fmt.Errorf("%w; %d entries spooled", err, len(items))
+}
+
+// status reports the queue state for SinkStatus.
+func (b *batcher) status() SinkHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := SinkHealth{
+		LastError:   b.lastErr,
+		LastErrorAt: b.lastErrAt,
+		Queued:      len(b.items),
+		Dropped:     atomic.LoadUint64(&b.dropped),
+		LastFlush:   b.lastFlush,
+	}
+	if b.spool != nil {
+		h.Spooled = b.spool.Size()
+		h.Dropped += b.spool.Dropped()
+	}
+	return h
+}
+
+// close stops the background goroutine and sends what is left.
+func (b *batcher) close() error {
+	select {
+	case <-b.stop:
+		return nil
+	default:
+		close(b.stop)
+	}
+	<-b.done
+	return b.flush()
+}
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcher(t *testing.T) {
+	sent := make(chan []batchItem, 10)
+	b := newBatcher(2, time.Hour, 0, nil, func(items []batchItem) error {
+		sent <- items
+		return nil
+	})
+
+	b.add(batchItem{data: []byte("1")})
+	b.add(batchItem{data: []byte("2")})
+	select {
+	case items := <-sent:
+		assert.Len(t, items, 2)
+	case <-time.After(time.Second):
+		t.Fatal("full batch was not sent")
+	}
+
+	b.add(batchItem{data: []byte("3")})
+	assert.NoError(t, b.close())
+	assert.Equal(t, []byte("3"), (<-sent)[0].data)
+}
+
+func TestBatcherLimit(t *testing.T) {
+	var sent []batchItem
+	b := newBatcher(10, time.Hour, 2, nil, func(items []batchItem) error {
+		sent = append(sent, items...)
+		return nil
+	})
+
+	b.add(batchItem{data: []byte("1")})
+	b.add(batchItem{data: []byte("2")})
+	b.add(batchItem{data: []byte("3")})
+	assert.NoError(t, b.close())
+	assert.Equal(t, uint64(1), b.dropped)
+	assert.Len(t, sent, 2)
+	assert.Equal(t, []byte("2"), sent[0].data)
+}
+
+func TestBatcherSpool(t *testing.T) {
+	spool, err := OpenSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	assert.NoError(t, err)
+	var sent [][]batchItem
+	fail := true
+	b := newBatcher(10, time.Hour, 0, spool, func(items []batchItem) error {
+		if fail {
+			return errors.New("unavailable")
+		}
+		sent = append(sent, items)
+		return nil
+	})
+
+	b.add(batchItem{data: []byte("a")})
+	b.add(batchItem{data: []byte("b")})
+	b.add(batchItem{data: []byte("c")})
+	assert.EqualError(t, b.flush(), "unavailable; 3 entries spooled")
+	assert.True(t, spool.Size() > 0)
+
+	fail = false
+	b.add(batchItem{data: []byte("d")})
+	assert.NoError(t, b.close())
+	assert.Len(t, sent, 2)
+	assert.Len(t, sent[0], 3)
+	assert.Equal(t, "a", string(sent[0][0].data))
+	assert.Equal(t, "d", string(sent[1][0].data))
+	assert.Equal(t, int64(0), spool.Size())
+}
+
+func TestBatcherSpoolPermanentFailure(t *testing.T) {
+	spool, err := OpenSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	assert.NoError(t, err)
+	var sent []batchItem
+	// without the background goroutine, so that only explicit flushes send
+	b := &batcher{size: 1, spool: spool, send: func(items []batchItem) error {
+		if string(items[0].data) == "poison" {
+			return &httpStatusError{code: 400}
+		}
+		sent = append(sent, items...)
+		return nil
+	}}
+
+	b.add(batchItem{data: []byte("poison")})
+	b.add(batchItem{data: []byte("a")})
+	assert.EqualError(t, b.flush(), "unexpected status 400: ; 1 entries dropped")
+	assert.Equal(t, int64(0), spool.Size())
+	assert.Equal(t, uint64(1), b.status().Dropped)
+	assert.Len(t, sent, 1)
+
+	// a poison batch already in the spool does not block later deliveries
+	assert.NoError(t, spool.append([]batchItem{{data: []byte("poison")}, {data: []byte("b")}}))
+	b.add(batchItem{data: []byte("c")})
+	assert.Error(t, b.flush())
+	assert.Len(t, sent, 3)
+	assert.Equal(t, "b", string(sent[1].data))
+	assert.Equal(t, int64(0), spool.Size())
+}
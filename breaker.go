@@ -0,0 +1,163 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultBreakerThreshold is how many consecutive failures open the circuit.
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerCooldown is how long an open circuit stays open.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// CircuitBreakerConfig configures a circuit breaker sink.
+type CircuitBreakerConfig struct {
+	// Sink is the protected sink.
+	Sink Sink
+	// Fallback, if set, receives the entries Sink fails to take and every entry
+	// while the circuit is open, for example a local file.
+	Fallback Sink
+	// Threshold defaults to DefaultBreakerThreshold.
+	Threshold int
+	// Cooldown defaults to DefaultBreakerCooldown.
+	Cooldown time.Duration
+}
+
+// CircuitBreakerSink stops writing to a sink that keeps failing. After Threshold
+// consecutive failures the circuit opens and entries go to the fallback, or are
+// dropped, until Cooldown has passed. Then a single entry is tried against the
+// sink again while the others still take the open path; its success closes the
+// circuit and its failure reopens it.
+type CircuitBreakerSink struct {
+	dropped   uint64 // accessed atomically, first for 64-bit alignment
+	cfg       CircuitBreakerConfig
+	now       func() time.Time
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	probing   bool
+	openUntil time.Time
+}
+
+// NewCircuitBreakerSink wraps cfg.Sink in a circuit breaker.
+func NewCircuitBreakerSink(cfg CircuitBreakerConfig) *CircuitBreakerSink {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultBreakerThreshold
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultBreakerCooldown
+	}
+	return &CircuitBreakerSink{cfg: cfg, now: time.Now}
+}
+
+// Open reports whether the circuit is currently open. It is not while the
+// cooldown has passed and the sink is being tried again.
+func (s *CircuitBreakerSink) Open() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.open && s.now().Before(s.openUntil)
+}
+
+// allow reports whether an entry may be written to the sink, and whether that
+// write is the probe of a half-open circuit.
+func (s *CircuitBreakerSink) allow() (ok, probe bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.open {
+		return true, false
+	}
+	if s.probing || s.now().Before(s.openUntil) {
+		return false, false
+	}
+	s.probing = true
+	return true, true
+}
+
+func (s *CircuitBreakerSink) Write(entry *Entry) error {
+	ok, probe := s.allow()
+	if !ok {
+		return s.fallback(entry)
+	}
+	err := s.cfg.Sink.Write(entry)
+
+	s.mu.Lock()
+	if probe {
+		s.probing = false
+	}
+	if err == nil {
+		s.failures = 0
+		s.open = false
+		s.mu.Unlock()
+		return nil
+	}
+	s.failures++
+	opened := probe || (!s.open && s.failures >= s.cfg.Threshold)
+	if opened {
+		s.open = true
+		s.failures = 0
+		s.openUntil = s.now().Add(s.cfg.Cooldown)
+	}
+	s.mu.Unlock()
+
+	if s.cfg.Fallback != nil {
+		if ferr := s.cfg.Fallback.Write(entry); ferr != nil {
+			return ferr
+		}
+		if !opened {
+			return nil
+		}
+	}
+	if opened {
+		return fmt.Errorf("circuit opened for %v: %w", s.cfg.Cooldown, err)
+	}
+	return err
+}
+
+// fallback writes entry to the fallback sink, or counts it as dropped if there
+// is none.
+func (s *CircuitBreakerSink) fallback(entry *Entry) error {
+	if s.cfg.Fallback == nil {
+		atomic.AddUint64(&s.dropped, 1)
+		return nil
+	}
+	return s.cfg.Fallback.Write(entry)
+}
+
+// Flush flushes the sink unless the circuit is open, and the fallback.
+func (s *CircuitBreakerSink) Flush() error {
+	var err error
+	if !s.Open() {
+		err = s.cfg.Sink.Flush()
+	}
+	if s.cfg.Fallback != nil {
+		if ferr := s.cfg.Fallback.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+func (s *CircuitBreakerSink) Close() error {
+	err := s.cfg.Sink.Close()
+	if s.cfg.Fallback != nil {
+		if ferr := s.cfg.Fallback.Close(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// Status reports the protected sink's status, if it has one, and the entries
+// dropped while the circuit was open.
+func (s *CircuitBreakerSink) Status() SinkHealth {
+	var h SinkHealth
+	if r, ok := s.cfg.Sink.(StatusReporter); ok {
+		h = r.Status()
+	}
+	h.Dropped += atomic.LoadUint64(&s.dropped)
+	return h
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerSink(t *testing.T) {
+	primary := &memorySink{err: errors.New("unavailable")}
+	fallback := &memorySink{}
+	sink := NewCircuitBreakerSink(CircuitBreakerConfig{Sink: primary, Fallback: fallback, Threshold: 2, Cooldown: time.Minute})
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+	entry := logrus.NewEntry(logrus.New())
+
+	assert.NoError(t, sink.Write(entry))
+	assert.EqualError(t, sink.Write(entry), "circuit opened for 1m0s: unavailable")
+	assert.True(t, sink.Open())
+	assert.NoError(t, sink.Write(entry))
+	assert.Len(t, primary.entries, 2)
+	assert.Len(t, fallback.entries, 3)
+
+	primary.err = nil
+	now = now.Add(time.Minute)
+	assert.False(t, sink.Open())
+	assert.NoError(t, sink.Write(entry))
+	assert.Len(t, primary.entries, 3)
+	assert.Len(t, fallback.entries, 3)
+
+	assert.NoError(t, sink.Close())
+	assert.True(t, primary.closed)
+	assert.True(t, fallback.closed)
+}
+
+func TestCircuitBreakerSinkProbeFailure(t *testing.T) {
+	primary := &memorySink{err: errors.New("unavailable")}
+	fallback := &memorySink{}
+	sink := NewCircuitBreakerSink(CircuitBreakerConfig{Sink: primary, Fallback: fallback, Threshold: 3, Cooldown: time.Minute})
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+	entry := logrus.NewEntry(logrus.New())
+
+	assert.NoError(t, sink.Write(entry))
+	assert.NoError(t, sink.Write(entry))
+	assert.Error(t, sink.Write(entry))
+	assert.Len(t, primary.entries, 3)
+
+	// a single failed probe reopens the circuit
+	now = now.Add(time.Minute)
+	assert.EqualError(t, sink.Write(entry), "circuit opened for 1m0s: unavailable")
+	assert.True(t, sink.Open())
+	assert.NoError(t, sink.Write(entry))
+	assert.Len(t, primary.entries, 4)
+	assert.Len(t, fallback.entries, 5)
+}
+
+func TestCircuitBreakerSinkWithoutFallback(t *testing.T) {
+	primary := &memorySink{err: errors.New("unavailable")}
+	sink := NewCircuitBreakerSink(CircuitBreakerConfig{Sink: primary, Threshold: 2})
+	entry := logrus.NewEntry(logrus.New())
+
+	assert.EqualError(t, sink.Write(entry), "unavailable")
+	assert.Error(t, sink.Write(entry))
+	assert.NoError(t, sink.Write(entry))
+	assert.Len(t, primary.entries, 2)
+	assert.Equal(t, uint64(1), sink.Status().Dropped)
+}
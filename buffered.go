@@ -0,0 +1,110 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBufferSize is the number of bytes a BufferedWriter holds.
+	DefaultBufferSize = 32 * 1024
+	// DefaultBufferFlushInterval is how long a BufferedWriter holds data.
+	DefaultBufferFlushInterval = 100 * time.Millisecond
+)
+
+// BufferedWriter coalesces writes to an underlying writer, for example a file
+// receiving a high volume of Debug entries. Data is written once the buffer is
+// full or when it has been held for the flush interval, whichever comes first.
+// Flush and Close write it immediately; the package flushes its output before
+// Fatal exits the program.
+type BufferedWriter struct {
+	w        io.Writer
+	size     int
+	interval time.Duration
+
+	mu    sync.Mutex
+	buf   []byte
+	timer *time.Timer
+}
+
+// NewBufferedWriter buffers up to size bytes for at most interval. Zero values
+// mean DefaultBufferSize and DefaultBufferFlushInterval.
+func NewBufferedWriter(w io.Writer, size int, interval time.Duration) *BufferedWriter {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	if interval <= 0 {
+		interval = DefaultBufferFlushInterval
+	}
+	return &BufferedWriter{w: w, size: size, interval: interval, buf: make([]byte, 0, size)}
+}
+
+func (b *BufferedWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.buf)+len(p) > b.size {
+		if err := b.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if len(p) >= b.size {
+		return b.w.Write(p)
+	}
+	b.buf = append(b.buf, p...)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.timer = nil
+			if err := b.flush(); err != nil {
+				reportError(err)
+			}
+		})
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered data.
+func (b *BufferedWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return b.flush()
+}
+
+// Close flushes the writer. The underlying writer is not closed.
+func (b *BufferedWriter) Close() error {
+	return b.Flush()
+}
+
+// flush must be called with mu held.
+func (b *BufferedWriter) flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
+// flushOutput flushes the logger's output if it buffers.
+func flushOutput() error {
+	if f, ok := logger.Out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func init() {
+	logrus.RegisterExitHandler(func() {
+		if err := flushOutput(); err != nil {
+			reportError(err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writes++
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestBufferedWriter(t *testing.T) {
+	out := &syncBuffer{}
+	w := NewBufferedWriter(out, 10, time.Hour)
+
+	_, _ = w.Write([]byte("abc"))
+	_, _ = w.Write([]byte("def"))
+	assert.Equal(t, "", out.String())
+	_, _ = w.Write([]byte("ghijk"))
+	assert.Equal(t, "abcdef", out.String())
+	_, _ = w.Write([]byte("0123456789"))
+	assert.Equal(t, "abcdefghijk0123456789", out.String())
+	assert.Equal(t, 3, out.writes)
+
+	_, _ = w.Write([]byte("x"))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "abcdefghijk0123456789x", out.String())
+}
+
+func TestBufferedWriterInterval(t *testing.T) {
+	out := &syncBuffer{}
+	Init(SimpleFormatter, InfoLevel)
+	SetOutput(NewBufferedWriter(out, 0, 10*time.Millisecond))
+	defer SetOutput(os.Stderr)
+
+	Info(context.Background(), "Informational Message 1")
+	Info(context.Background(), "Informational Message 2")
+	assert.Equal(t, "", out.String())
+	assert.Eventually(t, func() bool {
+		return out.String() == "Informational Message 1\nInformational Message 2\n"
+	}, time.Second, 5*time.Millisecond)
+
+	Info(context.Background(), "Informational Message 3")
+	assert.NoError(t, Flush(context.Background()))
+	assert.Contains(t, out.String(), "Informational Message 3")
+}
+
+func TestBufferedWriterFatal(t *testing.T) {
+	out := &syncBuffer{}
+	Init(SimpleFormatter, InfoLevel)
+	SetOutput(NewBufferedWriter(out, 0, time.Hour))
+	defer SetOutput(os.Stderr)
+	exited := false
+	SetExitFunc(func(int) { exited = true })
+	defer SetExitFunc(nil)
+
+	Fatal(context.Background(), errors.New("Fatal Message 1"))
+	assert.True(t, exited)
+	assert.Equal(t, "Fatal Message 1\n", out.String())
+}
@@ -0,0 +1,31 @@
+package log
+
+import "runtime/debug"
+
+// The fields WithBuildInfo adds.
+const (
+	// ModuleVersionKey holds the version of the main module, such as v1.4.0,
+	// or (devel) for a build from a working tree.
+	ModuleVersionKey = "module_version"
+	// RevisionKey holds the VCS revision the binary was built from.
+	RevisionKey = "vcs_revision"
+	// DirtyKey is true if the working tree had uncommitted changes.
+	DirtyKey = "vcs_dirty"
+)
+
+// WithBuildInfo adds the module version and, in binaries built by Go 1.18
+// or later from a VCS checkout, the revision and whether the tree was dirty
+// to every entry, so that each line identifies the binary that wrote it.
+// Fields the build does not record are left out.
+func WithBuildInfo() Option {
+	fields := map[string]interface{}{}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Version != "" {
+			fields[ModuleVersionKey] = info.Main.Version
+		}
+		addVCSFields(fields, info)
+	}
+	return func(c *config) {
+		c.setStatic(fields)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !go1.18
+// +build !go1.18
+
+package log
+
+import "runtime/debug"
+
+// addVCSFields does nothing, since builds before Go 1.18 record no VCS
+// settings.
+func addVCSFields(map[string]interface{}, *debug.BuildInfo) {}
@@ -0,0 +1,26 @@
+package log
+
+import (
+	"context"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithBuildInfo(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(func(c *config) { c.static = nil })
+
+	Configure(WithBuildInfo())
+	Info(context.Background(), "Informational Message 1")
+	info, _ := debug.ReadBuildInfo()
+	if info.Main.Version != "" {
+		assert.Equal(t, info.Main.Version, sink.entries[0].Data[ModuleVersionKey])
+	} else {
+		assert.NotContains(t, sink.entries[0].Data, ModuleVersionKey)
+	}
+}
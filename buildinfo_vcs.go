@@ -0,0 +1,19 @@
+//go:build go1.18
+// +build go1.18
+
+package log
+
+import "runtime/debug"
+
+// addVCSFields adds the revision and dirty flag recorded in the build
+// settings.
+func addVCSFields(fields map[string]interface{}, info *debug.BuildInfo) {
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			fields[RevisionKey] = s.Value
+		case "vcs.modified":
+			fields[DirtyKey] = s.Value == "true"
+		}
+	}
+}
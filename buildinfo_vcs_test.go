@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package log
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddVCSFields(t *testing.T) {
+	fields := map[string]interface{}{}
+	addVCSFields(fields, &debug.BuildInfo{Settings: []debug.BuildSetting{
+		{Key: "vcs", Value: "git"},
+		{Key: "vcs.revision", Value: "0cf8a7a"},
+		{Key: "vcs.modified", Value: "true"},
+	}})
+	assert.Equal(t, map[string]interface{}{RevisionKey: "0cf8a7a", DirtyKey: true}, fields)
+}
@@ -0,0 +1,34 @@
+package log
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+)
+
+// BytesFormat is how Bytes fields are written.
+type BytesFormat int
+
+const (
+	// BytesBase64 writes the standard base64 encoding, as encoding/json does.
+	BytesBase64 BytesFormat = iota
+	// BytesHex writes the lowercase hex encoding.
+	BytesHex
+	// BytesLen writes only the length, such as "len=4096".
+	BytesLen
+)
+
+// Bytes is a field whose value is data written in the given format, as a
+// string. BytesLen keeps large payloads out of entries.
+func Bytes(key string, data []byte, format BytesFormat) Fld {
+	var value string
+	switch format {
+	case BytesHex:
+		value = hex.EncodeToString(data)
+	case BytesLen:
+		value = "len=" + strconv.Itoa(len(data))
+	default:
+		value = base64.StdEncoding.EncodeToString(data)
+	}
+	return &fld{key: key, value: value}
+}
@@ -0,0 +1,29 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytes(t *testing.T) {
+	data := []byte{0xde, 0xad, 0xbe, 0xef}
+	tests := []struct {
+		format BytesFormat
+		want   string
+	}{
+		{BytesBase64, "3q2+7w=="},
+		{BytesHex, "deadbeef"},
+		{BytesLen, "len=4"},
+	}
+	for _, tt := range tests {
+		fields := logrus.Fields{}
+		Bytes("payload", data, tt.format).apply(fields)
+		assert.Equal(t, logrus.Fields{"payload": tt.want}, fields)
+	}
+
+	fields := logrus.Fields{}
+	Bytes("payload", nil, BytesLen).apply(fields)
+	assert.Equal(t, "len=0", fields["payload"])
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithCaller reports the file, line and function of the logging call with
+// every entry. Formatters write it as their caller field, such as func and
+// file in JSON.
+func WithCaller() Option {
+	return func(c *config) {
+		c.caller = true
+	}
+}
+
+type callerKey struct{}
+
+// withCaller records the program counter of the logging call in ctx. Skip is
+// the number of frames between the caller of withCaller and the application,
+// which is fixed for each of this package's logging functions.
+func withCaller(ctx context.Context, skip int) context.Context {
+	var pc [1]uintptr
+	if runtime.Callers(skip+2, pc[:]) == 0 {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, callerKey{}, pc[0])
+}
+
+// callerFrame returns the frame recorded by withCaller, if any.
+func callerFrame(ctx context.Context) *runtime.Frame {
+	if ctx == nil {
+		return nil
+	}
+	pc, ok := ctx.Value(callerKey{}).(uintptr)
+	if !ok {
+		return nil
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return &frame
+}
+
+// callerLocation returns the caller of an entry as "dir/file.go:line".
+func callerLocation(entry *Entry) string {
+	return shortPath(entry.Caller.File) + ":" + strconv.Itoa(entry.Caller.Line)
+}
+
+// callerHook replaces the caller logrus finds, which is inside this package
+// or, for entries written asynchronously, the queue worker, with the one
+// recorded by the logging call. It is the first hook, so sinks see it too.
+type callerHook struct{}
+
+func (callerHook) Levels() []Level {
+	return logrus.AllLevels
+}
+
+func (callerHook) Fire(entry *Entry) error {
+	if frame := callerFrame(entry.Context); frame != nil {
+		entry.Caller = frame
+	}
+	return nil
+}
+
+func init() {
+	logger.AddHook(callerHook{})
+}
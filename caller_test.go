@@ -0,0 +1,61 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// line returns the line it is called from.
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+func TestWithCaller(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	Init(JSONFormatter, InfoLevel)
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	Info(ctx, "no caller")
+	var got map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.NotContains(t, got, "file")
+	assert.False(t, sink.entries[0].HasCaller())
+
+	Configure(WithCaller())
+	defer Configure(func(c *config) { c.caller = false })
+	buf.Reset()
+	want := line() + 1
+	Info(ctx, "caller", Field("n", 1))
+	got = nil
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Regexp(t, `/caller_test\.go:`+strconv.Itoa(want)+`$`, got["file"])
+	assert.Equal(t, "github.com/andyday/go-log.TestWithCaller", got["func"])
+	assert.True(t, sink.entries[1].HasCaller())
+	assert.Equal(t, want, sink.entries[1].Caller.Line)
+
+	buf.Reset()
+	Init(SimpleFormatter, InfoLevel)
+	want = line() + 1
+	Warnf(ctx, "%d", 1)
+	assert.Regexp(t, `^1 \| caller=\w[^/]*/caller_test\.go:`+strconv.Itoa(want)+"\n$", buf.String())
+
+	Init(JSONFormatter, InfoLevel)
+	EnableAsync(AsyncConfig{})
+	want = line() + 1
+	Error(ctx, "async")
+	assert.NoError(t, Close())
+	assert.Equal(t, want, sink.entries[3].Caller.Line)
+}
@@ -0,0 +1,179 @@
+package log
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CBOR major types.
+const (
+	cborUint   = 0 << 5
+	cborNegint = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+)
+
+// cborFormatter writes each entry as a CBOR (RFC 8949) map of time, level,
+// msg, caller and the fields, those named like these keys under a "fields."
+// prefix. The time is an epoch-based date/time, tag 1. Entries are not
+// separated, forming a CBOR sequence (RFC 8742).
+type cborFormatter struct{}
+
+func (f *cborFormatter) Format(entry *Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	scratch := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(scratch)
+	n := len(entry.Data) + 3
+	if entry.HasCaller() {
+		n++
+	}
+	out := appendCBORHead((*scratch)[:0], cborMap, uint64(n))
+	out = appendCBORString(out, logrus.FieldKeyTime)
+	out = appendCBORTime(out, entry.Time)
+	out = appendCBORString(out, logrus.FieldKeyLevel)
+	out = appendCBORString(out, entry.Level.String())
+	out = appendCBORString(out, logrus.FieldKeyMsg)
+	out = appendCBORString(out, entry.Message)
+	if entry.HasCaller() {
+		out = appendCBORString(out, "caller")
+		out = appendCBORString(out, callerLocation(entry))
+	}
+	for _, k := range fieldKeys(entry) {
+		v := entry.Data[k]
+		switch k {
+		case logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg, "caller":
+			k = "fields." + k
+		}
+		out = appendCBORString(out, k)
+		out = appendCBOR(out, v)
+	}
+	*scratch = out
+	b.Write(out)
+	return b.Bytes(), nil
+}
+
+// appendCBOR appends the CBOR encoding of v to b. Types without a direct CBOR
+// representation are encoded as text strings.
+func appendCBOR(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, cborSimple|22)
+	case bool:
+		if v {
+			return append(b, cborSimple|21)
+		}
+		return append(b, cborSimple|20)
+	case int:
+		return appendCBORInt(b, int64(v))
+	case int8:
+		return appendCBORInt(b, int64(v))
+	case int16:
+		return appendCBORInt(b, int64(v))
+	case int32:
+		return appendCBORInt(b, int64(v))
+	case int64:
+		return appendCBORInt(b, v)
+	case uint:
+		return appendCBORHead(b, cborUint, uint64(v))
+	case uint8:
+		return appendCBORHead(b, cborUint, uint64(v))
+	case uint16:
+		return appendCBORHead(b, cborUint, uint64(v))
+	case uint32:
+		return appendCBORHead(b, cborUint, uint64(v))
+	case uint64:
+		return appendCBORHead(b, cborUint, v)
+	case float32:
+		return appendUint32(append(b, cborSimple|26), math.Float32bits(v))
+	case float64:
+		return appendUint64(append(b, cborSimple|27), math.Float64bits(v))
+	case string:
+		return appendCBORString(b, v)
+	case []byte:
+		b = appendCBORHead(b, cborBytes, uint64(len(v)))
+		return append(b, v...)
+	case time.Time:
+		return appendCBORTime(b, v)
+	case []interface{}:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, e := range v {
+			b = appendCBOR(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		return appendCBORMap(b, v)
+	case logrus.Fields:
+		return appendCBORMap(b, v)
+	case namespace:
+		return appendCBORMap(b, v)
+	case map[string]string:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		for k, e := range v {
+			b = appendCBORString(b, k)
+			b = appendCBORString(b, e)
+		}
+		return b
+	case error:
+		return appendCBORString(b, v.Error())
+	default:
+		return appendCBORString(b, stringValue(v))
+	}
+}
+
+func appendCBORMap(b []byte, m map[string]interface{}) []byte {
+	b = appendCBORHead(b, cborMap, uint64(len(m)))
+	for k, v := range m {
+		b = appendCBORString(b, k)
+		b = appendCBOR(b, v)
+	}
+	return b
+}
+
+func appendCBORInt(b []byte, i int64) []byte {
+	if i < 0 {
+		return appendCBORHead(b, cborNegint, uint64(-1-i))
+	}
+	return appendCBORHead(b, cborUint, uint64(i))
+}
+
+func appendCBORString(b []byte, s string) []byte {
+	b = appendCBORHead(b, cborText, uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendCBORTime encodes t as tag 1, with integer seconds when t falls on a
+// second and float seconds otherwise.
+func appendCBORTime(b []byte, t time.Time) []byte {
+	b = appendCBORHead(b, cborTag, 1)
+	if t.Nanosecond() == 0 {
+		return appendCBORInt(b, t.Unix())
+	}
+	return appendCBOR(b, float64(t.Unix())+float64(t.Nanosecond())/1e9)
+}
+
+// appendCBORHead appends the initial byte of the given major type and its
+// argument n in the shortest form.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return appendUint32(append(b, major|26), uint32(n))
+	default:
+		return appendUint64(append(b, major|27), n)
+	}
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendCBOR(t *testing.T) {
+	cases := []struct {
+		v        interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xf6}},
+		{false, []byte{0xf4}},
+		{true, []byte{0xf5}},
+		{0, []byte{0x00}},
+		{23, []byte{0x17}},
+		{24, []byte{0x18, 0x18}},
+		{1000, []byte{0x19, 0x03, 0xe8}},
+		{1000000, []byte{0x1a, 0x00, 0x0f, 0x42, 0x40}},
+		{uint64(math.MaxUint64), []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{-1, []byte{0x20}},
+		{-100, []byte{0x38, 0x63}},
+		{int64(math.MinInt64), []byte{0x3b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{1.1, []byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{float32(100000), []byte{0xfa, 0x47, 0xc3, 0x50, 0x00}},
+		{"", []byte{0x60}},
+		{"IETF", []byte{0x64, 0x49, 0x45, 0x54, 0x46}},
+		{[]byte{1, 2, 3, 4}, []byte{0x44, 0x01, 0x02, 0x03, 0x04}},
+		{[]interface{}{1, []interface{}{2, 3}}, []byte{0x82, 0x01, 0x82, 0x02, 0x03}},
+		{map[string]interface{}{"a": 1}, []byte{0xa1, 0x61, 0x61, 0x01}},
+		{logrus.Fields{"a": "b"}, []byte{0xa1, 0x61, 0x61, 0x61, 0x62}},
+		{map[string]string{"a": "b"}, []byte{0xa1, 0x61, 0x61, 0x61, 0x62}},
+		{time.Unix(1363896240, 0), []byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0}},
+		{time.Unix(1363896240, 500000000), []byte{0xc1, 0xfb, 0x41, 0xd4, 0x52, 0xd9, 0xec, 0x20, 0x00, 0x00}},
+		{errors.New("e"), []byte{0x61, 'e'}},
+		{struct{ A int }{1}, append([]byte{0x67}, `{"A":1}`...)},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, appendCBOR(nil, c.v), "%v", c.v)
+	}
+	long := make([]byte, 300)
+	assert.Equal(t, []byte{0x79, 0x01, 0x2c}, appendCBOR(nil, string(long))[:3])
+}
+
+func TestCBORFormatter(t *testing.T) {
+	assert.Equal(t, CBORFormatter, FormatterFromName("CBOR"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{"msg": "clash", "n": 1})
+	entry.Time = time.Unix(1363896240, 0)
+	entry.Level = WarnLevel
+	entry.Message = "hi"
+	got, err := new(cborFormatter).Format(entry)
+	assert.NoError(t, err)
+	want := []byte{0xa5,
+		0x64, 't', 'i', 'm', 'e', 0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0,
+		0x65, 'l', 'e', 'v', 'e', 'l', 0x67, 'w', 'a', 'r', 'n', 'i', 'n', 'g',
+		0x63, 'm', 's', 'g', 0x62, 'h', 'i',
+		0x6a, 'f', 'i', 'e', 'l', 'd', 's', '.', 'm', 's', 'g', 0x65, 'c', 'l', 'a', 's', 'h',
+		0x61, 'n', 0x01,
+	}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,199 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCloudLoggingURL is the Cloud Logging API endpoint.
+const DefaultCloudLoggingURL = "https://logging.googleapis.com"
+
+var cloudLoggingSeverity = [...]string{"EMERGENCY", "ALERT", "CRITICAL", "ERROR", "WARNING", "NOTICE", "INFO", "DEBUG"}
+
+// CloudLoggingResource is the monitored resource entries are attributed to.
+type CloudLoggingResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type cloudLoggingEntry struct {
+	Severity    string                 `json:"severity"`
+	Timestamp   string                 `json:"timestamp"`
+	JSONPayload map[string]interface{} `json:"jsonPayload"`
+	Trace       string                 `json:"trace,omitempty"`
+	SpanID      string                 `json:"spanId,omitempty"`
+}
+
+// CloudLoggingConfig configures a Google Cloud Logging sink.
+type CloudLoggingConfig struct {
+	// ProjectID is the GCP project that owns the log and traces.
+	ProjectID string
+	// LogName defaults to "app".
+	LogName string
+	// Resource defaults to the "global" resource.
+	Resource *CloudLoggingResource
+	// Labels are added to every entry.
+	Labels map[string]string
+	// Trace returns the trace and span IDs of the entry's context, if any.
+	Trace func(ctx context.Context) (traceID, spanID string)
+	// Output enables structured-stdout mode: entries are written to Output as
+	// JSON lines for the logging agent of GKE or Cloud Run to pick up, instead of
+	// being sent to the API.
+	Output io.Writer
+	// TokenSource returns an OAuth2 access token for API mode.
+	TokenSource func() (string, error)
+	// URL defaults to DefaultCloudLoggingURL.
+	URL string
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// CloudLoggingSink writes entries to Google Cloud Logging, either through the
+// API or as structured JSON on stdout.
+type CloudLoggingSink struct {
+	cfg     CloudLoggingConfig
+	url     string
+	mu      sync.Mutex
+	batcher *batcher
+}
+
+// NewCloudLoggingSink creates a Cloud Logging sink.
+func NewCloudLoggingSink(cfg CloudLoggingConfig) *CloudLoggingSink {
+	if cfg.LogName == "" {
+		cfg.LogName = "app"
+	}
+	if cfg.Resource == nil {
+		cfg.Resource = &CloudLoggingResource{Type: "global"}
+	}
+	if cfg.URL == "" {
+		cfg.URL = DefaultCloudLoggingURL
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+	s := &CloudLoggingSink{cfg: cfg, url: strings.TrimSuffix(cfg.URL, "/") + "/v2/entries:write"}
+	if cfg.Output == nil {
+		s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	}
+	return s
+}
+
+func (s *CloudLoggingSink) Write(entry *Entry) error {
+	payload := make(map[string]interface{}, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		payload[k] = v
+	}
+	payload["message"] = entry.Message
+
+	var traceID, spanID string
+	if s.cfg.Trace != nil && entry.Context != nil {
+		traceID, spanID = s.cfg.Trace(entry.Context)
+	}
+	if traceID != "" {
+		traceID = "projects/" + s.cfg.ProjectID + "/traces/" + traceID
+	}
+	severity := cloudLoggingSeverity[syslogSeverity(entry.Level)]
+
+	if s.cfg.Output != nil {
+		payload["severity"] = severity
+		payload["time"] = entry.Time.Format(time.RFC3339Nano)
+		if traceID != "" {
+			payload["logging.googleapis.com/trace"] = traceID
+		}
+		if spanID != "" {
+			payload["logging.googleapis.com/spanId"] = spanID
+		}
+		if len(s.cfg.Labels) > 0 {
+			payload["logging.googleapis.com/labels"] = s.cfg.Labels
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, err = s.cfg.Output.Write(append(data, '\n'))
+		return err
+	}
+
+	data, err := json.Marshal(&cloudLoggingEntry{
+		Severity:    severity,
+		Timestamp:   entry.Time.Format(time.RFC3339Nano),
+		JSONPayload: payload,
+		Trace:       traceID,
+		SpanID:      spanID,
+	})
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{time: entry.Time, data: data})
+	return nil
+}
+
+func (s *CloudLoggingSink) send(items []batchItem) error {
+	entries := make([]json.RawMessage, len(items))
+	for i, item := range items {
+		entries[i] = item.data
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"logName":  "projects/" + s.cfg.ProjectID + "/logs/" + s.cfg.LogName,
+		"resource": s.cfg.Resource,
+		"labels":   s.cfg.Labels,
+		"entries":  entries,
+	})
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if s.cfg.TokenSource != nil {
+		token, err := s.cfg.TokenSource()
+		if err != nil {
+			return err
+		}
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return post(s.cfg.Client, s.url, header, body, s.cfg.MaxRetries)
+}
+
+func (s *CloudLoggingSink) Flush() error {
+	if s.batcher == nil {
+		return nil
+	}
+	return s.batcher.flush()
+}
+
+func (s *CloudLoggingSink) Close() error {
+	if s.batcher == nil {
+		return nil
+	}
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *CloudLoggingSink) Status() SinkHealth {
+	if s.batcher == nil {
+		return SinkHealth{}
+	}
+	return s.batcher.status()
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type traceKey struct{}
+
+func testTrace(ctx context.Context) (string, string) {
+	if v, ok := ctx.Value(traceKey{}).(string); ok {
+		return v, "span-1"
+	}
+	return "", ""
+}
+
+func TestCloudLoggingSinkStdout(t *testing.T) {
+	out := &bytes.Buffer{}
+	sink := NewCloudLoggingSink(CloudLoggingConfig{ProjectID: "proj", Trace: testTrace, Output: out})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("gcp", sink)
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	Warn(ctx, "Warning Message 1", Field("field1", "value1"))
+	assert.NoError(t, RemoveSink("gcp"))
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "WARNING", line["severity"])
+	assert.Equal(t, "Warning Message 1", line["message"])
+	assert.Equal(t, "value1", line["field1"])
+	assert.Equal(t, "projects/proj/traces/trace-1", line["logging.googleapis.com/trace"])
+	assert.Equal(t, "span-1", line["logging.googleapis.com/spanId"])
+}
+
+func TestCloudLoggingSinkAPI(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		body map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/v2/entries:write", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer srv.Close()
+
+	sink := NewCloudLoggingSink(CloudLoggingConfig{
+		ProjectID:   "proj",
+		Resource:    &CloudLoggingResource{Type: "k8s_container", Labels: map[string]string{"cluster_name": "c1"}},
+		URL:         srv.URL,
+		TokenSource: func() (string, error) { return "token", nil },
+	})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("gcp", sink)
+	Error(context.Background(), "Error Message 1")
+	assert.NoError(t, RemoveSink("gcp"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "projects/proj/logs/app", body["logName"])
+	assert.Equal(t, "k8s_container", body["resource"].(map[string]interface{})["type"])
+	entry := body["entries"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "ERROR", entry["severity"])
+	assert.Equal(t, "Error Message 1", entry["jsonPayload"].(map[string]interface{})["message"])
+}
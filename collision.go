@@ -0,0 +1,68 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CollisionPolicy is what happens to a field whose key is taken, by a context
+// field or by a key formatters write the entry itself under, such as msg.
+type CollisionPolicy int
+
+const (
+	// Overwrite lets fields of the call replace context fields, and leaves
+	// formatters to handle reserved keys: the JSON and text formatters keep
+	// such fields under a "fields." prefix.
+	Overwrite CollisionPolicy = iota
+	// PrefixFields keeps the colliding field under a "fields." prefix.
+	PrefixFields
+	// SuffixDuplicates keeps the colliding field under a numbered key, such as
+	// "msg_2".
+	SuffixDuplicates
+	// StrictKeys reports each collision to the error handler set with
+	// SetErrorHandler and otherwise behaves like PrefixFields.
+	StrictKeys
+)
+
+// WithKeyCollisions sets what happens to fields of a call whose keys are taken
+// by context fields or are reserved: time, msg, level, the keys set with
+// WithJSONFields and logrus_error. Overwrite by default. The context field
+// and the entry's own time, message and level always keep their keys.
+func WithKeyCollisions(policy CollisionPolicy) Option {
+	return func(c *config) {
+		c.collisions = policy
+	}
+}
+
+// renameReserved moves the fields of the entry, which must not be shared,
+// whose keys are reserved.
+func (c *config) renameReserved(entry *Entry) {
+	timeKey, msgKey, levelKey := c.jsonFields.keys()
+	reserved := [...]string{logrus.FieldKeyTime, logrus.FieldKeyMsg, logrus.FieldKeyLevel, logrus.FieldKeyLogrusError, timeKey, msgKey, levelKey}
+	for _, k := range reserved {
+		if v, ok := entry.Data[k]; ok {
+			delete(entry.Data, k)
+			c.keepCollision(entry.Data, k, v, "a reserved key")
+		}
+	}
+}
+
+// keepCollision adds the value of a field whose key is taken to fields under
+// another key.
+func (c *config) keepCollision(fields logrus.Fields, key string, value interface{}, taken string) {
+	n := "fields." + key
+	if c.collisions == SuffixDuplicates {
+		for i := 2; ; i++ {
+			n = key + "_" + strconv.Itoa(i)
+			if _, ok := fields[n]; !ok {
+				break
+			}
+		}
+	}
+	if c.collisions == StrictKeys {
+		reportError(fmt.Errorf("field %q collides with %s", key, taken))
+	}
+	fields[n] = value
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithKeyCollisions(t *testing.T) {
+	Init(JSONFormatter, InfoLevel, key("requestId"))
+	defer Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	var reported []string
+	SetErrorHandler(func(err error) { reported = append(reported, err.Error()) })
+	defer SetErrorHandler(nil)
+	defer Configure(WithKeyCollisions(Overwrite), WithJSONFields(JSONFields{}))
+	Configure(WithJSONFields(JSONFields{MessageKey: "message"}))
+
+	ctx := context.WithValue(context.Background(), key("requestId"), "ctx")
+	flds := []Fld{Field("requestId", "call"), Field("msg", "m"), Field("level", "l"), Field("time", "t"), Field("message", "m2"), Field("n", 1)}
+	tests := []struct {
+		policy CollisionPolicy
+		want   logrus.Fields
+	}{
+		{Overwrite, logrus.Fields{"requestId": "call", "msg": "m", "level": "l", "time": "t", "message": "m2", "n": 1}},
+		{PrefixFields, logrus.Fields{"requestId": "ctx", "fields.requestId": "call", "fields.msg": "m", "fields.level": "l", "fields.time": "t", "fields.message": "m2", "n": 1}},
+		{SuffixDuplicates, logrus.Fields{"requestId": "ctx", "requestId_2": "call", "msg_2": "m", "level_2": "l", "time_2": "t", "message_2": "m2", "n": 1}},
+		{StrictKeys, logrus.Fields{"requestId": "ctx", "fields.requestId": "call", "fields.msg": "m", "fields.level": "l", "fields.time": "t", "fields.message": "m2", "n": 1}},
+	}
+	for _, tt := range tests {
+		sink.entries = nil
+		reported = nil
+		Configure(WithKeyCollisions(tt.policy))
+		Info(ctx, "collide", flds...)
+		assert.Equal(t, tt.want, sink.entries[0].Data, "policy %d", tt.policy)
+		if tt.policy == StrictKeys {
+			assert.Len(t, reported, 5)
+			assert.Contains(t, reported, `field "requestId" collides with a context field`)
+			assert.Contains(t, reported, `field "msg" collides with a reserved key`)
+		} else {
+			assert.Empty(t, reported)
+		}
+	}
+
+	// numbered keys skip taken ones
+	Configure(WithKeyCollisions(SuffixDuplicates))
+	sink.entries = nil
+	Info(ctx, "collide", Field("msg", 1), Field("msg_2", 2))
+	assert.Equal(t, logrus.Fields{"requestId": "ctx", "msg_2": 2, "msg_3": 1}, sink.entries[0].Data)
+
+	// without a collision nothing changes
+	Error(context.Background(), "plain", Field("err", errors.New("e")))
+	assert.Equal(t, logrus.Fields{"err": "e"}, sink.entries[1].Data)
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ColorMode is when the simple and text formatters write colors.
+type ColorMode int
+
+const (
+	// ColorAuto writes colors when the output is a terminal and the NO_COLOR
+	// environment variable is empty.
+	ColorAuto ColorMode = iota
+	// ColorAlways always writes colors.
+	ColorAlways
+	// ColorNever never writes colors.
+	ColorNever
+)
+
+// ANSI escape sequences.
+const (
+	colorReset  = "\x1b[0m"
+	colorDim    = "\x1b[2m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[36m"
+	colorGray   = "\x1b[37m"
+)
+
+// WithColor sets when the simple and text formatters write colors, ColorAuto
+// by default. The simple formatter colors the entry by level and dims field
+// keys.
+func WithColor(mode ColorMode) Option {
+	return func(c *config) {
+		c.color = mode
+	}
+}
+
+// terminals caches whether the files written to are terminals.
+var terminals sync.Map // *os.File to bool
+
+// colored reports whether the entry is written with colors.
+func colored(entry *Entry) bool {
+	switch currentConfig().color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || entry.Logger == nil {
+		return false
+	}
+	f, ok := entry.Logger.Out.(*os.File)
+	if !ok {
+		return false
+	}
+	if tty, ok := terminals.Load(f); ok {
+		return tty.(bool)
+	}
+	info, err := f.Stat()
+	tty := err == nil && info.Mode()&os.ModeCharDevice != 0
+	terminals.Store(f, tty)
+	return tty
+}
+
+// writeColored writes s in the color, if color is set.
+func writeColored(b *bytes.Buffer, color bool, code, s string) {
+	if !color {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(code)
+	b.WriteString(s)
+	b.WriteString(colorReset)
+}
+
+// levelColor returns the escape sequence entries of the level are colored
+// with.
+func levelColor(level Level) string {
+	switch level {
+	case DebugLevel, TraceLevel:
+		return colorGray
+	case WarnLevel:
+		return colorYellow
+	case ErrorLevel, FatalLevel, PanicLevel:
+		return colorRed
+	}
+	return colorBlue
+}
+
+// textFormatter is logrus.TextFormatter with colors as set with WithColor.
+type textFormatter struct {
+	colored logrus.TextFormatter
+	plain   logrus.TextFormatter
+}
+
+func newTextFormatter() *textFormatter {
+	return &textFormatter{
+		colored: logrus.TextFormatter{ForceColors: true},
+		plain:   logrus.TextFormatter{DisableColors: true},
+	}
+}
+
+func (f *textFormatter) Format(entry *Entry) ([]byte, error) {
+	if data, ok := flattenNamespaces(entry.Data); ok {
+		flat := *entry
+		flat.Data = data
+		entry = &flat
+	}
+	if colored(entry) {
+		return f.colored.Format(entry)
+	}
+	return f.plain.Format(entry)
+}
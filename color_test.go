@@ -0,0 +1,50 @@
+package log
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColor(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New()).WithField("k", "v")
+	entry.Message = "failed"
+	entry.Level = ErrorLevel
+
+	// a buffer is not a terminal
+	got, err := new(simpleFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "failed | k=v\n", string(got))
+
+	Configure(WithColor(ColorAlways))
+	defer Configure(WithColor(ColorAuto))
+	got, err = new(simpleFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x1b[31mfailed\x1b[0m | \x1b[2mk\x1b[0m=v\n", string(got))
+	got, err = newTextFormatter().Format(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), "\x1b[31mERRO\x1b[0m")
+
+	Configure(WithColor(ColorNever))
+	got, err = newTextFormatter().Format(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), "level=error")
+}
+
+func TestColorAuto(t *testing.T) {
+	// /dev/null is a character device, like a terminal
+	f, err := os.OpenFile("/dev/null", os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	l := logrus.New()
+	l.SetOutput(f)
+	entry := logrus.NewEntry(l)
+	t.Setenv("NO_COLOR", "")
+	assert.True(t, colored(entry))
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, colored(entry))
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// contextFieldsKey is the context key of the fields added by ContextWithFields.
+type contextFieldsKey struct{}
+
+// ContextWithFields returns a context whose entries carry flds in addition to
+// the fields added to ctx before, so middleware and intermediate layers can
+// accumulate fields such as the tenant or shard for every entry logged
+// downstream. A field added again under the same key replaces the earlier
+// one. They are context fields, like those given to Init: fields of a call
+// under the same keys replace them, or are kept as set with WithKeyCollisions.
+//
+//	ctx = log.ContextWithFields(ctx, log.Field("tenant", tenant.ID))
+func ContextWithFields(ctx context.Context, flds ...Fld) context.Context {
+	parent := contextFields(ctx)
+	b := Bound{
+		fields: make(logrus.Fields, len(parent.fields)+len(flds)),
+		keys:   make([]string, len(parent.keys), len(parent.keys)+len(flds)),
+	}
+	copy(b.keys, parent.keys)
+	parent.apply(b.fields)
+	for _, f := range flds {
+		f.apply(b.fields)
+		switch f := f.(type) {
+		case *fld:
+			b.keys = append(b.keys, f.key)
+		case Bound:
+			b.keys = append(b.keys, f.keys...)
+		}
+	}
+	return context.WithValue(ctx, contextFieldsKey{}, b)
+}
+
+// contextFields returns the fields added to ctx by ContextWithFields.
+func contextFields(ctx context.Context) Bound {
+	if ctx == nil {
+		return Bound{}
+	}
+	b, _ := ctx.Value(contextFieldsKey{}).(Bound)
+	return b
+}
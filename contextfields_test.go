@@ -0,0 +1,30 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithFields(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	parent := ContextWithFields(context.Background(), Field("tenant", "acme"), Field("plan", "free"))
+	ctx := ContextWithFields(parent, Field("plan", "pro"), Prebind(Field("shard", 3)))
+	Info(parent, "Informational Message 1")
+	Info(ctx, "Informational Message 2", Field("shard", 4))
+
+	assert.Equal(t, logrus.Fields{"tenant": "acme", "plan": "free"}, sink.entries[0].Data)
+	assert.Equal(t, logrus.Fields{"tenant": "acme", "plan": "pro", "shard": 4}, sink.entries[1].Data)
+
+	defer Configure(WithKeyCollisions(Overwrite), WithFieldOrder(SortedFields))
+	Configure(WithKeyCollisions(PrefixFields), WithFieldOrder(InsertionOrder))
+	Info(ctx, "Informational Message 3", Field("id", 1), Field("shard", 4))
+	assert.Equal(t, logrus.Fields{"tenant": "acme", "plan": "pro", "shard": 3, "fields.shard": 4, "id": 1}, sink.entries[2].Data)
+	assert.Equal(t, []string{"tenant", "plan", "shard", "id", "fields.shard"}, fieldKeys(sink.entries[2]))
+}
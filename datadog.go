@@ -0,0 +1,161 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultDatadogURL is the logs intake endpoint of the US1 site.
+const DefaultDatadogURL = "https://http-intake.logs.datadoghq.com"
+
+var datadogStatus = [...]string{"emergency", "alert", "critical", "error", "warning", "notice", "info", "debug"}
+
+// DatadogConfig configures a Datadog logs sink.
+type DatadogConfig struct {
+	// URL is the intake base URL and defaults to DefaultDatadogURL.
+	URL string
+	// APIKey is the Datadog API key.
+	APIKey string
+	// Service and Source are the default service and ddsource. The "service" and
+	// "source" entry fields override them.
+	Service string
+	Source  string
+	// Tags are static tags in key:value form.
+	Tags []string
+	// TagFields names entry fields sent as tags rather than attributes.
+	TagFields []string
+	// Hostname defaults to os.Hostname.
+	Hostname string
+	// Gzip compresses request bodies.
+	Gzip bool
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// DatadogSink batches entries and posts them to the Datadog logs intake.
+type DatadogSink struct {
+	cfg     DatadogConfig
+	url     string
+	header  http.Header
+	tagKeys map[string]bool
+	batcher *batcher
+}
+
+// NewDatadogSink creates a Datadog logs sink.
+func NewDatadogSink(cfg DatadogConfig) *DatadogSink {
+	if cfg.URL == "" {
+		cfg.URL = DefaultDatadogURL
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+
+	header := http.Header{
+		"Dd-Api-Key":   {cfg.APIKey},
+		"Content-Type": {"application/json"},
+	}
+	if cfg.Gzip {
+		header.Set("Content-Encoding", "gzip")
+	}
+	s := &DatadogSink{
+		cfg:     cfg,
+		url:     strings.TrimSuffix(cfg.URL, "/") + "/api/v2/logs",
+		header:  header,
+		tagKeys: map[string]bool{},
+	}
+	for _, k := range cfg.TagFields {
+		s.tagKeys[k] = true
+	}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *DatadogSink) Write(entry *Entry) error {
+	log := map[string]interface{}{
+		"message":  entry.Message,
+		"status":   datadogStatus[syslogSeverity(entry.Level)],
+		"hostname": s.cfg.Hostname,
+		"service":  s.cfg.Service,
+		"ddsource": s.cfg.Source,
+		"date":     entry.Time.UnixNano() / int64(time.Millisecond),
+	}
+	tags := append([]string(nil), s.cfg.Tags...)
+	for k, v := range entry.Data {
+		switch {
+		case k == "service":
+			log["service"] = stringValue(v)
+		case k == "source":
+			log["ddsource"] = stringValue(v)
+		case s.tagKeys[k]:
+			tags = append(tags, k+":"+stringValue(v))
+		default:
+			log[k] = v
+		}
+	}
+	if len(tags) > 0 {
+		log["ddtags"] = strings.Join(tags, ",")
+	}
+
+	data, err := json.Marshal(log)
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{time: entry.Time, data: data})
+	return nil
+}
+
+// send posts items as a JSON array.
+func (s *DatadogSink) send(items []batchItem) (err error) {
+	body := bytes.Buffer{}
+	body.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(item.data)
+	}
+	body.WriteByte(']')
+	data := body.Bytes()
+	if s.cfg.Gzip {
+		if data, err = gzipBytes(data); err != nil {
+			return err
+		}
+	}
+	return post(s.cfg.Client, s.url, s.header, data, s.cfg.MaxRetries)
+}
+
+func (s *DatadogSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *DatadogSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *DatadogSink) Status() SinkHealth {
+	return s.batcher.status()
+}
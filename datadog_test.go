@@ -0,0 +1,57 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatadogSink(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		logs []map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/api/v2/logs", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("DD-API-KEY"))
+		var batch []map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		logs = append(logs, batch...)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sink := NewDatadogSink(DatadogConfig{
+		URL:       srv.URL,
+		APIKey:    "key",
+		Service:   "api",
+		Source:    "go",
+		Tags:      []string{"env:test"},
+		TagFields: []string{"tenant"},
+	})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("datadog", sink)
+	ctx := context.Background()
+	Error(ctx, "Error Message 1", Field("tenant", "acme"), Field("field1", "value1"))
+	Info(ctx, "Informational Message 1", Field("service", "worker"))
+	assert.NoError(t, RemoveSink("datadog"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "error", logs[0]["status"])
+	assert.Equal(t, "api", logs[0]["service"])
+	assert.Equal(t, "go", logs[0]["ddsource"])
+	assert.Equal(t, "env:test,tenant:acme", logs[0]["ddtags"])
+	assert.Equal(t, "value1", logs[0]["field1"])
+	assert.Equal(t, "info", logs[1]["status"])
+	assert.Equal(t, "worker", logs[1]["service"])
+}
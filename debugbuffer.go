@@ -0,0 +1,81 @@
+package log
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultDebugBufferSize is the number of entries a debug buffer holds.
+const DefaultDebugBufferSize = 256
+
+type debugBufferKey struct{}
+
+// debugBuffer holds the suppressed Debug and Trace entries of a request.
+type debugBuffer struct {
+	size    int
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+// WithDebugBuffer returns a context under which Debug and Trace entries that
+// are below the logger's level are held back instead of discarded. If an Error
+// or Fatal entry is later logged with the context, the held entries are written
+// first, so failing requests are logged in full detail without the cost of
+// always-on debug logging. At most size entries are held, the oldest being
+// dropped; zero means DefaultDebugBufferSize.
+func WithDebugBuffer(ctx context.Context, size int) context.Context {
+	if size <= 0 {
+		size = DefaultDebugBufferSize
+	}
+	return context.WithValue(ctx, debugBufferKey{}, &debugBuffer{size: size})
+}
+
+func debugBufferFrom(ctx context.Context) *debugBuffer {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(debugBufferKey{}).(*debugBuffer)
+	return b
+}
+
+func (b *debugBuffer) add(entry *Entry, level Level, msg string) {
+	e := &Entry{Logger: logger, Data: entry.Data, Time: entry.Time, Level: level, Message: msg, Context: entry.Context}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) == b.size {
+		b.entries = append(b.entries[:0], b.entries[1:]...)
+	}
+	b.entries = append(b.entries, e)
+}
+
+// flush writes the held entries, bypassing the level check, and empties the
+// buffer.
+func (b *debugBuffer) flush() {
+	b.mu.Lock()
+	entries := b.entries
+	b.entries = nil
+	b.mu.Unlock()
+	if len(entries) == 0 {
+		return
+	}
+	l := unfilteredLogger()
+	for _, e := range entries {
+		e.Logger = l
+		e.Log(e.Level, e.Message)
+	}
+}
+
+// unfilteredLogger returns a logger writing like the package logger, at every
+// level.
+func unfilteredLogger() *logrus.Logger {
+	return &logrus.Logger{
+		Out:          logger.Out,
+		Hooks:        logger.Hooks,
+		Formatter:    logger.Formatter,
+		Level:        TraceLevel,
+		ExitFunc:     logger.ExitFunc,
+		ReportCaller: logger.ReportCaller,
+	}
+}
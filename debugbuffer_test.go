@@ -0,0 +1,43 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugBuffer(t *testing.T) {
+	Init(SimpleFormatter, InfoLevel)
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(os.Stderr)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	// a request that succeeds logs no debug entries
+	ctx := WithDebugBuffer(context.Background(), 2)
+	Debug(ctx, "Debug Message 1")
+	Info(ctx, "Informational Message 1")
+	assert.Equal(t, "Informational Message 1\n", out.String())
+
+	// a failing one logs them before the error
+	out.Reset()
+	ctx = WithDebugBuffer(context.Background(), 2)
+	Debug(ctx, "Debug Message 1")
+	Debugf(ctx, "Debug Message %d", 2)
+	Debug(ctx, "Debug Message 3")
+	Debug(context.Background(), "Debug Message 4")
+	Error(ctx, "Error Message 1")
+	assert.Equal(t, "Debug Message 2\nDebug Message 3\nError Message 1\n", out.String())
+	assert.Len(t, sink.entries, 4)
+	assert.Equal(t, DebugLevel, sink.entries[1].Level)
+
+	// held entries are written once
+	out.Reset()
+	Error(ctx, "Error Message 2")
+	assert.Equal(t, "Error Message 2\n", out.String())
+}
@@ -0,0 +1,116 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RepeatCountKey is the field counting the identical entries an entry stands
+// for after duplicate suppression.
+const RepeatCountKey = "repeat_count"
+
+// WithDedup collapses consecutive identical entries, with the same level,
+// message and fields, logged within window of the first one. The first entry
+// is written as usual; once a different entry arrives or the window ends, one
+// more copy is written with the number of suppressed repeats in repeat_count,
+// like syslog's "last message repeated" line. Zero turns it off.
+func WithDedup(window time.Duration) Option {
+	return func(c *config) {
+		if window <= 0 {
+			c.dedup = nil
+			return
+		}
+		c.dedup = &deduper{window: window}
+	}
+}
+
+type deduper struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	key     string
+	entry   *Entry
+	level   Level
+	msg     string
+	until   time.Time
+	repeats int
+	timer   *time.Timer
+}
+
+// repeated reports whether the entry repeats the previous one and is to be
+// suppressed. Otherwise it writes the repeats of the previous entry first.
+func (d *deduper) repeated(entry *Entry, level Level, msg string) bool {
+	key := dedupKey(level, msg, entry)
+	d.mu.Lock()
+	if key == d.key && entry.Time.Before(d.until) {
+		d.repeats++
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.until.Sub(entry.Time), d.flush)
+		}
+		d.mu.Unlock()
+		return true
+	}
+	prev, prevLevel, prevMsg, repeats := d.take()
+	d.key, d.entry, d.level, d.msg = key, entry, level, msg
+	d.until = entry.Time.Add(d.window)
+	d.mu.Unlock()
+	writeRepeats(prev, prevLevel, prevMsg, repeats)
+	return false
+}
+
+// flush writes the pending repeats and forgets the previous entry.
+func (d *deduper) flush() {
+	d.mu.Lock()
+	prev, level, msg, repeats := d.take()
+	d.key, d.entry = "", nil
+	d.mu.Unlock()
+	writeRepeats(prev, level, msg, repeats)
+}
+
+func (d *deduper) take() (*Entry, Level, string, int) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	repeats := d.repeats
+	d.repeats = 0
+	return d.entry, d.level, d.msg, repeats
+}
+
+func writeRepeats(entry *Entry, level Level, msg string, repeats int) {
+	if repeats == 0 {
+		return
+	}
+	cfg := currentConfig()
+	entry = entry.WithField(RepeatCountKey, repeats)
+	entry.Time = cfg.now()
+	if cfg.sequence {
+		// the summary is a new entry, not a copy of the first
+		entry.Data[SequenceKey] = nextSequence()
+	}
+	write(entry, level, msg)
+}
+
+func dedupKey(level Level, msg string, entry *Entry) string {
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d\x00%s", level, msg)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\x00%s=%v", k, entry.Data[k])
+	}
+	return b.String()
+}
+
+// flushRepeats writes the repeats pending under the current configuration.
+func flushRepeats() {
+	if d := currentConfig().dedup; d != nil {
+		d.flush()
+	}
+}
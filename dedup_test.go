@@ -0,0 +1,61 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDedup(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithDedup(time.Hour), WithSequenceNumbers())
+	defer Configure(WithDedup(0), func(c *config) { c.sequence = false })
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		Warn(ctx, "disk almost full", Field("disk", "sda"))
+	}
+	Warn(ctx, "disk almost full", Field("disk", "sdb"))
+	Info(ctx, "done")
+	Info(ctx, "done")
+	assert.NoError(t, Flush(ctx))
+
+	if assert.Len(t, sink.entries, 5) {
+		assert.NotContains(t, sink.entries[0].Data, RepeatCountKey)
+		assert.Equal(t, 3, sink.entries[1].Data[RepeatCountKey])
+		assert.Equal(t, "sda", sink.entries[1].Data["disk"])
+		assert.Equal(t, "sdb", sink.entries[2].Data["disk"])
+		assert.Equal(t, "done", sink.entries[3].Message)
+		assert.Equal(t, 1, sink.entries[4].Data[RepeatCountKey])
+		for i := 1; i < 5; i++ {
+			assert.Equal(t, sink.entries[i-1].Data[SequenceKey].(uint64)+1, sink.entries[i].Data[SequenceKey], i)
+		}
+	}
+}
+
+func TestWithDedupWindow(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithDedup(20 * time.Millisecond))
+	defer Configure(WithDedup(0))
+
+	Info(context.Background(), "tick")
+	Info(context.Background(), "tick")
+	assert.Eventually(t, func() bool {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return len(sink.entries) == 2
+	}, time.Second, 5*time.Millisecond)
+	Info(context.Background(), "tick")
+	sink.mu.Lock()
+	assert.Len(t, sink.entries, 3)
+	assert.NotContains(t, sink.entries[2].Data, RepeatCountKey)
+	sink.mu.Unlock()
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// devFormatter writes entries for reading during development: a line with
+// the time, a level badge, the message and the caller, then one line per
+// field with JSON values indented and multi-line strings, such as error
+// traces, continued under their key.
+type devFormatter struct{}
+
+func (f *devFormatter) Format(entry *Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	color := colored(entry)
+
+	writeColored(b, color, colorDim, entry.Time.Format("15:04:05.000"))
+	b.WriteByte(' ')
+	writeColored(b, color, levelColor(entry.Level), levelBadge(entry.Level))
+	b.WriteByte(' ')
+	b.WriteString(entry.Message)
+	if entry.HasCaller() {
+		b.WriteByte(' ')
+		writeColored(b, color, colorDim, fmt.Sprintf("(%s:%d)", shortPath(entry.Caller.File), entry.Caller.Line))
+	}
+	b.WriteByte('\n')
+
+	for _, k := range fieldKeys(entry) {
+		b.WriteString("    ")
+		writeColored(b, color, colorDim, k+":")
+		b.WriteByte(' ')
+		b.WriteString(devValue(entry.Data[k]))
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+// levelBadge returns the level in upper case, padded to five characters.
+func levelBadge(level Level) string {
+	if level == WarnLevel {
+		return "WARN "
+	}
+	return fmt.Sprintf("%-5.5s", strings.ToUpper(level.String()))
+}
+
+// shortPath returns the file name with its directory.
+func shortPath(file string) string {
+	dir, name := filepath.Split(file)
+	return filepath.Join(filepath.Base(dir), name)
+}
+
+// devValue formats a field value. JSON is indented under the key and the
+// lines of strings after the first are indented further.
+func devValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	default:
+		s = jsonString(v)
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(s), "    ", "  ") == nil {
+			return indented.String()
+		}
+	}
+	return strings.ReplaceAll(s, "\n", "\n      ")
+}
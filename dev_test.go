@@ -0,0 +1,49 @@
+package log
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevFormatter(t *testing.T) {
+	assert.Equal(t, DevFormatter, FormatterFromName("Dev"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"user":  "jane",
+		"order": map[string]interface{}{"id": 42, "items": []string{"a"}},
+		"err":   errors.New("failed\nat main.go:10"),
+		"n":     3,
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 120_000_000, time.UTC)
+	entry.Level = WarnLevel
+	entry.Message = "order failed"
+	got, err := new(devFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `07:08:09.120 WARN  order failed
+    err: failed
+      at main.go:10
+    n: 3
+    order: {
+      "id": 42,
+      "items": [
+        "a"
+      ]
+    }
+    user: jane
+`, string(got))
+
+	entry.Data = logrus.Fields{}
+	entry.Level = ErrorLevel
+	entry.Caller = &runtime.Frame{File: "/src/app/main.go", Line: 12}
+	entry.Logger.ReportCaller = true
+	Configure(WithColor(ColorAlways))
+	defer Configure(WithColor(ColorAuto))
+	got, err = new(devFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x1b[2m07:08:09.120\x1b[0m \x1b[31mERROR\x1b[0m order failed \x1b[2m(app/main.go:12)\x1b[0m\n", string(got))
+}
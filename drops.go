@@ -0,0 +1,104 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultDropSummaryInterval is how often a summary of dropped entries is logged.
+const DefaultDropSummaryInterval = time.Minute
+
+// Reasons for dropping entries, as reported in drop summaries.
+const (
+	dropQueueFull   = "queue full"
+	dropSampled     = "sampled"
+	dropRateLimited = "rate limited"
+)
+
+type dropKey struct {
+	reason string
+	level  Level
+}
+
+var (
+	dropsMu      sync.Mutex
+	drops        = map[dropKey]uint64{}
+	dropTotal    uint64
+	dropInterval = DefaultDropSummaryInterval
+	dropTimer    *time.Timer
+)
+
+// SetDropSummaryInterval sets how often a warning summarizing the entries
+// dropped by full queues, sampling or rate limiting is logged, such as "dropped
+// 1342 debug entries in last 1m0s". Zero disables the summary.
+func SetDropSummaryInterval(interval time.Duration) {
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	dropInterval = interval
+	if dropTimer != nil && interval <= 0 {
+		dropTimer.Stop()
+		dropTimer = nil
+	}
+}
+
+// DroppedEntries returns the number of entries dropped since the program
+// started.
+func DroppedEntries() uint64 {
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	return dropTotal
+}
+
+// countDrop records a dropped entry and schedules the next summary.
+func countDrop(reason string, level Level) {
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	drops[dropKey{reason: reason, level: level}]++
+	dropTotal++
+	if dropTimer == nil && dropInterval > 0 {
+		dropTimer = time.AfterFunc(dropInterval, summarizeDrops)
+	}
+}
+
+// summarizeDrops logs one warning per reason and level with the number of
+// entries dropped since the last summary.
+func summarizeDrops() {
+	dropsMu.Lock()
+	counts := drops
+	drops = map[dropKey]uint64{}
+	dropTimer = nil
+	interval := dropInterval
+	dropsMu.Unlock()
+
+	keys := make([]dropKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].reason != keys[j].reason {
+			return keys[i].reason < keys[j].reason
+		}
+		return keys[i].level < keys[j].level
+	})
+	for _, k := range keys {
+		entry := logger.WithFields(logrus.Fields{"dropped": counts[k], "dropped_level": k.level.String(), "reason": k.reason})
+		emit(entry, WarnLevel, fmt.Sprintf("dropped %d %s entries in last %v", counts[k], k.level, interval))
+	}
+}
+
+// stopDropSummary cancels the scheduled summary and logs it right away.
+func stopDropSummary() {
+	dropsMu.Lock()
+	pending := dropTimer != nil
+	if pending {
+		dropTimer.Stop()
+	}
+	dropsMu.Unlock()
+	if pending {
+		summarizeDrops()
+	}
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropSummary(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	SetDropSummaryInterval(time.Hour)
+	defer SetDropSummaryInterval(DefaultDropSummaryInterval)
+
+	dropsMu.Lock()
+	drops = map[dropKey]uint64{}
+	dropsMu.Unlock()
+	total := DroppedEntries()
+	countDrop(dropQueueFull, DebugLevel)
+	countDrop(dropQueueFull, DebugLevel)
+	countDrop(dropQueueFull, InfoLevel)
+	assert.Equal(t, total+3, DroppedEntries())
+	summarizeDrops()
+
+	assert.Len(t, sink.entries, 2)
+	assert.Equal(t, WarnLevel, sink.entries[0].Level)
+	assert.Equal(t, "dropped 1 info entries in last 1h0m0s", sink.entries[0].Message)
+	assert.Equal(t, "dropped 2 debug entries in last 1h0m0s", sink.entries[1].Message)
+	assert.Equal(t, "queue full", sink.entries[1].Data["reason"])
+
+	// nothing dropped, nothing to report
+	summarizeDrops()
+	assert.Len(t, sink.entries, 2)
+	Info(context.Background(), "Informational Message 1")
+	assert.Len(t, sink.entries, 3)
+}
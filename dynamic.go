@@ -0,0 +1,58 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// dynamicField is a field whose value is computed for each entry.
+type dynamicField struct {
+	key string
+	fn  func() interface{}
+	ttl time.Duration
+
+	mu      sync.Mutex
+	value   interface{}
+	expires time.Time
+}
+
+// WithDynamicField adds the key field to every entry, with the value fn
+// returns when the entry is logged, such as a queue depth or the number of
+// open connections. fn is called for every entry, from the goroutine logging
+// it, so it must be cheap and safe for concurrent use. Fields of a call or its
+// context take precedence, and fn is not called for entries that set the key.
+// A nil fn removes the field.
+func WithDynamicField(key string, fn func() interface{}) Option {
+	return WithCachedDynamicField(key, 0, fn)
+}
+
+// WithCachedDynamicField is WithDynamicField, reusing the value fn returned for
+// ttl before calling it again, for values that are costly to compute, such as
+// memory statistics.
+func WithCachedDynamicField(key string, ttl time.Duration, fn func() interface{}) Option {
+	return func(c *config) {
+		dynamic := make([]*dynamicField, 0, len(c.dynamic)+1)
+		for _, f := range c.dynamic {
+			if f.key != key {
+				dynamic = append(dynamic, f)
+			}
+		}
+		if fn != nil {
+			dynamic = append(dynamic, &dynamicField{key: key, fn: fn, ttl: ttl})
+		}
+		c.dynamic = dynamic
+	}
+}
+
+func (f *dynamicField) get() interface{} {
+	if f.ttl <= 0 {
+		return f.fn()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := time.Now(); now.After(f.expires) {
+		f.value = f.fn()
+		f.expires = now.Add(f.ttl)
+	}
+	return f.value
+}
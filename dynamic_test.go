@@ -0,0 +1,46 @@
+package log
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDynamicField(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(func(c *config) { c.dynamic = nil })
+
+	var depth, stats int64
+	Configure(
+		WithDynamicField("queue_depth", func() interface{} { return atomic.AddInt64(&depth, 1) }),
+		WithCachedDynamicField("mem", time.Hour, func() interface{} { return atomic.AddInt64(&stats, 1) }),
+	)
+	Info(ctx, "Informational Message 1")
+	Info(ctx, "Informational Message 2")
+	Info(ctx, "Informational Message 3", Field("queue_depth", 0))
+	Debug(ctx, "Debug Message 1")
+
+	assert.Equal(t, int64(1), sink.entries[0].Data["queue_depth"])
+	assert.Equal(t, int64(2), sink.entries[1].Data["queue_depth"])
+	assert.Equal(t, 0, sink.entries[2].Data["queue_depth"])
+	assert.Equal(t, int64(2), atomic.LoadInt64(&depth))
+	for _, e := range sink.entries {
+		assert.Equal(t, int64(1), e.Data["mem"])
+	}
+
+	Configure(
+		WithDynamicField("queue_depth", nil),
+		WithCachedDynamicField("mem", time.Nanosecond, func() interface{} { return "fresh" }),
+	)
+	time.Sleep(time.Millisecond)
+	Info(ctx, "Informational Message 4")
+	assert.NotContains(t, sink.entries[3].Data, "queue_depth")
+	assert.Equal(t, "fresh", sink.entries[3].Data["mem"])
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"fmt"
+	"time"
+)
+
+// ECSVersion is the Elastic Common Schema version ECS entries declare.
+const ECSVersion = "8.11.0"
+
+// ecsFormatter writes entries as Elastic Common Schema JSON: @timestamp,
+// log.level, message and ecs.version, the caller under log.origin, errors
+// under error and other fields under labels. Fields holding errors, and the
+// fields named error or err, are errors, with the type in error_type as added
+// by Err, and error_code is the error.code. Labels that are not strings,
+// numbers or booleans are written as their JSON encoding, since ECS labels
+// are flat.
+type ecsFormatter struct{}
+
+func (f *ecsFormatter) Format(entry *Entry) ([]byte, error) {
+	data := getFields()
+	defer putFields(data)
+	data["@timestamp"] = entry.Time.Format(time.RFC3339Nano)
+	data["log.level"] = entry.Level.String()
+	data["message"] = entry.Message
+	data["ecs.version"] = ECSVersion
+	if entry.HasCaller() {
+		data["log.origin"] = map[string]interface{}{
+			"file":     map[string]interface{}{"name": entry.Caller.File, "line": entry.Caller.Line},
+			"function": entry.Caller.Function,
+		}
+	}
+	labels := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			data["error"] = map[string]interface{}{"message": err.Error(), "type": fmt.Sprintf("%T", err)}
+			continue
+		}
+		if k == "error" || k == "err" {
+			if s, ok := v.(string); ok {
+				if _, set := data["error"]; !set {
+					e := map[string]interface{}{"message": s}
+					if typ, ok := entry.Data[ErrorTypeKey].(string); ok && k == ErrorKey {
+						e["type"] = typ
+					}
+					data["error"] = e
+				}
+				continue
+			}
+		}
+		if _, ok := entry.Data[ErrorKey].(string); ok && k == ErrorTypeKey {
+			continue
+		}
+		if _, ok := v.(string); ok && k == ErrorCodeKey {
+			continue
+		}
+		switch v.(type) {
+		case nil, string, bool, int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, float64, float32:
+			labels[k] = v
+		default:
+			labels[k] = jsonString(v)
+		}
+	}
+	if code, ok := entry.Data[ErrorCodeKey].(string); ok {
+		e, _ := data["error"].(map[string]interface{})
+		if e == nil {
+			e = map[string]interface{}{}
+			data["error"] = e
+		}
+		e["code"] = code
+	}
+	if len(labels) > 0 {
+		data["labels"] = labels
+	}
+	return writeJSONLine(entry, currentConfig(), data)
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestECSFormatter(t *testing.T) {
+	assert.Equal(t, ECSFormatter, FormatterFromName("ECS"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"user":  "jane",
+		"n":     3,
+		"order": map[string]int{"id": 42},
+		"err":   "timeout",
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 5, time.UTC)
+	entry.Level = ErrorLevel
+	entry.Message = "order failed"
+	got, err := new(ecsFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"@timestamp":"2024-05-06T07:08:09.000000005Z","ecs.version":"`+ECSVersion+`","error":{"message":"timeout"},`+
+		`"labels":{"n":3,"order":"{\"id\":42}","user":"jane"},"log.level":"error","message":"order failed"}`+"\n", string(got))
+
+	entry.Data = logrus.Fields{"cause": errors.New("refused")}
+	entry.Caller = &runtime.Frame{File: "/src/main.go", Line: 12, Function: "main.run"}
+	entry.Logger.ReportCaller = true
+	got, err = new(ecsFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"@timestamp":"2024-05-06T07:08:09.000000005Z","ecs.version":"`+ECSVersion+`","error":{"message":"refused","type":"*errors.errorString"},`+
+		`"log.level":"error","log.origin":{"file":{"line":12,"name":"/src/main.go"},"function":"main.run"},"message":"order failed"}`+"\n", string(got))
+
+	entry.Data = logrus.Fields{}
+	entry.Logger.ReportCaller = false
+	Err(fmt.Errorf("wrapped: %w", errors.New("refused"))).apply(entry.Data)
+	got, err = new(ecsFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"@timestamp":"2024-05-06T07:08:09.000000005Z","ecs.version":"`+ECSVersion+`","error":{"message":"wrapped: refused","type":"*fmt.wrapError"},`+
+		`"labels":{"error_chain":"[{\"message\":\"refused\",\"type\":\"*errors.errorString\"}]"},"log.level":"error","message":"order failed"}`+"\n", string(got))
+}
@@ -0,0 +1,226 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultElasticsearchIndex writes to one index per day.
+const DefaultElasticsearchIndex = "logs-{2006.01.02}"
+
+// ElasticsearchConfig configures an Elasticsearch sink.
+type ElasticsearchConfig struct {
+	// URL is the cluster base URL, e.g. http://localhost:9200.
+	URL string
+	// Index is the index name. Text within braces is a time layout applied to the
+	// entry time in UTC, so "logs-{2006.01.02}" writes daily indices. Defaults to
+	// DefaultElasticsearchIndex.
+	Index string
+	// Username and Password enable basic authentication.
+	Username string
+	Password string
+	// APIKey enables API key authentication.
+	APIKey string
+	// Formatter renders documents and defaults to JSON with an @timestamp key.
+	Formatter logrus.Formatter
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize bounds the entries waiting to be sent; the oldest are dropped
+	// beyond it. Defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// ElasticsearchSink buffers entries and writes them with the _bulk API.
+type ElasticsearchSink struct {
+	cfg     ElasticsearchConfig
+	url     string
+	header  http.Header
+	batcher *batcher
+}
+
+// bulkError reports documents the cluster rejected.
+type bulkError struct {
+	failed    int
+	retryable bool
+	reason    string
+}
+
+func (e *bulkError) Error() string {
+	return fmt.Sprintf("elasticsearch rejected %d documents: %s", e.failed, e.reason)
+}
+
+func (e *bulkError) Retryable() bool {
+	return e.retryable
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// NewElasticsearchSink creates an Elasticsearch sink.
+func NewElasticsearchSink(cfg ElasticsearchConfig) *ElasticsearchSink {
+	if cfg.Index == "" {
+		cfg.Index = DefaultElasticsearchIndex
+	}
+	if cfg.Formatter == nil {
+		cfg.Formatter = &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap:        logrus.FieldMap{logrus.FieldKeyTime: "@timestamp"},
+		}
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+
+	header := http.Header{"Content-Type": {"application/x-ndjson"}}
+	if cfg.APIKey != "" {
+		header.Set("Authorization", "ApiKey "+cfg.APIKey)
+	} else if cfg.Username != "" {
+		req := http.Request{Header: http.Header{}}
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+		header.Set("Authorization", req.Header.Get("Authorization"))
+	}
+
+	s := &ElasticsearchSink{cfg: cfg, url: strings.TrimSuffix(cfg.URL, "/") + "/_bulk", header: header}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *ElasticsearchSink) Write(entry *Entry) error {
+	doc, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{
+		key:  indexName(s.cfg.Index, entry.Time),
+		time: entry.Time,
+		data: bytes.TrimRight(doc, "\n"),
+	})
+	return nil
+}
+
+// indexName expands the time layouts in braces within template.
+func indexName(template string, t time.Time) string {
+	return expandTemplate(template, func(layout string) string {
+		return t.UTC().Format(layout)
+	})
+}
+
+// expandTemplate replaces every {name} in template by expand(name).
+func expandTemplate(template string, expand func(name string) string) string {
+	b := strings.Builder{}
+	for {
+		start := strings.IndexByte(template, '{')
+		end := strings.IndexByte(template, '}')
+		if start < 0 || end < start {
+			b.WriteString(template)
+			return b.String()
+		}
+		b.WriteString(template[:start])
+		b.WriteString(expand(template[start+1 : end]))
+		template = template[end+1:]
+	}
+}
+
+// send writes items with the bulk API. Documents rejected with 429 or 5xx are
+// retried; other rejections are dropped and reported. If retrying fails, only
+// the documents not yet indexed are handed back to the batcher.
+func (s *ElasticsearchSink) send(items []batchItem) error {
+	err := withRetries(s.cfg.MaxRetries, func() (err error) {
+		items, err = s.bulk(items)
+		return err
+	})
+	if err != nil {
+		return &partialSendError{items: items, err: err}
+	}
+	return nil
+}
+
+// bulk sends items once and returns those that should be retried.
+func (s *ElasticsearchSink) bulk(items []batchItem) ([]batchItem, error) {
+	body := bytes.Buffer{}
+	for _, item := range items {
+		body.WriteString(`{"index":{"_index":`)
+		body.WriteString(jsonString(item.key))
+		body.WriteString("}}\n")
+		body.Write(item.data)
+		body.WriteByte('\n')
+	}
+	data, err := postOnce(s.cfg.Client, s.url, s.header, body.Bytes())
+	if err != nil {
+		return items, err
+	}
+
+	var resp bulkResponse
+	if err = json.Unmarshal(data, &resp); err != nil {
+		// the request was accepted; resending it could index the documents
+		// twice
+		reportError(fmt.Errorf("elasticsearch: decoding bulk response: %w", err))
+		return nil, nil
+	}
+	if !resp.Errors {
+		return nil, nil
+	}
+	var (
+		retry, rejected []batchItem
+		reason          string
+	)
+	for i, result := range resp.Items {
+		for _, r := range result {
+			if r.Status < 300 || i >= len(items) {
+				continue
+			}
+			reason = string(r.Error)
+			if r.Status == http.StatusTooManyRequests || r.Status >= 500 {
+				retry = append(retry, items[i])
+			} else {
+				rejected = append(rejected, items[i])
+			}
+		}
+	}
+	if len(rejected) > 0 {
+		err := &bulkError{failed: len(rejected), reason: reason}
+		reportError(fmt.Errorf("elasticsearch: %w", s.batcher.drop(rejected, err)))
+	}
+	if len(retry) == 0 {
+		return nil, nil
+	}
+	return retry, &bulkError{failed: len(retry), retryable: true, reason: reason}
+}
+
+func (s *ElasticsearchSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *ElasticsearchSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *ElasticsearchSink) Status() SinkHealth {
+	return s.batcher.status()
+}
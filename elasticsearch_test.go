@@ -0,0 +1,106 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexName(t *testing.T) {
+	ts := time.Date(2024, 3, 7, 23, 0, 0, 0, time.FixedZone("X", -3600))
+	assert.Equal(t, "logs-2024.03.08", indexName(DefaultElasticsearchIndex, ts))
+	assert.Equal(t, "app-2024-03", indexName("app-{2006-01}", ts))
+	assert.Equal(t, "static", indexName("static", ts))
+}
+
+func TestElasticsearchSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests [][]string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, "ApiKey secret", r.Header.Get("Authorization"))
+		var lines []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		requests = append(requests, lines)
+		if len(requests) == 1 {
+			// reject the first document temporarily and the second permanently
+			_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":429}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}},{"index":{"status":201}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+
+	sink := NewElasticsearchSink(ElasticsearchConfig{URL: srv.URL, Index: "logs", APIKey: "secret"})
+	var reported []error
+	SetErrorHandler(func(err error) { reported = append(reported, err) })
+	defer SetErrorHandler(nil)
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("es", sink)
+	ctx := context.Background()
+	Info(ctx, "Informational Message 1")
+	Info(ctx, "Informational Message 2")
+	Info(ctx, "Informational Message 3")
+	assert.NoError(t, RemoveSink("es"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, requests, 2)
+	assert.Len(t, requests[0], 6)
+	assert.Equal(t, `{"index":{"_index":"logs"}}`, requests[0][0])
+	assert.True(t, strings.Contains(requests[0][1], `"@timestamp"`))
+	assert.Len(t, requests[1], 2)
+	assert.Contains(t, requests[1][1], "Informational Message 1")
+	assert.Len(t, reported, 1)
+	assert.Contains(t, reported[0].Error(), "mapper_parsing_exception")
+	assert.Equal(t, uint64(1), sink.Status().Dropped)
+}
+
+func TestElasticsearchSinkPartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":503}},{"index":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+
+	sink := NewElasticsearchSink(ElasticsearchConfig{URL: srv.URL, MaxRetries: -1})
+	defer sink.Close()
+	err := sink.send([]batchItem{{data: []byte(`{"n":1}`)}, {data: []byte(`{"n":2}`)}})
+	assert.Error(t, err)
+	failed := failedItems(nil, err)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, `{"n":1}`, string(failed[0].data))
+}
+
+func TestElasticsearchSinkUndecodableResponse(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`<html>proxy</html>`))
+	}))
+	defer srv.Close()
+	var reported []error
+	SetErrorHandler(func(err error) { reported = append(reported, err) })
+	defer SetErrorHandler(nil)
+
+	sink := NewElasticsearchSink(ElasticsearchConfig{URL: srv.URL, MaxRetries: 3})
+	defer sink.Close()
+	assert.NoError(t, sink.send([]batchItem{{data: []byte(`{"n":1}`)}}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Len(t, reported, 1)
+}
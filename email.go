@@ -0,0 +1,231 @@
+package log
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultEmailInterval is the default minimum time between two digest emails.
+const DefaultEmailInterval = 15 * time.Minute
+
+const emailMaxFrames = 32
+
+// EmailConfig configures an email sink.
+type EmailConfig struct {
+	// Addr is the SMTP server address, host:port.
+	Addr string
+	// Username and Password enable PLAIN authentication.
+	Username string
+	Password string
+	// From and To are the envelope and header addresses.
+	From string
+	To   []string
+	// Subject prefixes the subject line and defaults to the host name.
+	Subject string
+	// Interval is the minimum time between two emails. Entries within it are
+	// collected into the next digest. Defaults to DefaultEmailInterval.
+	Interval time.Duration
+	// Timeout bounds sending an email, from dialing to the end of the
+	// session, and defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+type emailEntry struct {
+	time    time.Time
+	level   Level
+	message string
+	fields  string
+	stack   string
+}
+
+// EmailSink emails digests of Fatal and Panic entries, including the stack of the
+// logging call, at most once per interval. Lower levels are ignored. Entries held
+// back by the rate limit are sent when the interval has passed, or on Flush and
+// Close regardless of it. A Fatal entry is always sent at once, together with
+// anything held back, since the process exits right after it is logged.
+type EmailSink struct {
+	cfg      EmailConfig
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	mu      sync.Mutex
+	pending []emailEntry
+	last    time.Time
+	timer   *time.Timer
+}
+
+// NewEmailSink creates an email sink.
+func NewEmailSink(cfg EmailConfig) *EmailSink {
+	if cfg.Subject == "" {
+		cfg.Subject, _ = os.Hostname()
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultEmailInterval
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	}
+	s := &EmailSink{cfg: cfg}
+	s.sendMail = s.smtpSendMail
+	return s
+}
+
+func (s *EmailSink) Write(entry *Entry) error {
+	if entry.Level > FatalLevel {
+		return nil
+	}
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := strings.Builder{}
+	for _, k := range keys {
+		fmt.Fprintf(&fields, "  %s=%s\n", k, stringValue(entry.Data[k]))
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, emailEntry{
+		time:    entry.Time,
+		level:   entry.Level,
+		message: entry.Message,
+		fields:  fields.String(),
+		stack:   formatStack(loggingStack(entry, 1, emailMaxFrames)),
+	})
+	wait := s.cfg.Interval - time.Since(s.last)
+	if wait <= 0 || entry.Level == FatalLevel {
+		if s.timer != nil {
+			s.timer.Stop()
+			s.timer = nil
+		}
+		msg := s.digest()
+		s.mu.Unlock()
+		return s.send(msg)
+	}
+	if s.timer == nil {
+		s.timer = time.AfterFunc(wait, func() {
+			s.mu.Lock()
+			s.timer = nil
+			msg := s.digest()
+			s.mu.Unlock()
+			if err := s.send(msg); err != nil {
+				reportError(fmt.Errorf("email: %w", err))
+			}
+		})
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// digest takes the pending entries and returns them as one email, or nil if
+// there are none. It must be called with mu held.
+func (s *EmailSink) digest() []byte {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	entries := s.pending
+	s.pending = nil
+	s.last = time.Now()
+
+	body := strings.Builder{}
+	for i, e := range entries {
+		if i > 0 {
+			body.WriteString("\n")
+		}
+		fmt.Fprintf(&body, "%s %s: %s\n%s\n%s", e.time.Format(time.RFC3339), strings.ToUpper(e.level.String()), e.message, e.fields, e.stack)
+	}
+	subject := fmt.Sprintf("%s: %s", s.cfg.Subject, entries[0].message)
+	if len(entries) > 1 {
+		subject = fmt.Sprintf("%s: %d critical failures", s.cfg.Subject, len(entries))
+	}
+	msg := "From: " + s.cfg.From + "\r\n" +
+		"To: " + strings.Join(s.cfg.To, ", ") + "\r\n" +
+		"Subject: " + strings.NewReplacer("\r", " ", "\n", " ").Replace(subject) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+		strings.ReplaceAll(body.String(), "\n", "\r\n")
+	return []byte(msg)
+}
+
+// send mails a digest, if there is one. It is called without mu held, so a
+// slow server does not block logging.
+func (s *EmailSink) send(msg []byte) error {
+	if msg == nil {
+		return nil
+	}
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(s.cfg.Addr)
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+	return s.sendMail(s.cfg.Addr, auth, s.cfg.From, s.cfg.To, msg)
+}
+
+// smtpSendMail is smtp.SendMail with the configured timeout.
+func (s *EmailSink) smtpSendMail(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	conn, err := net.DialTimeout("tcp", addr, s.cfg.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(s.cfg.Timeout)); err != nil {
+		return err
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if err := c.Auth(a); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Flush mails pending entries without waiting for the interval to pass.
+func (s *EmailSink) Flush() error {
+	s.mu.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	msg := s.digest()
+	s.mu.Unlock()
+	return s.send(msg)
+}
+
+func (s *EmailSink) Close() error {
+	return s.Flush()
+}
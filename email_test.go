@@ -0,0 +1,66 @@
+package log
+
+import (
+	"net"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailSink(t *testing.T) {
+	var messages []string
+	sink := NewEmailSink(EmailConfig{Addr: "localhost:25", From: "app@example.com", To: []string{"ops@example.com"}, Subject: "app"})
+	sink.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "localhost:25", addr)
+		assert.Equal(t, []string{"ops@example.com"}, to)
+		messages = append(messages, string(msg))
+		return nil
+	}
+
+	assert.NoError(t, sink.Write(&Entry{Level: ErrorLevel, Message: "Error Message 1", Time: time.Now()}))
+	assert.NoError(t, sink.Write(&Entry{Level: FatalLevel, Message: "Fatal Message 1", Time: time.Now(), Data: logrus.Fields{"field1": "value1"}}))
+	assert.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "Subject: app: Fatal Message 1\r\n")
+	assert.Contains(t, messages[0], "field1=value1")
+	assert.Contains(t, messages[0], "testing.tRunner")
+
+	// panics are rate limited until flushed
+	assert.NoError(t, sink.Write(&Entry{Level: PanicLevel, Message: "Panic Message 1", Time: time.Now()}))
+	assert.NoError(t, sink.Write(&Entry{Level: PanicLevel, Message: "Panic Message 2", Time: time.Now()}))
+	assert.Len(t, messages, 1)
+	assert.NoError(t, sink.Close())
+	assert.Len(t, messages, 2)
+	assert.Contains(t, messages[1], "Subject: app: 2 critical failures\r\n")
+
+	// a fatal entry is sent at once, with whatever is held back
+	assert.NoError(t, sink.Write(&Entry{Level: PanicLevel, Message: "Panic Message 3", Time: time.Now()}))
+	assert.NoError(t, sink.Write(&Entry{Level: FatalLevel, Message: "Fatal Message 2", Time: time.Now()}))
+	assert.Len(t, messages, 3)
+	assert.Contains(t, messages[2], "Panic Message 3")
+	assert.Contains(t, messages[2], "Fatal Message 2")
+	assert.NotContains(t, messages[2], "\r\r")
+	assert.NoError(t, sink.Close())
+	assert.Len(t, messages, 3)
+}
+
+func TestEmailSinkTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		// accept, but never greet
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	sink := NewEmailSink(EmailConfig{Addr: ln.Addr().String(), To: []string{"ops@example.com"}, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	assert.Error(t, sink.Write(&Entry{Level: FatalLevel, Message: "Fatal Message 1", Time: start}))
+	assert.True(t, time.Since(start) < time.Second)
+}
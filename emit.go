@@ -0,0 +1,121 @@
+package log
+
+import (
+	"context"
+	"fmt"
+)
+
+// logged reports whether an entry of the level logged with ctx goes anywhere:
+// to the output, the flight recorder or a debug buffer. The logging functions
+// check it before building fields so disabled levels cost no allocations.
+func logged(ctx context.Context, level Level) bool {
+	return logger.IsLevelEnabled(level) || currentRecorder() != nil || debugBufferFrom(ctx) != nil
+}
+
+// prepare normalizes keys, moves fields with reserved keys, formats times,
+// scrubs the entry and applies the size limits, returning the message to log.
+func (c *config) prepare(entry *Entry, msg string) string {
+	if c.keyCase != KeysAsIs {
+		c.normalizeKeys(entry)
+	}
+	if c.collisions != Overwrite {
+		c.renameReserved(entry)
+	}
+	if c.durationFormat != DurationNanos || c.timeFormat != "" {
+		c.formatTimes(entry)
+	}
+	if c.scrubs() {
+		msg = c.scrubEntry(entry, msg)
+	}
+	if c.limits != nil {
+		msg = c.limits.apply(entry, msg)
+	}
+	return msg
+}
+
+// emit is the common path of the logging functions below Fatal.
+func emit(entry *Entry, level Level, args ...interface{}) {
+	limit := takeRateLimit(entry)
+	enabled := logger.IsLevelEnabled(level)
+	recorder := currentRecorder()
+	buffer := debugBufferFrom(entry.Context)
+	if !enabled && recorder == nil && buffer == nil {
+		return
+	}
+	cfg := currentConfig()
+	entry.Time = cfg.now()
+	if cfg.caller {
+		entry.Context = withCaller(entry.Context, 2)
+	}
+	if cfg.goroutineID {
+		entry.Data[GoroutineKey] = goroutineID()
+	}
+	cfg.attachStack(entry, level, 1)
+	if len(args) == 1 {
+		addErrorFields(entry, args[0])
+	}
+	msg := fmt.Sprint(args...)
+	msg = cfg.prepare(entry, msg)
+	if recorder != nil {
+		recorder.record(entry, level, msg)
+	}
+	if !enabled {
+		if buffer != nil && level >= DebugLevel {
+			buffer.add(entry, level, msg)
+		}
+		return
+	}
+	if s := cfg.samplers[level]; s != nil && !s.sample(msg) {
+		countDrop(dropSampled, level)
+		return
+	}
+	fraction, ok := cfg.fractions[level]
+	if !ok {
+		fraction = 1
+	}
+	if cfg.adaptive != nil && level > WarnLevel {
+		fraction *= cfg.adaptive.current()
+	}
+	if fraction < 1 {
+		if random() >= fraction {
+			countDrop(dropSampled, level)
+			return
+		}
+		entry = entry.WithField(SampleRateKey, fraction)
+	}
+	if limit != nil {
+		ok, suppressed := limit.allow()
+		if !ok {
+			countDrop(dropRateLimited, level)
+			return
+		}
+		if suppressed > 0 {
+			entry = entry.WithField(SuppressedKey, suppressed)
+		}
+	}
+	if cfg.dedup != nil && cfg.dedup.repeated(entry, level, msg) {
+		return
+	}
+	if buffer != nil && level <= ErrorLevel {
+		buffer.flush()
+	}
+	if cfg.adaptive != nil {
+		cfg.adaptive.add(entry, msg)
+	}
+	if cfg.sequence {
+		entry.Data[SequenceKey] = nextSequence()
+	}
+	write(entry, level, msg)
+}
+
+// write hands the entry to the async queue, or logs it if there is none.
+// The allowlist is applied again so that the fields added after prepare,
+// such as sample_rate or seq, are filtered too.
+func write(entry *Entry, level Level, msg string) {
+	if cfg := currentConfig(); cfg.allowedKeys != nil {
+		cfg.dropDisallowed(entry)
+	}
+	if !enqueue(entry, level, msg) {
+		entry.Log(level, msg)
+	}
+}
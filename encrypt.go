@@ -0,0 +1,97 @@
+package log
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptedPrefix starts the values of encrypted fields.
+const EncryptedPrefix = "aesgcm:"
+
+// WithEncryptionKey sets the AES key, of 16, 24 or 32 bytes, that fields made
+// with Encrypted are encrypted with. Without a valid key their values are
+// redacted.
+func WithEncryptionKey(key []byte) Option {
+	return func(c *config) {
+		aead, err := newAEAD(key)
+		if err != nil {
+			reportError(fmt.Errorf("encryption key: %w", err))
+		}
+		c.aead = aead
+	}
+}
+
+// Encrypted is a field whose value is encrypted with AES-GCM under the key set
+// with WithEncryptionKey before anything sees it. Decrypt recovers the value.
+func Encrypted(key string, value interface{}) Fld {
+	return &fld{key: key, value: &encryptedValue{value: value}}
+}
+
+// encryptedValue is a value to encrypt. It prints as redacted should it reach
+// a formatter unencrypted.
+type encryptedValue struct {
+	value interface{}
+}
+
+func (e *encryptedValue) String() string {
+	return RedactedValue
+}
+
+func (e *encryptedValue) MarshalJSON() ([]byte, error) {
+	return appendJSONString(nil, RedactedValue), nil
+}
+
+// encrypt returns the encrypted value, or RedactedValue without a key.
+func (c *config) encrypt(e *encryptedValue) string {
+	if c.aead == nil {
+		return RedactedValue
+	}
+	plaintext, ok := e.value.(string)
+	if !ok {
+		plaintext = jsonString(e.value)
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return RedactedValue
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+// Decrypt returns the value of a field made with Encrypted, as a string or its
+// JSON encoding.
+func Decrypt(key []byte, value string) (string, error) {
+	if !strings.HasPrefix(value, EncryptedPrefix) {
+		return "", errors.New("not an encrypted value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(EncryptedPrefix):])
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
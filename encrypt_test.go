@@ -0,0 +1,52 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypted(t *testing.T) {
+	Init(SimpleFormatter, InfoLevel)
+	defer Init(JSONFormatter, InfoLevel)
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(os.Stderr)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	// without a key the value never shows
+	Info(context.Background(), "payment", Encrypted("card", "4111111111111111"))
+	assert.Equal(t, "payment | card=\"[REDACTED]\"\n", out.String())
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	Configure(WithEncryptionKey(key))
+	defer Configure(func(c *config) { c.aead = nil })
+	Info(context.Background(), "payment", Encrypted("card", "4111111111111111"), Encrypted("meta", map[string]int{"cvv": 123}))
+
+	card := sink.entries[1].Data["card"].(string)
+	assert.True(t, strings.HasPrefix(card, EncryptedPrefix))
+	plain, err := Decrypt(key, card)
+	assert.NoError(t, err)
+	assert.Equal(t, "4111111111111111", plain)
+	plain, err = Decrypt(key, sink.entries[1].Data["meta"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"cvv":123}`, plain)
+
+	_, err = Decrypt([]byte("fedcba9876543210fedcba9876543210"), card)
+	assert.Error(t, err)
+	_, err = Decrypt(key, "4111111111111111")
+	assert.Error(t, err)
+
+	var reported error
+	SetErrorHandler(func(err error) { reported = err })
+	defer SetErrorHandler(nil)
+	Configure(WithEncryptionKey([]byte("short")))
+	assert.Error(t, reported)
+	assert.Nil(t, currentConfig().aead)
+}
@@ -0,0 +1,205 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The fields Err records an error in.
+const (
+	// ErrorKey holds the message of the error.
+	ErrorKey = "error"
+	// ErrorTypeKey holds the Go type of the error, such as "*fs.PathError".
+	ErrorTypeKey = "error_type"
+	// ErrorChainKey holds the errors it wraps, outermost first, as a list of
+	// objects with the message and type of each.
+	ErrorChainKey = "error_chain"
+	// ErrorCodeKey holds the code of the error, a stable identifier such as
+	// "payment_declined" to alert and build dashboards on.
+	ErrorCodeKey = "error_code"
+	// ErrorCategoryKey holds the broader class of the error, such as
+	// "validation" or "dependency".
+	ErrorCategoryKey = "error_category"
+	// ErrorStackKey holds the stack recorded by the error where it was created,
+	// such as by github.com/pkg/errors, in the format of a goroutine trace. The
+	// logging functions add it too when they are passed such an error as the
+	// message.
+	ErrorStackKey = "error_stack"
+	// ErrorsKey holds the errors an error in the chain aggregates, such as one
+	// from errors.Join or hashicorp/go-multierror, as a list of objects with
+	// the message and type of each. The logging functions add it too when
+	// they are passed such an error as the message.
+	ErrorsKey = "errors"
+)
+
+// Coder is implemented by errors that carry a code. Err records the code of
+// the first error in the chain that has one.
+type Coder interface {
+	Code() string
+}
+
+// Categorizer is implemented by errors that carry a category. Err records the
+// category of the first error in the chain that has one.
+type Categorizer interface {
+	Category() string
+}
+
+// ErrCode is the error_code field, for entries about errors without a Coder.
+func ErrCode(code string) Fld {
+	return &fld{key: ErrorCodeKey, value: code}
+}
+
+// ErrCategory is the error_category field, for entries about errors without
+// a Categorizer.
+func ErrCategory(category string) Fld {
+	return &fld{key: ErrorCategoryKey, value: category}
+}
+
+// Err records err as structured fields: its message, its type, its code and
+// category if an error in the chain is a Coder or Categorizer and, if it
+// wraps other errors, the chain errors.Unwrap walks, where Field would only
+// keep the message. The stack an error in the chain recorded is added as
+// well, see errorStack, and the errors it aggregates, see ErrorsKey. A nil
+// error adds no fields.
+func Err(err error) Fld {
+	if err == nil {
+		return Bound{}
+	}
+	fields := logrus.Fields{
+		ErrorKey:     err.Error(),
+		ErrorTypeKey: fmt.Sprintf("%T", err),
+	}
+	keys := []string{ErrorKey, ErrorTypeKey}
+	var coder Coder
+	if errors.As(err, &coder) {
+		fields[ErrorCodeKey] = coder.Code()
+		keys = append(keys, ErrorCodeKey)
+	}
+	var categorizer Categorizer
+	if errors.As(err, &categorizer) {
+		fields[ErrorCategoryKey] = categorizer.Category()
+		keys = append(keys, ErrorCategoryKey)
+	}
+	if stack := errorStack(err); stack != "" {
+		fields[ErrorStackKey] = stack
+		keys = append(keys, ErrorStackKey)
+	}
+	var chain []interface{}
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		chain = append(chain, map[string]interface{}{
+			"message": cause.Error(),
+			"type":    fmt.Sprintf("%T", cause),
+		})
+	}
+	if chain != nil {
+		fields[ErrorChainKey] = chain
+		keys = append(keys, ErrorChainKey)
+	}
+	if errs := aggregatedErrors(err); errs != nil {
+		fields[ErrorsKey] = errs
+		keys = append(keys, ErrorsKey)
+	}
+	return Bound{fields: fields, keys: keys}
+}
+
+// WithError is Err, named like logrus's Entry.WithError.
+func WithError(err error) Fld {
+	return Err(err)
+}
+
+// addErrorFields adds the error_stack field if v is an error that recorded a
+// stack, and the errors field if it aggregates errors.
+func addErrorFields(entry *Entry, v interface{}) {
+	if err, ok := v.(error); ok {
+		if stack := errorStack(err); stack != "" {
+			entry.Data[ErrorStackKey] = stack
+		}
+		if errs := aggregatedErrors(err); errs != nil {
+			entry.Data[ErrorsKey] = errs
+		}
+	}
+}
+
+// aggregatedErrors returns the message and type of each error aggregated by
+// the first error in the chain with an Unwrap method returning a slice, as
+// errors.Join returns, or a WrappedErrors method, as go-multierror has.
+func aggregatedErrors(err error) []interface{} {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		var errs []error
+		switch e := e.(type) {
+		case interface{ Unwrap() []error }:
+			errs = e.Unwrap()
+		case interface{ WrappedErrors() []error }:
+			errs = e.WrappedErrors()
+		default:
+			continue
+		}
+		list := make([]interface{}, 0, len(errs))
+		for _, e := range errs {
+			if e != nil {
+				list = append(list, map[string]interface{}{
+					"message": e.Error(),
+					"type":    fmt.Sprintf("%T", e),
+				})
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// errorStack returns the stack recorded by the innermost error in the chain
+// with a StackTrace method returning program counters, as errors of
+// github.com/pkg/errors have, or else the part of the %+v formatting of err
+// that follows its message, if it has one.
+func errorStack(err error) string {
+	var pcs []uintptr
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if p := stackTrace(e); len(p) > 0 {
+			pcs = p
+		}
+	}
+	if pcs != nil {
+		var frames []runtime.Frame
+		iter := runtime.CallersFrames(pcs)
+		for {
+			f, more := iter.Next()
+			frames = append(frames, f)
+			if !more {
+				break
+			}
+		}
+		return formatStack(frames)
+	}
+	if _, ok := err.(fmt.Formatter); ok {
+		msg := err.Error()
+		if s := fmt.Sprintf("%+v", err); strings.HasPrefix(s, msg+"\n") {
+			return strings.TrimLeft(s[len(msg):], "\n")
+		}
+	}
+	return ""
+}
+
+// stackTrace calls the StackTrace method of err, if it has one returning a
+// slice of program counters, such as errors.StackTrace of pkg/errors.
+func stackTrace(err error) []uintptr {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() {
+		return nil
+	}
+	t := m.Type()
+	if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Slice || t.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	trace := m.Call(nil)[0]
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	return pcs
+}
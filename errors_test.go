@@ -0,0 +1,175 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErr(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist}
+	err := fmt.Errorf("loading config: %w", pathErr)
+
+	fields := logrus.Fields{}
+	Err(err).apply(fields)
+	assert.Equal(t, logrus.Fields{
+		"error":      "loading config: open /x: file does not exist",
+		"error_type": "*fmt.wrapError",
+		"error_chain": []interface{}{
+			map[string]interface{}{"message": "open /x: file does not exist", "type": "*fs.PathError"},
+			map[string]interface{}{"message": "file does not exist", "type": "*errors.errorString"},
+		},
+	}, fields)
+
+	fields = logrus.Fields{}
+	WithError(errors.New("plain")).apply(fields)
+	assert.Equal(t, logrus.Fields{"error": "plain", "error_type": "*errors.errorString"}, fields)
+
+	fields = logrus.Fields{}
+	Err(nil).apply(fields)
+	assert.Empty(t, fields)
+
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(WithFieldOrder(SortedFields))
+	Configure(WithFieldOrder(InsertionOrder))
+	Error(context.Background(), "failed", Field("id", 1), Err(err))
+	assert.Equal(t, []string{"id", "error", "error_type", "error_chain"}, fieldKeys(sink.entries[0]))
+}
+
+type declinedError struct{}
+
+func (declinedError) Error() string    { return "card declined" }
+func (declinedError) Code() string     { return "payment_declined" }
+func (declinedError) Category() string { return "payment" }
+
+func TestErrCode(t *testing.T) {
+	fields := logrus.Fields{}
+	Err(fmt.Errorf("charging: %w", declinedError{})).apply(fields)
+	assert.Equal(t, "payment_declined", fields[ErrorCodeKey])
+	assert.Equal(t, "payment", fields[ErrorCategoryKey])
+
+	fields = logrus.Fields{}
+	Err(errors.New("plain")).apply(fields)
+	assert.NotContains(t, fields, ErrorCodeKey)
+	assert.NotContains(t, fields, ErrorCategoryKey)
+
+	ErrCode("timeout").apply(fields)
+	ErrCategory("dependency").apply(fields)
+	assert.Equal(t, "timeout", fields[ErrorCodeKey])
+	assert.Equal(t, "dependency", fields[ErrorCategoryKey])
+
+	entry := logrus.NewEntry(logrus.New())
+	Err(declinedError{}).apply(entry.Data)
+	got, err := new(ecsFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), `"error":{"code":"payment_declined","message":"card declined","type":"log.declinedError"}`)
+	assert.Contains(t, string(got), `"labels":{"error_category":"payment"}`)
+}
+
+// pkgFrame and pkgStack mimic the stack types of github.com/pkg/errors.
+type pkgFrame uintptr
+
+type pkgStack []pkgFrame
+
+type pkgError struct {
+	msg   string
+	stack []uintptr
+}
+
+func newPkgError(msg string) error {
+	pcs := make([]uintptr, 32)
+	return &pkgError{msg: msg, stack: pcs[:runtime.Callers(2, pcs)]}
+}
+
+func (e *pkgError) Error() string { return e.msg }
+
+func (e *pkgError) StackTrace() pkgStack {
+	st := make(pkgStack, len(e.stack))
+	for i, pc := range e.stack {
+		st[i] = pkgFrame(pc)
+	}
+	return st
+}
+
+type verboseError struct{}
+
+func (verboseError) Error() string { return "verbose" }
+
+func (e verboseError) Format(s fmt.State, verb rune) {
+	if s.Flag('+') {
+		fmt.Fprint(s, "verbose\nmain.f\n\tmain.go:1\n")
+		return
+	}
+	fmt.Fprint(s, "verbose")
+}
+
+func TestErrorStack(t *testing.T) {
+	origin := newPkgError("origin")
+	fields := logrus.Fields{}
+	Err(fmt.Errorf("outer: %w", origin)).apply(fields)
+	stack := fields[ErrorStackKey].(string)
+	assert.True(t, strings.HasPrefix(stack, "github.com/andyday/go-log.TestErrorStack\n\t"), stack)
+	assert.Contains(t, stack, "errors_test.go:")
+
+	fields = logrus.Fields{}
+	Err(verboseError{}).apply(fields)
+	assert.Equal(t, "main.f\n\tmain.go:1\n", fields[ErrorStackKey])
+
+	fields = logrus.Fields{}
+	Err(errors.New("plain")).apply(fields)
+	assert.NotContains(t, fields, ErrorStackKey)
+
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Error(context.Background(), origin)
+	assert.Equal(t, stack, sink.entries[0].Data[ErrorStackKey])
+}
+
+// wrappedErrors mimics the Error type of hashicorp/go-multierror.
+type wrappedErrors struct {
+	errs []error
+}
+
+func (e *wrappedErrors) Error() string          { return fmt.Sprintf("%d errors occurred", len(e.errs)) }
+func (e *wrappedErrors) WrappedErrors() []error { return e.errs }
+
+func TestAggregatedErrors(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist}
+	joined := multiError{pathErr, errors.New("plain")}
+	want := []interface{}{
+		map[string]interface{}{"message": "open /x: file does not exist", "type": "*fs.PathError"},
+		map[string]interface{}{"message": "plain", "type": "*errors.errorString"},
+	}
+
+	fields := logrus.Fields{}
+	Err(fmt.Errorf("closing: %w", joined)).apply(fields)
+	assert.Equal(t, want, fields[ErrorsKey])
+
+	fields = logrus.Fields{}
+	Err(&wrappedErrors{errs: []error{pathErr, nil, errors.New("plain")}}).apply(fields)
+	assert.Equal(t, want, fields[ErrorsKey])
+
+	fields = logrus.Fields{}
+	Err(errors.New("plain")).apply(fields)
+	assert.NotContains(t, fields, ErrorsKey)
+
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Error(context.Background(), joined)
+	assert.Equal(t, "open /x: file does not exist; plain", sink.entries[0].Message)
+	assert.Equal(t, want, sink.entries[0].Data[ErrorsKey])
+}
@@ -0,0 +1,93 @@
+//go:build windows
+// +build windows
+
+package log
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Event IDs reported to the Windows Event Log, one per severity.
+const (
+	EventIDInfo    uint32 = 1
+	EventIDWarning uint32 = 2
+	EventIDError   uint32 = 3
+)
+
+func init() {
+	RegisterSink("eventlog", func(options map[string]string) (Sink, error) {
+		return NewEventLogSink(options["source"])
+	})
+}
+
+// EventLogSink writes Error, Warn and Info entries to the Windows Event Log.
+// Debug and Trace entries are ignored.
+type EventLogSink struct {
+	log *eventlog.Log
+}
+
+// InstallEventLogSource registers source with the Event Log so its entries
+// render without "description not found" warnings. It requires administrator
+// rights and is typically run by an installer.
+func InstallEventLogSource(source string) error {
+	return eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+}
+
+// RemoveEventLogSource removes a source registered by InstallEventLogSource.
+func RemoveEventLogSource(source string) error {
+	return eventlog.Remove(source)
+}
+
+// NewEventLogSink opens the Event Log for the registered source name.
+func NewEventLogSink(source string) (*EventLogSink, error) {
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &EventLogSink{log: l}, nil
+}
+
+func (s *EventLogSink) Write(entry *Entry) error {
+	switch entry.Level {
+	case PanicLevel, FatalLevel, ErrorLevel:
+		return s.log.Error(EventIDError, eventLogMessage(entry))
+	case WarnLevel:
+		return s.log.Warning(EventIDWarning, eventLogMessage(entry))
+	case InfoLevel:
+		return s.log.Info(EventIDInfo, eventLogMessage(entry))
+	}
+	return nil
+}
+
+func (s *EventLogSink) Flush() error {
+	return nil
+}
+
+func (s *EventLogSink) Close() error {
+	return s.log.Close()
+}
+
+// eventLogMessage renders the message followed by one key=value line per field.
+func eventLogMessage(entry *Entry) string {
+	if len(entry.Data) == 0 {
+		return entry.Message
+	}
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := strings.Builder{}
+	b.WriteString(entry.Message)
+	for _, k := range keys {
+		b.WriteString("\r\n")
+		b.WriteString(k)
+		b.WriteRune('=')
+		b.WriteString(stringValue(entry.Data[k]))
+	}
+	return b.String()
+}
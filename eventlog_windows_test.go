@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package log
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLogMessage(t *testing.T) {
+	entry := &Entry{Message: "Error Message 1", Data: logrus.Fields{"b": 2, "a": "apple"}}
+	assert.Equal(t, "Error Message 1\r\na=apple\r\nb=2", eventLogMessage(entry))
+}
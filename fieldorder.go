@@ -0,0 +1,86 @@
+package log
+
+import (
+	"context"
+	"fmt"
+)
+
+// FieldOrder is the order the simple formatter writes fields in.
+type FieldOrder int
+
+const (
+	// SortedFields writes fields sorted by key.
+	SortedFields FieldOrder = iota
+	// InsertionOrder writes the context fields in the order given to Init and
+	// to ContextWithFields, then the fields of the call in the order they
+	// were passed, then fields added while processing the entry, such as
+	// sample_rate, sorted by key.
+	InsertionOrder
+)
+
+// fieldOrderKey is the context key of the field keys of an entry in insertion
+// order.
+type fieldOrderKey struct{}
+
+// WithFieldOrder sets the order the simple formatter writes fields in,
+// SortedFields by default.
+func WithFieldOrder(order FieldOrder) Option {
+	return func(c *config) {
+		c.fieldOrder = order
+	}
+}
+
+// withFieldOrder returns ctx with the keys of the fields in the order they
+// are added to an entry.
+func withFieldOrder(ctx context.Context, flds []Fld) context.Context {
+	bound := contextFields(ctx)
+	keys := make([]string, 0, len(ctxFields)+len(bound.keys)+len(flds))
+	for _, f := range ctxFields {
+		if ctx.Value(f) != nil {
+			keys = append(keys, fmt.Sprintf("%v", f))
+		}
+	}
+	keys = append(keys, bound.keys...)
+	for _, f := range flds {
+		switch f := f.(type) {
+		case *fld:
+			keys = append(keys, f.key)
+		case Bound:
+			keys = append(keys, f.keys...)
+		}
+	}
+	return context.WithValue(ctx, fieldOrderKey{}, keys)
+}
+
+// fieldKeys returns the keys of the fields of the entry in the configured
+// order. The keys recorded by withFieldOrder are normalized like the fields
+// themselves.
+func fieldKeys(entry *Entry) []string {
+	keys := make([]string, 0, len(entry.Data))
+	if entry.Context != nil {
+		if order, ok := entry.Context.Value(fieldOrderKey{}).([]string); ok {
+			keyCase := currentConfig().keyCase
+			seen := make(map[string]bool, len(order))
+			for _, k := range order {
+				k = normalizeKey(k, keyCase)
+				if _, ok := entry.Data[k]; ok && !seen[k] {
+					keys = append(keys, k)
+					seen[k] = true
+				}
+			}
+			rest := len(keys)
+			for k := range entry.Data {
+				if !seen[k] {
+					keys = append(keys, k)
+				}
+			}
+			sortStrings(keys[rest:])
+			return keys
+		}
+	}
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sortStrings(keys)
+	return keys
+}
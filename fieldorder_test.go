@@ -0,0 +1,46 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldOrder(t *testing.T) {
+	Init(SimpleFormatter, InfoLevel, key("requestId"))
+	defer Init(JSONFormatter, InfoLevel)
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(os.Stderr)
+	ctx := context.WithValue(context.Background(), key("requestId"), "r1")
+	bound := Prebind(Field("z", 1), Field("m", 2))
+
+	for i := 0; i < 5; i++ {
+		Info(ctx, "sorted", Field("c", 3), Field("b", "x"), bound, Field("a", 1))
+	}
+	line := "sorted | a=1 | b=x | c=3 | m=2 | requestId=r1 | z=1\n"
+	assert.Equal(t, line+line+line+line+line, out.String())
+
+	Configure(WithFieldOrder(InsertionOrder))
+	defer Configure(WithFieldOrder(SortedFields))
+	out.Reset()
+	Info(ctx, "inserted", Field("c", 3), Field("b", "x"), bound, Field("a", 1))
+	Info(context.Background(), "inserted", Field("c", 3), Field("b", "x"))
+	assert.Equal(t, "inserted | requestId=r1 | c=3 | b=x | z=1 | m=2 | a=1\ninserted | c=3 | b=x\n", out.String())
+
+	// fields added later follow, sorted
+	entry := newEntry(context.Background(), []Fld{Field("b", 1), Field("a", 2)})
+	entry.Data[SampleRateKey] = 0.5
+	entry.Data[RepeatCountKey] = 2
+	assert.Equal(t, []string{"b", "a", RepeatCountKey, SampleRateKey}, fieldKeys(entry))
+
+	// normalized keys keep their place
+	Configure(WithKeyCase(SnakeCase))
+	defer Configure(WithKeyCase(KeysAsIs))
+	out.Reset()
+	Info(ctx, "normalized", Field("userID", 1), Field("Status-Code", 200), Field("a", 1))
+	assert.Equal(t, "normalized | request_id=r1 | user_id=1 | status_code=200 | a=1\n", out.String())
+}
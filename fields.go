@@ -0,0 +1,67 @@
+package log
+
+import (
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldsFromMap returns the entries of m as fields, in the order of their
+// keys, as if each was passed to Field.
+//
+//	log.Info(ctx, "request", log.FieldsFromMap(attrs), log.Field("id", id))
+func FieldsFromMap(m map[string]interface{}) Fld {
+	b := Bound{fields: make(logrus.Fields, len(m)), keys: make([]string, 0, len(m))}
+	for k := range m {
+		b.keys = append(b.keys, k)
+	}
+	sortStrings(b.keys)
+	for _, k := range b.keys {
+		Field(k, m[k]).apply(b.fields)
+	}
+	return b
+}
+
+// FieldsToMap returns the fields flds add to an entry, as a map.
+func FieldsToMap(flds ...Fld) map[string]interface{} {
+	fields := make(logrus.Fields, len(flds))
+	for _, f := range flds {
+		f.apply(fields)
+	}
+	delete(fields, rateLimitField)
+	return fields
+}
+
+// FieldsFromStruct returns the exported fields of the struct v, or of the
+// struct it points to, as fields, in the order they are declared. They are
+// named and skipped as the JSON formatter would encode the struct: a json tag
+// sets the key and omitempty leaves out empty values, while fields tagged
+// log:"-" are left out and fields tagged log:"mask" are masked. A nil pointer
+// or a value other than a struct adds no fields.
+func FieldsFromStruct(v interface{}) Fld {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return Bound{}
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return Bound{}
+	}
+	enc := structEncodingOf(rv.Type())
+	b := Bound{fields: make(logrus.Fields, len(enc.fields)), keys: make([]string, 0, len(enc.fields))}
+	for _, f := range enc.fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		b.keys = append(b.keys, f.name)
+		if f.mask {
+			b.fields[f.name] = MaskedValue
+			continue
+		}
+		Field(f.name, fv.Interface()).apply(b.fields)
+	}
+	return b
+}
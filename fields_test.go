@@ -0,0 +1,60 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fieldsAudit struct {
+	Actor string `json:"actor"`
+}
+
+type fieldsRequest struct {
+	fieldsAudit
+	ID       int    `json:"id"`
+	Path     string `json:"path,omitempty"`
+	Token    string `log:"mask"`
+	Internal string `log:"-"`
+	Err      error  `json:"err"`
+	private  int
+}
+
+func TestFieldsFromMap(t *testing.T) {
+	fields := logrus.Fields{}
+	FieldsFromMap(map[string]interface{}{"b": 2, "a": "x", "err": errors.New("boom")}).apply(fields)
+	assert.Equal(t, logrus.Fields{"a": "x", "b": 2, "err": "boom"}, fields)
+
+	fields = logrus.Fields{}
+	FieldsFromMap(nil).apply(fields)
+	assert.Empty(t, fields)
+
+	m := map[string]interface{}{"a": "x", "b": 2}
+	assert.Equal(t, m, FieldsToMap(FieldsFromMap(m)))
+	assert.Equal(t, map[string]interface{}{"a": "y"}, FieldsToMap(Field("a", "x"), Field("a", "y"), RateLimited("k", 1)))
+}
+
+func TestFieldsFromStruct(t *testing.T) {
+	req := &fieldsRequest{fieldsAudit{"jane"}, 7, "", "secret", "x", errors.New("boom"), 1}
+	fields := logrus.Fields{}
+	FieldsFromStruct(req).apply(fields)
+	assert.Equal(t, logrus.Fields{"actor": "jane", "id": 7, "Token": MaskedValue, "err": "boom"}, fields)
+
+	for _, v := range []interface{}{nil, (*fieldsRequest)(nil), 3} {
+		fields = logrus.Fields{}
+		FieldsFromStruct(v).apply(fields)
+		assert.Empty(t, fields)
+	}
+
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(WithFieldOrder(SortedFields))
+	Configure(WithFieldOrder(InsertionOrder))
+	Info(context.Background(), "request", FieldsFromStruct(req))
+	assert.Equal(t, []string{"actor", "id", "Token", "err"}, fieldKeys(sink.entries[0]))
+}
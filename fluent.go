@@ -0,0 +1,155 @@
+package log
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultFluentAddr is the default address of a local Fluentd or Fluent Bit
+// forward input.
+const DefaultFluentAddr = "127.0.0.1:24224"
+
+// FluentConfig configures a Fluent forward sink.
+type FluentConfig struct {
+	// Network defaults to "tcp"; "unix" is also supported.
+	Network string
+	// Addr defaults to DefaultFluentAddr.
+	Addr string
+	// Tag may contain {name} placeholders that are replaced by the entry field of
+	// that name, or by the entry level for {level}, e.g. "app.{service}".
+	// Missing fields are replaced by "unknown". Defaults to "app".
+	Tag string
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Timeout bounds connecting and writing and defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// FluentSink ships entries to Fluentd or Fluent Bit using the forward protocol
+// (MessagePack over TCP) in forward mode, one message per tag and batch.
+type FluentSink struct {
+	cfg     FluentConfig
+	mu      sync.Mutex
+	conn    net.Conn
+	batcher *batcher
+}
+
+// NewFluentSink creates a Fluent forward sink. The connection is established on
+// first send.
+func NewFluentSink(cfg FluentConfig) *FluentSink {
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultFluentAddr
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = "app"
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	}
+	s := &FluentSink{cfg: cfg}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *FluentSink) Write(entry *Entry) error {
+	record := make(map[string]interface{}, len(entry.Data)+2)
+	for k, v := range entry.Data {
+		record[k] = v
+	}
+	record["level"] = entry.Level.String()
+	record["msg"] = entry.Message
+
+	// [time, record] as one forward mode entry
+	data := appendMsgpackArrayHeader(nil, 2)
+	data = appendMsgpackEventTime(data, entry.Time)
+	data = appendMsgpackMap(data, record)
+
+	tag := expandTemplate(s.cfg.Tag, func(name string) string {
+		if v, ok := templateValue(entry, name); ok {
+			return v
+		}
+		return "unknown"
+	})
+	s.batcher.add(batchItem{key: tag, time: entry.Time, data: data})
+	return nil
+}
+
+// send writes one forward mode message per run of items sharing a tag.
+func (s *FluentSink) send(items []batchItem) error {
+	var msg []byte
+	for len(items) > 0 {
+		n := 1
+		for n < len(items) && items[n].key == items[0].key {
+			n++
+		}
+		msg = appendMsgpackArrayHeader(msg, 3)
+		msg = appendMsgpackString(msg, items[0].key)
+		msg = appendMsgpackArrayHeader(msg, n)
+		for _, item := range items[:n] {
+			msg = append(msg, item.data...)
+		}
+		msg = appendMsgpackMapHeader(msg, 1)
+		msg = appendMsgpackString(msg, "size")
+		msg = appendMsgpackInt(msg, int64(n))
+		items = items[n:]
+	}
+	return withRetries(s.cfg.MaxRetries, func() error {
+		return s.write(msg)
+	})
+}
+
+func (s *FluentSink) write(msg []byte) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if s.conn, err = net.DialTimeout(s.cfg.Network, s.cfg.Addr, s.cfg.Timeout); err != nil {
+			return err
+		}
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.cfg.Timeout))
+	if _, err = s.conn.Write(msg); err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *FluentSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *FluentSink) Close() error {
+	err := s.batcher.close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		if cerr := s.conn.Close(); err == nil {
+			err = cerr
+		}
+		s.conn = nil
+	}
+	return err
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *FluentSink) Status() SinkHealth {
+	return s.batcher.status()
+}
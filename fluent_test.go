@@ -0,0 +1,44 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFluentSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	sink := NewFluentSink(FluentConfig{Addr: ln.Addr().String(), Tag: "app.{service}"})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("fluent", sink)
+	ctx := context.Background()
+	Info(ctx, "Informational Message 1", Field("service", "api"))
+	Info(ctx, "Informational Message 2", Field("service", "api"))
+	assert.NoError(t, RemoveSink("fluent"))
+
+	data := <-received
+	// [tag, [entry, entry], {"size": 2}]
+	assert.Equal(t, []byte{0x93, 0xa7}, data[:2])
+	assert.Equal(t, "app.api", string(data[2:9]))
+	assert.Equal(t, byte(0x92), data[9])
+	assert.Equal(t, []byte{0x92, 0xd7, 0x00}, data[10:13])
+	assert.True(t, bytes.Contains(data, []byte("Informational Message 2")))
+	assert.True(t, bytes.HasSuffix(data, []byte{0x81, 0xa4, 's', 'i', 'z', 'e', 0x02}))
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// GCPHTTPRequest is a field value the GCP formatter writes as the httpRequest
+// of the entry, which Cloud Logging shows with the request.
+type GCPHTTPRequest struct {
+	RequestMethod string
+	RequestURL    string
+	RequestSize   int64
+	Status        int
+	ResponseSize  int64
+	UserAgent     string
+	RemoteIP      string
+	ServerIP      string
+	Referer       string
+	Latency       time.Duration
+	Protocol      string
+}
+
+// payload returns the request as Cloud Logging's HttpRequest.
+func (r *GCPHTTPRequest) payload() map[string]interface{} {
+	p := map[string]interface{}{}
+	add := func(k, v string) {
+		if v != "" {
+			p[k] = v
+		}
+	}
+	add("requestMethod", r.RequestMethod)
+	add("requestUrl", r.RequestURL)
+	add("userAgent", r.UserAgent)
+	add("remoteIp", r.RemoteIP)
+	add("serverIp", r.ServerIP)
+	add("referer", r.Referer)
+	add("protocol", r.Protocol)
+	if r.RequestSize > 0 {
+		p["requestSize"] = strconv.FormatInt(r.RequestSize, 10)
+	}
+	if r.ResponseSize > 0 {
+		p["responseSize"] = strconv.FormatInt(r.ResponseSize, 10)
+	}
+	if r.Status > 0 {
+		p["status"] = r.Status
+	}
+	if r.Latency > 0 {
+		p["latency"] = strconv.FormatFloat(r.Latency.Seconds(), 'f', -1, 64) + "s"
+	}
+	return p
+}
+
+// WithGCPTrace sets how the GCP formatter finds the trace and span of an
+// entry: trace returns their IDs from the entry's context, and the trace is
+// written as projects/<projectID>/traces/<traceID>.
+func WithGCPTrace(projectID string, trace func(ctx context.Context) (traceID, spanID string)) Option {
+	return func(c *config) {
+		c.gcpProject = projectID
+		c.gcpTrace = trace
+	}
+}
+
+// gcpFormatter writes entries as the JSON Cloud Logging parses from the
+// stdout of GKE and Cloud Run workloads: severity, message, time, the caller
+// as sourceLocation, the trace and span set with WithGCPTrace and a
+// GCPHTTPRequest field as httpRequest. Other fields become the keys of the
+// payload, those with the names of these keys under a "fields." prefix.
+type gcpFormatter struct{}
+
+func (f *gcpFormatter) Format(entry *Entry) ([]byte, error) {
+	cfg := currentConfig()
+	data := getFields()
+	defer putFields(data)
+	var req *GCPHTTPRequest
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case GCPHTTPRequest:
+			req = &v
+		case *GCPHTTPRequest:
+			req = v
+		case error:
+			data[k] = v.Error()
+		default:
+			data[k] = v
+		}
+	}
+	for _, k := range [...]string{"severity", "message", "time", "sourceLocation", "httpRequest", "logging.googleapis.com/trace", "logging.googleapis.com/spanId"} {
+		if v, ok := data[k]; ok {
+			data["fields."+k] = v
+			delete(data, k)
+		}
+	}
+	if req != nil {
+		data["httpRequest"] = req.payload()
+	}
+	data["severity"] = cloudLoggingSeverity[syslogSeverity(entry.Level)]
+	data["message"] = entry.Message
+	data["time"] = entry.Time.Format(time.RFC3339Nano)
+	if entry.HasCaller() {
+		data["sourceLocation"] = map[string]interface{}{
+			"file":     entry.Caller.File,
+			"line":     strconv.Itoa(entry.Caller.Line),
+			"function": entry.Caller.Function,
+		}
+	}
+	if cfg.gcpTrace != nil && entry.Context != nil {
+		traceID, spanID := cfg.gcpTrace(entry.Context)
+		if traceID != "" {
+			data["logging.googleapis.com/trace"] = "projects/" + cfg.gcpProject + "/traces/" + traceID
+		}
+		if spanID != "" {
+			data["logging.googleapis.com/spanId"] = spanID
+		}
+	}
+	return writeJSONLine(entry, cfg, data)
+}
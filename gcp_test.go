@@ -0,0 +1,46 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPFormatter(t *testing.T) {
+	assert.Equal(t, GCPFormatter, FormatterFromName("gcp"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"user":     "jane",
+		"severity": "clash",
+		"request": &GCPHTTPRequest{
+			RequestMethod: "GET",
+			RequestURL:    "/orders",
+			Status:        200,
+			ResponseSize:  512,
+			Latency:       1500 * time.Millisecond,
+		},
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entry.Level = WarnLevel
+	entry.Message = "slow"
+	got, err := new(gcpFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fields.severity":"clash","httpRequest":{"latency":"1.5s","requestMethod":"GET","requestUrl":"/orders","responseSize":"512","status":200},`+
+		`"message":"slow","severity":"WARNING","time":"2024-05-06T07:08:09Z","user":"jane"}`+"\n", string(got))
+
+	Configure(WithGCPTrace("proj", func(ctx context.Context) (string, string) { return "abc", "def" }))
+	defer Configure(WithGCPTrace("", nil))
+	entry.Data = logrus.Fields{}
+	entry.Context = context.Background()
+	entry.Level = ErrorLevel
+	entry.Caller = &runtime.Frame{File: "/src/main.go", Line: 12, Function: "main.run"}
+	entry.Logger.ReportCaller = true
+	got, err = new(gcpFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"logging.googleapis.com/spanId":"def","logging.googleapis.com/trace":"projects/proj/traces/abc","message":"slow",`+
+		`"severity":"ERROR","sourceLocation":{"file":"/src/main.go","function":"main.run","line":"12"},"time":"2024-05-06T07:08:09Z"}`+"\n", string(got))
+}
@@ -0,0 +1,169 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGELFChunkSize keeps UDP datagrams below a typical Ethernet MTU.
+	DefaultGELFChunkSize = 1420
+
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+var gelfKeyRegexp = regexp.MustCompile(`[^\w.\-]`)
+
+// GELFConfig configures a GELF sink.
+type GELFConfig struct {
+	// Network is "udp" (default) or "tcp".
+	Network string
+	// Addr is the Graylog input address.
+	Addr string
+	// Host defaults to os.Hostname.
+	Host string
+	// ChunkSize is the maximum UDP datagram size and defaults to
+	// DefaultGELFChunkSize.
+	ChunkSize int
+	// Compress gzips UDP payloads.
+	Compress bool
+	// Timeout bounds connecting and writing and defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// GELFSink ships entries to Graylog using the GELF 1.1 format.
+type GELFSink struct {
+	cfg  GELFConfig
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewGELFSink connects to the Graylog input described by cfg.
+func NewGELFSink(cfg GELFConfig) (*GELFSink, error) {
+	if cfg.Network == "" {
+		cfg.Network = "udp"
+	}
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+	if cfg.ChunkSize <= gelfChunkHeaderSize {
+		cfg.ChunkSize = DefaultGELFChunkSize
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	}
+	conn, err := net.DialTimeout(cfg.Network, cfg.Addr, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &GELFSink{cfg: cfg, conn: conn}, nil
+}
+
+func (s *GELFSink) Write(entry *Entry) error {
+	payload, err := json.Marshal(gelfMessage(entry, s.cfg.Host))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if s.conn, err = net.DialTimeout(s.cfg.Network, s.cfg.Addr, s.cfg.Timeout); err != nil {
+			return err
+		}
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.cfg.Timeout))
+	if s.cfg.Network == "udp" {
+		err = s.writeUDP(payload)
+	} else {
+		_, err = s.conn.Write(append(payload, 0))
+	}
+	if err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *GELFSink) writeUDP(payload []byte) error {
+	if s.cfg.Compress {
+		var err error
+		if payload, err = gzipBytes(payload); err != nil {
+			return err
+		}
+	}
+	if len(payload) <= s.cfg.ChunkSize {
+		_, err := s.conn.Write(payload)
+		return err
+	}
+
+	size := s.cfg.ChunkSize - gelfChunkHeaderSize
+	count := (len(payload) + size - 1) / size
+	if count > gelfMaxChunks {
+		return errors.New("gelf message too large")
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, s.cfg.ChunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, payload[i*size:end]...)
+		if _, err := s.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GELFSink) Flush() error {
+	return nil
+}
+
+func (s *GELFSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func gelfMessage(entry *Entry, host string) map[string]interface{} {
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": entry.Message,
+		"timestamp":     float64(entry.Time.UnixNano()/int64(1e6)) / 1e3,
+		"level":         syslogSeverity(entry.Level),
+	}
+	for k, v := range entry.Data {
+		k = "_" + gelfKeyRegexp.ReplaceAllString(k, "_")
+		if k == "_id" {
+			k = "__id"
+		}
+		switch v.(type) {
+		case string, int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, float32, float64:
+			m[k] = v
+		default:
+			m[k] = stringValue(v)
+		}
+	}
+	return m
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGELFSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := NewGELFSink(GELFConfig{Addr: conn.LocalAddr().String(), Host: "host", ChunkSize: 64})
+	assert.NoError(t, err)
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("gelf", sink)
+	defer func() { _ = RemoveSink("gelf") }()
+
+	Error(context.Background(), strings.Repeat("x", 100), Field("id", "abc"), Field("n", 2))
+
+	chunks := map[byte][]byte{}
+	var count byte
+	buf := make([]byte, 1024)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x1e, 0x0f}, buf[:2])
+		count = buf[11]
+		chunks[buf[10]] = append([]byte(nil), buf[12:n]...)
+		if len(chunks) == int(count) {
+			break
+		}
+	}
+	payload := bytes.Buffer{}
+	for i := byte(0); i < count; i++ {
+		payload.Write(chunks[i])
+	}
+
+	var msg map[string]interface{}
+	assert.NoError(t, json.Unmarshal(payload.Bytes(), &msg))
+	assert.Equal(t, "1.1", msg["version"])
+	assert.Equal(t, "host", msg["host"])
+	assert.Equal(t, strings.Repeat("x", 100), msg["short_message"])
+	assert.Equal(t, float64(3), msg["level"])
+	assert.Equal(t, "abc", msg["__id"])
+	assert.Equal(t, float64(2), msg["_n"])
+}
+
+func TestGELFSinkTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		// accept, but never read
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	sink, err := NewGELFSink(GELFConfig{Network: "tcp", Addr: ln.Addr().String(), Timeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	start := time.Now()
+	assert.Error(t, sink.Write(&Entry{Time: start, Message: strings.Repeat("x", 16<<20)}))
+	assert.True(t, time.Since(start) < time.Second)
+}
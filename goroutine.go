@@ -0,0 +1,36 @@
+package log
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// GoroutineKey is the field holding the ID of the goroutine an entry was
+// logged on, as shown in goroutine traces.
+const GoroutineKey = "goroutine"
+
+// WithGoroutineID adds the goroutine field to every entry, to follow
+// concurrent work through the logs. Finding the ID takes a short stack trace,
+// about a microsecond, so it is off by default.
+func WithGoroutineID() Option {
+	return func(c *config) {
+		c.goroutineID = true
+	}
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID returns the ID of the calling goroutine, parsed from the first
+// line of its trace, "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], goroutinePrefix)
+	var id uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoroutineID(t *testing.T) {
+	buf := make([]byte, 64)
+	fields := strings.Fields(string(buf[:runtime.Stack(buf, false)]))
+	assert.Equal(t, fields[1], strconv.FormatUint(goroutineID(), 10))
+
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	Info(context.Background(), "Informational Message 1")
+	assert.NotContains(t, sink.entries[0].Data, GoroutineKey)
+
+	Configure(WithGoroutineID())
+	defer Configure(func(c *config) { c.goroutineID = false })
+	var other uint64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other = goroutineID()
+		Info(context.Background(), "Informational Message 2")
+	}()
+	wg.Wait()
+	assert.Equal(t, other, sink.entries[1].Data[GoroutineKey])
+	assert.NotEqual(t, goroutineID(), other)
+}
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashPrefix starts the values of hashed fields.
+const HashPrefix = "sha256:"
+
+// WithHashedKeys replaces the values of fields with the given names, compared
+// case-insensitively and nested ones included, with a salted SHA-256 hash. The
+// same value always hashes the same, so entries stay joinable on it without
+// containing it. The salt applies to all hashed keys and should be kept
+// secret; a later call replaces it.
+func WithHashedKeys(salt string, keys ...string) Option {
+	return func(c *config) {
+		c.hashedKeys = addKeys(c.hashedKeys, keys)
+		c.hashSalt = salt
+	}
+}
+
+// hashValue returns the salted hash of v.
+func (c *config) hashValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		s = jsonString(v)
+	}
+	sum := sha256.Sum256([]byte(c.hashSalt + s))
+	return HashPrefix + hex.EncodeToString(sum[:])
+}
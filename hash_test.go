@@ -0,0 +1,27 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHashedKeys(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithHashedKeys("pepper", "email", "userId"))
+	defer Configure(func(c *config) { c.hashedKeys = nil })
+
+	Info(context.Background(), "login", Field("email", "jane@example.com"), Field("UserID", 42), Field("team", "a"))
+	Info(context.Background(), "logout", Field("user", map[string]interface{}{"email": "jane@example.com"}))
+
+	first, second := sink.entries[0].Data, sink.entries[1].Data
+	// echo -n 'pepperjane@example.com' | sha256sum
+	assert.Equal(t, "sha256:2daebb07b6fe2686ef59cd504c8b776ad69262a4e97fbc978e5d7851bdc6fe15", first["email"])
+	assert.Equal(t, first["email"], second["user"].(map[string]interface{})["email"])
+	assert.Equal(t, currentConfig().hashValue("42"), first["UserID"])
+	assert.Equal(t, "a", first["team"])
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultHTTPTimeout = 10 * time.Second
+
+var defaultHTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+
+// httpStatusError is returned for non-2xx responses.
+type httpStatusError struct {
+	code int
+	body string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.code, e.body)
+}
+
+// Retryable reports whether the request is worth repeating: 429 and 5xx
+// responses are, other client errors are not.
+func (e *httpStatusError) Retryable() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+// post sends body to url, retrying retryable failures up to maxRetries times.
+func post(client *http.Client, url string, header http.Header, body []byte, maxRetries int) error {
+	return withRetries(maxRetries, func() error {
+		_, err := postOnce(client, url, header, body)
+		return err
+	})
+}
+
+// postOnce sends body to url and returns the response body of a 2xx response.
+func postOnce(client *http.Client, url string, header http.Header, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &httpStatusError{code: resp.StatusCode, body: string(msg)}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	buf := bytes.Buffer{}
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
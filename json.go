@@ -0,0 +1,1027 @@
+package log
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultJSONMaxDepth is how deeply maps, slices and structs are nested
+	// before they are replaced with MaxDepthValue.
+	DefaultJSONMaxDepth = 32
+	// DefaultJSONMaxElements is how many elements of a map or slice are
+	// encoded before the rest are summarized.
+	DefaultJSONMaxElements = 1000
+
+	// MaxDepthValue replaces values nested too deeply.
+	MaxDepthValue = "<max depth>"
+	// CycleValue replaces values that contain themselves.
+	CycleValue = "<cycle>"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	timeType               = reflect.TypeOf(time.Time{})
+	durationType           = reflect.TypeOf(time.Duration(0))
+	mapStringStringType    = reflect.TypeOf(map[string]string(nil))
+	mapStringInterfaceType = reflect.TypeOf(map[string]interface{}(nil))
+
+	scratchPool = sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 0, 1024)
+			return &b
+		},
+	}
+	pathPool = sync.Pool{
+		New: func() interface{} {
+			path := make([]jsonRef, 0, 16)
+			return &path
+		},
+	}
+)
+
+// appendJSON appends the JSON encoding of v to b. The output is the same as
+// encoding/json's, including HTML escaping and sorted map keys, but common
+// types are encoded without reflection and into the caller's buffer. Types
+// with their own JSON marshalers are left to encoding/json, and LogMarshalers
+// are encoded with the fields they expose. Struct fields tagged log:"-" are
+// left out and those tagged log:"mask" are replaced with MaskedValue.
+//
+// Any object graph can be encoded: values nested deeper than the depth limit
+// become MaxDepthValue, values that contain themselves become CycleValue and
+// the elements of maps and slices beyond the element limit are summarized;
+// see WithJSONLimits.
+func appendJSON(b []byte, v interface{}) ([]byte, error) {
+	path := pathPool.Get().(*[]jsonRef)
+	defer pathPool.Put(path)
+	e := currentConfig().jsonEncoder(*path)
+	b, err := e.appendAny(b, v)
+	*path = e.path[:0]
+	return b, err
+}
+
+// WithJSONLimits sets how deeply nested values are encoded as JSON and how
+// many elements of each map or slice are encoded. Zero restores the default.
+func WithJSONLimits(maxDepth, maxElements int) Option {
+	return func(c *config) {
+		c.jsonMaxDepth = maxDepth
+		c.jsonMaxElements = maxElements
+	}
+}
+
+// jsonEncoder returns an encoder that appends to path, which must be empty.
+func (c *config) jsonEncoder(path []jsonRef) jsonEncoder {
+	e := jsonEncoder{
+		maxDepth:       c.jsonMaxDepth,
+		maxElements:    c.jsonMaxElements,
+		durationFormat: c.durationFormat,
+		timeFormat:     c.timeFormat,
+		path:           path,
+	}
+	if e.maxDepth <= 0 {
+		e.maxDepth = DefaultJSONMaxDepth
+	}
+	if e.maxElements <= 0 {
+		e.maxElements = DefaultJSONMaxElements
+	}
+	return e
+}
+
+// jsonEncoder holds the limits and state of one encoding. It is not used
+// after an error, so the path is not unwound on errors.
+type jsonEncoder struct {
+	maxDepth       int
+	maxElements    int
+	durationFormat DurationFormat
+	timeFormat     string
+	depth          int
+	path           []jsonRef // the maps, slices and pointers being encoded
+	// placeholders replaces the map values that cannot be encoded with a
+	// "<marshal error: ...>" string instead of failing the whole encoding.
+	placeholders bool
+}
+
+// jsonRef identifies a map, slice or pointer. Slices include their length
+// since a shorter slice of the same array is a different value.
+type jsonRef struct {
+	ptr uintptr
+	len int
+}
+
+// enter starts encoding a map, struct, slice or array, which is one level
+// deeper, or returns false after appending a placeholder if it is too deep or
+// is already being encoded.
+func (e *jsonEncoder) enter(b []byte, ref jsonRef) ([]byte, bool) {
+	if e.depth >= e.maxDepth {
+		return appendPlaceholder(b, MaxDepthValue), false
+	}
+	if b, ok := e.push(b, ref); !ok {
+		return b, false
+	}
+	e.depth++
+	return b, true
+}
+
+func (e *jsonEncoder) leave() {
+	e.depth--
+	e.pop()
+}
+
+// push adds a value to the path unless it is already on it.
+func (e *jsonEncoder) push(b []byte, ref jsonRef) ([]byte, bool) {
+	if ref.ptr != 0 {
+		for _, r := range e.path {
+			if r == ref {
+				return appendPlaceholder(b, CycleValue), false
+			}
+		}
+	}
+	e.path = append(e.path, ref)
+	return b, true
+}
+
+func (e *jsonEncoder) pop() {
+	e.path = e.path[:len(e.path)-1]
+}
+
+// appendPlaceholder appends a string that stands in for a value. Unlike
+// appendJSONString, it does not escape angle brackets.
+func appendPlaceholder(b []byte, s string) []byte {
+	b = append(b, '"')
+	b = append(b, s...)
+	return append(b, '"')
+}
+
+// appendMore summarizes the elements left out by the element limit.
+func appendMore(b []byte, n int) []byte {
+	b = append(b, '"', '<')
+	b = strconv.AppendInt(b, int64(n), 10)
+	return append(b, " more>\""...)
+}
+
+func (e *jsonEncoder) appendAny(b []byte, val interface{}) ([]byte, error) {
+	switch v := val.(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case *encodedJSON:
+		return append(b, v.data...), nil
+	case json.Number:
+		if v == "" {
+			return append(b, '0'), nil
+		}
+		return append(b, v...), nil
+	case string:
+		return appendJSONString(b, v), nil
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case int:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case uint:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint(b, v, 10), nil
+	case float64:
+		return appendFloat(b, v, 64)
+	case float32:
+		return appendFloat(b, float64(v), 32)
+	case time.Duration:
+		return e.appendAny(b, formatDuration(v, e.durationFormat))
+	case time.Time:
+		if e.timeFormat != "" {
+			return e.appendAny(b, formatTime(v, e.timeFormat))
+		}
+		if y := v.Year(); y < 0 || y >= 10000 {
+			// encoding/json reports the error
+			return appendMarshaled(b, reflect.ValueOf(v))
+		}
+		b = append(b, '"')
+		b = v.AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"'), nil
+	case LogMarshaler:
+		return e.appendMap(b, marshalLog(v))
+	case logrus.Fields:
+		return e.appendMap(b, v)
+	case namespace:
+		return e.appendMap(b, v)
+	case map[string]interface{}:
+		return e.appendMap(b, v)
+	case map[string]string:
+		if v == nil {
+			return append(b, "null"...), nil
+		}
+		b, ok := e.enter(b, jsonRef{ptr: reflect.ValueOf(val).Pointer()})
+		if !ok {
+			return b, nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sortStrings(keys)
+		b = append(b, '{')
+		for i, k := range keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			if i == e.maxElements {
+				b = append(b, `"...":`...)
+				b = appendMore(b, len(keys)-i)
+				break
+			}
+			b = appendJSONString(b, k)
+			b = append(b, ':')
+			b = appendJSONString(b, v[k])
+		}
+		e.leave()
+		return append(b, '}'), nil
+	case []interface{}:
+		if v == nil {
+			return append(b, "null"...), nil
+		}
+		b, ok := e.enter(b, jsonRef{reflect.ValueOf(val).Pointer(), len(v)})
+		if !ok {
+			return b, nil
+		}
+		b = append(b, '[')
+		for i, x := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			if i == e.maxElements {
+				b = appendMore(b, len(v)-i)
+				break
+			}
+			var err error
+			if b, err = e.appendAny(b, x); err != nil {
+				return b, err
+			}
+		}
+		e.leave()
+		return append(b, ']'), nil
+	}
+	return e.appendValue(b, reflect.ValueOf(val))
+}
+
+func (e *jsonEncoder) appendMap(b []byte, m map[string]interface{}) ([]byte, error) {
+	if m == nil {
+		return append(b, "null"...), nil
+	}
+	b, ok := e.enter(b, jsonRef{ptr: reflect.ValueOf(m).Pointer()})
+	if !ok {
+		return b, nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sortStrings(keys)
+	b = append(b, '{')
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if i == e.maxElements {
+			b = append(b, `"...":`...)
+			b = appendMore(b, len(keys)-i)
+			break
+		}
+		b = appendJSONString(b, k)
+		b = append(b, ':')
+		start, depth, path := len(b), e.depth, len(e.path)
+		var err error
+		if b, err = e.appendAny(b, m[k]); err != nil {
+			if !e.placeholders {
+				return b, err
+			}
+			atomic.AddUint64(&marshalErrors, 1)
+			b, e.depth, e.path = b[:start], depth, e.path[:path]
+			b = appendJSONString(b, "<marshal error: "+err.Error()+">")
+		}
+	}
+	e.leave()
+	return append(b, '}'), nil
+}
+
+func (e *jsonEncoder) appendValue(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return append(b, "null"...), nil
+	}
+	t := v.Type()
+	if t.Kind() == reflect.Ptr && v.IsNil() {
+		return append(b, "null"...), nil
+	}
+	if t == timeType || t == durationType && e.durationFormat != DurationNanos {
+		return e.appendAny(b, v.Interface())
+	}
+	switch m := marshalerOf(t); {
+	case m&logMarshals != 0:
+		return e.appendMap(b, marshalLog(v.Interface().(LogMarshaler)))
+	case m&ptrLogMarshals != 0 && v.CanAddr():
+		return e.appendMap(b, marshalLog(v.Addr().Interface().(LogMarshaler)))
+	case m&marshals != 0:
+		return appendMarshaled(b, v)
+	case m&ptrMarshals != 0 && v.CanAddr():
+		return appendMarshaled(b, v.Addr())
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.AppendBool(b, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(b, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(b, v.Uint(), 10), nil
+	case reflect.Float32:
+		return appendFloat(b, v.Float(), 32)
+	case reflect.Float64:
+		return appendFloat(b, v.Float(), 64)
+	case reflect.String:
+		return appendJSONString(b, v.String()), nil
+	case reflect.Interface:
+		// the dynamic value gets the fast paths, without copying
+		return e.appendAny(b, v.Interface())
+	case reflect.Ptr:
+		b, ok := e.push(b, jsonRef{ptr: v.Pointer()})
+		if !ok {
+			return b, nil
+		}
+		b, err := e.appendValue(b, v.Elem())
+		e.pop()
+		return b, err
+	case reflect.Struct:
+		b, ok := e.enter(b, jsonRef{})
+		if !ok {
+			return b, nil
+		}
+		b, err := e.appendStruct(b, v)
+		e.leave()
+		return b, err
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return appendMarshaled(b, v)
+		}
+		if t == mapStringStringType || t == mapStringInterfaceType {
+			return e.appendAny(b, v.Interface())
+		}
+		if v.IsNil() {
+			return append(b, "null"...), nil
+		}
+		b, ok := e.enter(b, jsonRef{ptr: v.Pointer()})
+		if !ok {
+			return b, nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		b = append(b, '{')
+		for i, k := range keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			if i == e.maxElements {
+				b = append(b, `"...":`...)
+				b = appendMore(b, len(keys)-i)
+				break
+			}
+			b = appendJSONString(b, k.String())
+			b = append(b, ':')
+			var err error
+			if b, err = e.appendValue(b, v.MapIndex(k)); err != nil {
+				return b, err
+			}
+		}
+		e.leave()
+		return append(b, '}'), nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return append(b, "null"...), nil
+		}
+		if et := t.Elem(); et.Kind() == reflect.Uint8 && marshalerOf(et) == 0 {
+			return appendBase64(b, v.Bytes()), nil
+		}
+		b, ok := e.enter(b, jsonRef{v.Pointer(), v.Len()})
+		if !ok {
+			return b, nil
+		}
+		b, err := e.appendElements(b, v)
+		e.leave()
+		return b, err
+	case reflect.Array:
+		b, ok := e.enter(b, jsonRef{})
+		if !ok {
+			return b, nil
+		}
+		b, err := e.appendElements(b, v)
+		e.leave()
+		return b, err
+	}
+	return appendMarshaled(b, v)
+}
+
+func (e *jsonEncoder) appendElements(b []byte, v reflect.Value) ([]byte, error) {
+	b = append(b, '[')
+	for i, n := 0, v.Len(); i < n; i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if i == e.maxElements {
+			b = appendMore(b, n-i)
+			break
+		}
+		var err error
+		if b, err = e.appendValue(b, v.Index(i)); err != nil {
+			return b, err
+		}
+	}
+	return append(b, ']'), nil
+}
+
+func appendBase64(b, data []byte) []byte {
+	n := base64.StdEncoding.EncodedLen(len(data))
+	if cap(b)-len(b) < n+2 {
+		grown := make([]byte, len(b), 2*cap(b)+n+2)
+		copy(grown, b)
+		b = grown
+	}
+	b = append(b, '"')
+	start := len(b)
+	b = b[:start+n]
+	base64.StdEncoding.Encode(b[start:], data)
+	return append(b, '"')
+}
+
+// Flags of types that marshal themselves for logging or to JSON, by value or
+// only through a pointer.
+const (
+	logMarshals = 1 << iota
+	ptrLogMarshals
+	marshals
+	ptrMarshals
+)
+
+var marshalers sync.Map // reflect.Type to int
+
+func marshalerOf(t reflect.Type) int {
+	if m, ok := marshalers.Load(t); ok {
+		return m.(int)
+	}
+	m := 0
+	if t.Implements(logMarshalerType) {
+		m |= logMarshals
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		m |= marshals
+	}
+	if pt := reflect.PtrTo(t); t.Kind() != reflect.Ptr {
+		if pt.Implements(logMarshalerType) {
+			m |= ptrLogMarshals
+		}
+		if pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+			m |= ptrMarshals
+		}
+	}
+	marshalers.Store(t, m)
+	return m
+}
+
+// sortStrings sorts the few keys of a typical entry without allocating.
+func sortStrings(keys []string) {
+	if len(keys) > 12 {
+		sort.Strings(keys)
+		return
+	}
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+}
+
+// appendMarshaled appends what encoding/json makes of v.
+func appendMarshaled(b []byte, v reflect.Value) ([]byte, error) {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return b, err
+	}
+	return append(b, data...), nil
+}
+
+func appendFloat(b []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return b, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	// like encoding/json, use exponents only for very small and large numbers
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b, nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s quoted, escaping like encoding/json with HTML
+// escaping enabled.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// MaskedValue replaces the values of struct fields tagged log:"mask".
+const MaskedValue = "***"
+
+// structEncoding is how values of a struct type are encoded: the fields
+// encoding/json would encode, in its order and with promoted fields of
+// embedded structs, minus those tagged log:"-".
+type structEncoding struct {
+	fields []structField
+}
+
+type structField struct {
+	name      string
+	index     []int
+	key       []byte // quoted name and colon
+	depth     int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool // the string option
+	mask      bool
+}
+
+var structEncodings sync.Map // reflect.Type to *structEncoding
+
+func (e *jsonEncoder) appendStruct(b []byte, v reflect.Value) ([]byte, error) {
+	enc := structEncodingOf(v.Type())
+	b = append(b, '{')
+	first := true
+	for _, f := range enc.fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if !first {
+			b = append(b, ',')
+		}
+		first = false
+		b = append(b, f.key...)
+		var err error
+		switch {
+		case f.mask:
+			b = appendJSONString(b, MaskedValue)
+		case f.quoted:
+			b, err = e.appendQuoted(b, fv)
+		default:
+			b, err = e.appendValue(b, fv)
+		}
+		if err != nil {
+			return b, err
+		}
+	}
+	return append(b, '}'), nil
+}
+
+// fieldByIndex returns a possibly promoted field, or false if it is reached
+// through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// appendQuoted encodes a field with the string option, which puts scalars in
+// quotes.
+func (e *jsonEncoder) appendQuoted(b []byte, v reflect.Value) ([]byte, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return append(b, "null"...), nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return appendJSONString(b, string(appendJSONString(nil, v.String()))), nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if marshalerOf(v.Type()) != 0 {
+			break
+		}
+		b = append(b, '"')
+		b, err := e.appendValue(b, v)
+		return append(b, '"'), err
+	}
+	return e.appendValue(b, v)
+}
+
+func structEncodingOf(t reflect.Type) *structEncoding {
+	if enc, ok := structEncodings.Load(t); ok {
+		return enc.(*structEncoding)
+	}
+	enc := &structEncoding{fields: dominantFields(structFields(t))}
+	actual, _ := structEncodings.LoadOrStore(t, enc)
+	return actual.(*structEncoding)
+}
+
+// structFields lists the fields of t and its embedded structs, breadth first
+// like encoding/json.
+func structFields(t reflect.Type) []structField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []structField
+	next := []embedded{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	for depth := 0; len(next) > 0; depth++ {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				f := e.typ.Field(i)
+				ft := f.Type
+				if f.Anonymous {
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if f.PkgPath != "" && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if f.PkgPath != "" {
+					continue
+				}
+				tag := f.Tag.Get("json")
+				logTag := f.Tag.Get("log")
+				if tag == "-" || logTag == "-" {
+					continue
+				}
+				name, opts := tag, ""
+				if i := strings.Index(tag, ","); i >= 0 {
+					name, opts = tag[:i], tag[i+1:]
+				}
+				if !isValidTag(name) {
+					name = ""
+				}
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+				if name == "" && f.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+				field := structField{
+					name:      name,
+					index:     index,
+					depth:     depth,
+					tagged:    name != "",
+					omitEmpty: hasOption(opts, "omitempty"),
+					mask:      logTag == "mask",
+				}
+				if field.name == "" {
+					field.name = f.Name
+				}
+				if hasOption(opts, "string") {
+					qt := f.Type
+					if qt.Name() == "" && qt.Kind() == reflect.Ptr {
+						qt = qt.Elem()
+					}
+					switch qt.Kind() {
+					case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64, reflect.String:
+						field.quoted = true
+					}
+				}
+				field.key = append(appendJSONString(nil, field.name), ':')
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
+
+// dominantFields resolves fields of the same name as encoding/json does: the
+// shallowest wins, a tagged one among equally shallow ones, and if that leaves
+// several none is encoded.
+func dominantFields(fields []structField) []structField {
+	byName := map[string][]int{}
+	for i, f := range fields {
+		byName[f.name] = append(byName[f.name], i)
+	}
+	keep := make([]bool, len(fields))
+	for _, idx := range byName {
+		winner, ambiguous := -1, false
+		for _, i := range idx {
+			switch {
+			case winner < 0 || fields[i].depth < fields[winner].depth:
+				winner, ambiguous = i, false
+			case fields[i].depth == fields[winner].depth:
+				switch {
+				case fields[i].tagged && !fields[winner].tagged:
+					winner, ambiguous = i, false
+				case fields[i].tagged == fields[winner].tagged:
+					ambiguous = true
+				}
+			}
+		}
+		if !ambiguous {
+			keep[winner] = true
+		}
+	}
+	kept := fields[:0]
+	for i, f := range fields {
+		if keep[i] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+var logTaggedTypes sync.Map // reflect.Type to bool
+
+// hasLogTags reports whether values of t contain struct fields with log tags.
+func hasLogTags(t reflect.Type) bool {
+	if tagged, ok := logTaggedTypes.Load(t); ok {
+		return tagged.(bool)
+	}
+	tagged := logTagged(t, map[reflect.Type]bool{})
+	logTaggedTypes.Store(t, tagged)
+	return tagged
+}
+
+func logTagged(t reflect.Type, seen map[reflect.Type]bool) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return logTagged(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Tag.Get("log") != "" || logTagged(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		opt := opts
+		if i := strings.Index(opts, ","); i >= 0 {
+			opt, opts = opts[:i], opts[i+1:]
+		} else {
+			opts = ""
+		}
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidTag reports whether encoding/json accepts s as a field name.
+func isValidTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// JSONFields names the keys the JSON formatter writes the entry time, message
+// and level under, such as "timestamp", "message" and "severity"; empty keys
+// keep the defaults, "time", "msg" and "level".
+type JSONFields struct {
+	TimeKey    string
+	MessageKey string
+	LevelKey   string
+	// NumericLevel writes levels as numbers, from 10 for trace to 60 for fatal
+	// and panic, instead of names.
+	NumericLevel bool
+}
+
+// WithJSONFields sets the keys and level representation of the JSON
+// formatter.
+func WithJSONFields(fields JSONFields) Option {
+	return func(c *config) {
+		c.jsonFields = fields
+	}
+}
+
+// keys returns the time, message and level keys.
+func (f JSONFields) keys() (timeKey, msgKey, levelKey string) {
+	timeKey, msgKey, levelKey = f.TimeKey, f.MessageKey, f.LevelKey
+	if timeKey == "" {
+		timeKey = logrus.FieldKeyTime
+	}
+	if msgKey == "" {
+		msgKey = logrus.FieldKeyMsg
+	}
+	if levelKey == "" {
+		levelKey = logrus.FieldKeyLevel
+	}
+	return timeKey, msgKey, levelKey
+}
+
+// levelNumber returns the number of the level as written with NumericLevel.
+func levelNumber(level Level) int {
+	switch level {
+	case TraceLevel:
+		return 10
+	case DebugLevel:
+		return 20
+	case InfoLevel:
+		return 30
+	case WarnLevel:
+		return 40
+	case ErrorLevel:
+		return 50
+	}
+	return 60
+}
+
+// jsonFormatter writes entries like logrus.JSONFormatter with its defaults,
+// encoding them with appendJSON, and with the keys set with WithJSONFields.
+// Hook errors, which logrus keeps private, are not reported in a logrus_error
+// field.
+type jsonFormatter struct{}
+
+func (f *jsonFormatter) Format(entry *Entry) ([]byte, error) {
+	cfg := currentConfig()
+	timeKey, msgKey, levelKey := cfg.jsonFields.keys()
+	data := getFields()
+	defer putFields(data)
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	for _, k := range [...]string{timeKey, msgKey, levelKey, logrus.FieldKeyLogrusError} {
+		if v, ok := data[k]; ok {
+			data["fields."+k] = v
+			delete(data, k)
+		}
+	}
+	data[timeKey] = entry.Time.Format(time.RFC3339)
+	data[msgKey] = entry.Message
+	if cfg.jsonFields.NumericLevel {
+		data[levelKey] = levelNumber(entry.Level)
+	} else {
+		data[levelKey] = entry.Level.String()
+	}
+	if entry.HasCaller() {
+		for _, k := range []string{logrus.FieldKeyFunc, logrus.FieldKeyFile} {
+			if v, ok := data[k]; ok {
+				data["fields."+k] = v
+			}
+		}
+		data[logrus.FieldKeyFunc] = entry.Caller.Function
+		data[logrus.FieldKeyFile] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+	return writeJSONLine(entry, cfg, data)
+}
+
+// writeJSONLine writes data, the JSON object of a formatted entry, and a
+// newline to the entry's buffer. Values that cannot be encoded are replaced
+// with a placeholder, like jsonString does, so the entry is not lost.
+func writeJSONLine(entry *Entry, cfg *config, data map[string]interface{}) ([]byte, error) {
+	scratch := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(scratch)
+	path := pathPool.Get().(*[]jsonRef)
+	defer pathPool.Put(path)
+	enc := cfg.jsonEncoder(*path)
+	enc.placeholders = true
+	out, err := enc.appendMap((*scratch)[:0], data)
+	*path = enc.path[:0]
+	*scratch = out
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+	}
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	b.Write(out)
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,338 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+	Zip    *int   `json:"zip,omitempty"`
+	secret string
+}
+
+type jsonOrder struct {
+	ID       int64             `json:"id"`
+	Customer string            `json:"customer"`
+	Total    float64           `json:"total"`
+	Paid     bool              `json:"paid"`
+	Items    []string          `json:"items"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Address  *jsonAddress      `json:"address"`
+	Created  time.Time         `json:"created"`
+	Raw      []byte            `json:"raw"`
+	Extra    interface{}       `json:"extra"`
+	Skipped  string            `json:"-"`
+	Dashed   string            `json:"-,"`
+	Untagged uint16
+}
+
+type jsonEmbedded struct {
+	jsonAddress
+	Name string
+}
+
+type jsonStringOption struct {
+	N int     `json:"n,string"`
+	S string  `json:"s,string"`
+	P *bool   `json:"p,string"`
+	F []int   `json:"f,string"`
+	B float64 `json:",string"`
+}
+
+type jsonName struct {
+	Name string
+}
+
+type jsonOther struct {
+	Name string
+	Age  int
+}
+
+type jsonTaggedName struct {
+	Name string `json:"Name"`
+}
+
+type jsonNested struct {
+	*jsonName
+	jsonOther
+	Age int `json:"age"`
+}
+
+type jsonDominant struct {
+	jsonOther
+	jsonTaggedName
+	ID int
+}
+
+type jsonSecret struct {
+	User     string `json:"user"`
+	Password string `json:"password" log:"mask"`
+	Hash     []byte `log:"-"`
+	Token    string `json:"token,omitempty" log:"mask"`
+}
+
+type jsonAccount struct {
+	jsonSecret
+	Owners []jsonSecret
+}
+
+func testOrder() jsonOrder {
+	zip := 12345
+	return jsonOrder{
+		ID:       42,
+		Customer: "Jane <jane@example.com> & co",
+		Total:    1234.5,
+		Items:    []string{"apple", "banana"},
+		Tags:     map[string]string{"b": "2", "a": "1"},
+		Address:  &jsonAddress{Street: "1 Main St", Zip: &zip, secret: "x"},
+		Created:  time.Date(2024, 5, 6, 7, 8, 9, 123, time.UTC),
+		Raw:      []byte("raw bytes"),
+		Extra:    map[string]interface{}{"n": 1e-7, "list": []interface{}{1, "two", nil}},
+		Dashed:   "dash",
+		Untagged: 7,
+	}
+}
+
+func TestAppendJSON(t *testing.T) {
+	values := []interface{}{
+		nil,
+		"plain",
+		"quotes \" backslash \\ newline \n tab \t ctrl \x01 html <>& seps \u2028\u2029 ünïcode",
+		true,
+		-17,
+		int64(math.MinInt64),
+		uint64(math.MaxUint64),
+		int8(-3),
+		1.5,
+		float32(0.1),
+		1e21,
+		1e-7,
+		0.0,
+		time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("x", 3600)),
+		[]interface{}{1, "a", nil, []int{1, 2}},
+		map[string]interface{}{"b": 1, "a": map[string]interface{}{"c": []string{}}},
+		logrus.Fields{"k": "v"},
+		map[int]string{2: "b", 1: "a"},
+		[]byte{0, 1, 2, 255},
+		[3]byte{1, 2, 3},
+		[]int(nil),
+		map[string]int(nil),
+		(*jsonAddress)(nil),
+		testOrder(),
+		&[]jsonOrder{testOrder()},
+		jsonEmbedded{jsonAddress: jsonAddress{Street: "s"}, Name: "n"},
+		jsonStringOption{N: 3, S: "a\"b", B: 1.5},
+		jsonNested{jsonName: &jsonName{Name: "n"}, jsonOther: jsonOther{Name: "o", Age: 1}, Age: 2},
+		jsonNested{},
+		jsonDominant{jsonOther: jsonOther{Name: "o"}, jsonTaggedName: jsonTaggedName{Name: "t"}},
+		net.ParseIP("10.0.0.1"),
+		json.RawMessage(`{"raw":true}`),
+		time.Second,
+		struct{}{},
+	}
+	for _, v := range values {
+		want, err := json.Marshal(v)
+		assert.NoError(t, err)
+		got, err := appendJSON(nil, v)
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), string(got), "%#v", v)
+	}
+
+	// the replacement character is escaped or not depending on the Go version
+	got, err := appendJSON(nil, "bad \xff")
+	assert.NoError(t, err)
+	var s string
+	assert.NoError(t, json.Unmarshal(got, &s))
+	assert.Equal(t, "bad \ufffd", s)
+
+	_, err = appendJSON(nil, time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+	_, err = appendJSON(nil, math.NaN())
+	assert.Error(t, err)
+	_, err = appendJSON(nil, map[string]interface{}{"f": func() {}})
+	assert.Error(t, err)
+}
+
+type jsonNode struct {
+	Name string
+	Next *jsonNode
+}
+
+func TestAppendJSONLimits(t *testing.T) {
+	node := &jsonNode{Name: "a"}
+	node.Next = &jsonNode{Name: "b", Next: node}
+	assert.Equal(t, `{"Name":"a","Next":{"Name":"b","Next":"<cycle>"}}`, jsonString(node))
+
+	m := map[string]interface{}{"k": 1}
+	m["self"] = m
+	assert.Equal(t, `{"k":1,"self":"<cycle>"}`, jsonString(m))
+	s := []interface{}{1, nil}
+	s[1] = s
+	assert.Equal(t, `[1,"<cycle>"]`, jsonString(s))
+	var x interface{}
+	x = &x
+	assert.Equal(t, `"<cycle>"`, jsonString(x))
+
+	// the same value twice is not a cycle
+	shared := &jsonName{Name: "n"}
+	assert.Equal(t, `[{"Name":"n"},{"Name":"n"}]`, jsonString([]*jsonName{shared, shared}))
+	// nor is a shorter slice of the same array
+	ints := []interface{}{1, 2, nil}
+	ints[2] = ints[:2]
+	assert.Equal(t, `[1,2,[1,2]]`, jsonString(ints))
+
+	deep := map[string]interface{}{}
+	for i := 0; i < 40; i++ {
+		deep = map[string]interface{}{"d": deep}
+	}
+	got := jsonString(deep)
+	assert.Equal(t, DefaultJSONMaxDepth, strings.Count(got, `{"d":`))
+	assert.Contains(t, got, `{"d":"<max depth>"}`)
+
+	long := make([]int, DefaultJSONMaxElements+5)
+	assert.True(t, strings.HasSuffix(jsonString(long), `,0,"<5 more>"]`))
+
+	defer Configure(WithJSONLimits(0, 0))
+	Configure(WithJSONLimits(2, 2))
+	assert.Equal(t, `{"a":[1,2,"<1 more>"],"b":{"c":"d"},"...":"<1 more>"}`,
+		jsonString(map[string]interface{}{"a": []int{1, 2, 3}, "b": map[string]string{"c": "d"}, "c": 1}))
+	assert.Equal(t, `[[1,2,"<2 more>"],["<max depth>"]]`,
+		jsonString([]interface{}{[4]int{1, 2}, []interface{}{[]int{}}}))
+}
+
+func TestAppendJSONLogTags(t *testing.T) {
+	secret := jsonSecret{User: "jane", Password: "hunter2", Hash: []byte("hash")}
+	got := jsonString(jsonAccount{jsonSecret: secret, Owners: []jsonSecret{secret}})
+	assert.Equal(t, `{"user":"jane","password":"***","Owners":[{"user":"jane","password":"***"}]}`, got)
+
+	assert.True(t, hasLogTags(reflect.TypeOf(&[]jsonAccount{})))
+	assert.False(t, hasLogTags(reflect.TypeOf(jsonOrder{})))
+	f := Field("account", &secret).(*fld)
+	assert.Equal(t, `{"user":"jane","password":"***"}`, fmt.Sprint(f.value))
+	assert.Equal(t, jsonOrder{}, Field("order", jsonOrder{}).(*fld).value)
+	assert.Equal(t, []interface{}{`{"user":"jane","password":"***"}`}, normalizeArgs([]interface{}{secret}))
+}
+
+func TestJSONFormatter(t *testing.T) {
+	entry := logger.WithFields(logrus.Fields{
+		"order": testOrder(),
+		"err":   errors.New("failed"),
+		"msg":   "clash",
+		"n":     3,
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entry.Level = WarnLevel
+	entry.Message = "order <placed>"
+
+	want, err := new(logrus.JSONFormatter).Format(entry)
+	assert.NoError(t, err)
+	got, err := new(jsonFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestJSONFormattersMarshalError(t *testing.T) {
+	formatters := map[string]logrus.Formatter{
+		"json":     new(jsonFormatter),
+		"ecs":      new(ecsFormatter),
+		"gcp":      new(gcpFormatter),
+		"logstash": new(logstashFormatter),
+		"lambda":   new(lambdaFormatter),
+	}
+	for name, f := range formatters {
+		entry := logger.WithFields(logrus.Fields{
+			"nan":     math.NaN(),
+			"funcs":   []interface{}{func() {}},
+			"complex": complex(1, 2),
+			"nested":  map[string]interface{}{"inf": math.Inf(1), "n": 1},
+			"n":       3,
+		})
+		entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+		entry.Level = WarnLevel
+		entry.Message = "failed"
+		errs := MarshalErrors()
+		got, err := f.Format(entry)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		assert.Equal(t, errs+4, MarshalErrors(), name)
+		var line map[string]interface{}
+		assert.NoError(t, json.Unmarshal(got, &line), name)
+		s := string(got)
+		assert.Contains(t, s, "failed", name)
+		assert.Contains(t, s, `"n":3`, name)
+		assert.Contains(t, s, `"\u003cmarshal error: json: unsupported value: +Inf\u003e"`, name)
+		assert.Contains(t, s, `"nan":"\u003cmarshal error: json: unsupported value: NaN\u003e"`, name)
+		assert.Contains(t, s, `"funcs":"\u003cmarshal error: json: unsupported type: func()\u003e"`, name)
+		assert.Contains(t, s, `"complex":"\u003cmarshal error: json: unsupported type: complex128\u003e"`, name)
+	}
+}
+
+func TestJSONFormatterFields(t *testing.T) {
+	entry := logger.WithFields(logrus.Fields{"message": "clash", "n": 1})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entry.Level = ErrorLevel
+	entry.Message = "failed"
+	defer Configure(WithJSONFields(JSONFields{}))
+
+	Configure(WithJSONFields(JSONFields{TimeKey: "timestamp", MessageKey: "message", LevelKey: "severity"}))
+	got, err := new(jsonFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fields.message":"clash","message":"failed","n":1,"severity":"error","timestamp":"2024-05-06T07:08:09Z"}`+"\n", string(got))
+
+	Configure(WithJSONFields(JSONFields{NumericLevel: true}))
+	got, err = new(jsonFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":50,"message":"clash","msg":"failed","n":1,"time":"2024-05-06T07:08:09Z"}`+"\n", string(got))
+	for level, n := range map[Level]int{TraceLevel: 10, DebugLevel: 20, InfoLevel: 30, WarnLevel: 40, FatalLevel: 60, PanicLevel: 60} {
+		assert.Equal(t, n, levelNumber(level))
+	}
+}
+
+func BenchmarkJSONString(b *testing.B) {
+	order := testOrder()
+	b.Run("appendJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = jsonString(order)
+		}
+	})
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, _ := json.Marshal(order)
+			_ = string(data)
+		}
+	})
+}
+
+func BenchmarkJSONFormatter(b *testing.B) {
+	entry := logger.WithFields(logrus.Fields{"order": testOrder(), "request_id": "abc", "status": 200})
+	entry.Message = "order placed"
+	b.Run("jsonFormatter", func(b *testing.B) {
+		f := new(jsonFormatter)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = f.Format(entry)
+		}
+	})
+	b.Run("logrus", func(b *testing.B) {
+		f := new(logrus.JSONFormatter)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = f.Format(entry)
+		}
+	})
+}
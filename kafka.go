@@ -0,0 +1,120 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// KafkaMessage is a record published by the Kafka sink.
+type KafkaMessage struct {
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// KafkaProducer publishes messages to Kafka. It is implemented by a thin adapter
+// around the application's Kafka client, so this package does not depend on one.
+type KafkaProducer interface {
+	Produce(messages []KafkaMessage) error
+}
+
+// KafkaConfig configures a Kafka sink.
+type KafkaConfig struct {
+	// Producer publishes the batches.
+	Producer KafkaProducer
+	// Topic receives the entries.
+	Topic string
+	// KeyField names the entry field used as message key, e.g. "tenant". Entries
+	// without it are published without a key.
+	KeyField string
+	// Formatter renders message values and defaults to JSON.
+	Formatter logrus.Formatter
+	// OnDeliveryFailure is called with messages that could not be published.
+	// By default the failure is passed to the error handler.
+	OnDeliveryFailure func(messages []KafkaMessage, err error)
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+}
+
+// KafkaSink publishes entries to a Kafka topic in asynchronous batches.
+type KafkaSink struct {
+	cfg     KafkaConfig
+	batcher *batcher
+}
+
+// NewKafkaSink creates a Kafka sink.
+func NewKafkaSink(cfg KafkaConfig) *KafkaSink {
+	if cfg.Formatter == nil {
+		cfg.Formatter = new(logrus.JSONFormatter)
+	}
+	if cfg.OnDeliveryFailure == nil {
+		cfg.OnDeliveryFailure = func(messages []KafkaMessage, err error) {
+			reportError(fmt.Errorf("kafka: %d messages not delivered: %w", len(messages), err))
+		}
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	s := &KafkaSink{cfg: cfg}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, nil, s.send)
+	return s
+}
+
+func (s *KafkaSink) Write(entry *Entry) error {
+	value, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	var key string
+	if v, ok := entry.Data[s.cfg.KeyField]; ok && s.cfg.KeyField != "" {
+		key = stringValue(v)
+	}
+	s.batcher.add(batchItem{key: key, time: entry.Time, data: bytes.TrimRight(value, "\n")})
+	return nil
+}
+
+// send publishes items. Failures are handed to OnDeliveryFailure and returned
+// marked as reported, so the batcher accounts for them without reporting them
+// a second time.
+func (s *KafkaSink) send(items []batchItem) error {
+	messages := make([]KafkaMessage, len(items))
+	for i, item := range items {
+		messages[i] = KafkaMessage{Topic: s.cfg.Topic, Value: item.data}
+		if item.key != "" {
+			messages[i].Key = []byte(item.key)
+		}
+	}
+	err := withRetries(s.cfg.MaxRetries, func() error {
+		return s.cfg.Producer.Produce(messages)
+	})
+	if err != nil {
+		s.cfg.OnDeliveryFailure(messages, err)
+		return &reportedError{err: err}
+	}
+	return nil
+}
+
+func (s *KafkaSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *KafkaSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *KafkaSink) Status() SinkHealth {
+	return s.batcher.status()
+}
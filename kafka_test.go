@@ -0,0 +1,65 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProducer struct {
+	mu       sync.Mutex
+	err      error
+	messages []KafkaMessage
+}
+
+func (p *testProducer) Produce(messages []KafkaMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+func TestKafkaSink(t *testing.T) {
+	producer := &testProducer{}
+	sink := NewKafkaSink(KafkaConfig{Producer: producer, Topic: "logs", KeyField: "tenant"})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("kafka", sink)
+	ctx := context.Background()
+	Info(ctx, "Informational Message 1", Field("tenant", "acme"))
+	Info(ctx, "Informational Message 2")
+	assert.NoError(t, RemoveSink("kafka"))
+
+	assert.Len(t, producer.messages, 2)
+	assert.Equal(t, "logs", producer.messages[0].Topic)
+	assert.Equal(t, []byte("acme"), producer.messages[0].Key)
+	assert.Contains(t, string(producer.messages[0].Value), "Informational Message 1")
+	assert.Nil(t, producer.messages[1].Key)
+}
+
+func TestKafkaSinkDeliveryFailure(t *testing.T) {
+	var failed []KafkaMessage
+	producer := &testProducer{err: errors.New("broker down")}
+	sink := NewKafkaSink(KafkaConfig{
+		Producer:          producer,
+		Topic:             "logs",
+		MaxRetries:        -1,
+		OnDeliveryFailure: func(messages []KafkaMessage, err error) { failed = messages },
+	})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("kafka", sink)
+	Error(context.Background(), "Error Message 1")
+	assert.EqualError(t, RemoveSink("kafka"), "broker down; 1 entries dropped")
+	assert.Len(t, failed, 1)
+	status := sink.Status()
+	assert.Equal(t, uint64(1), status.Dropped)
+	assert.EqualError(t, status.LastError, "broker down")
+	assert.True(t, status.LastFlush.IsZero())
+}
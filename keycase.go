@@ -0,0 +1,126 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeyCase is the case field keys are normalized to.
+type KeyCase int
+
+const (
+	// KeysAsIs leaves field keys as they are logged.
+	KeysAsIs KeyCase = iota
+	// SnakeCase normalizes keys like "userID" and "User-Name" to "user_id" and
+	// "user_name".
+	SnakeCase
+	// CamelCase normalizes keys like "user_id" and "User-Name" to "userId" and
+	// "userName".
+	CamelCase
+)
+
+// maxCachedKeys bounds the normalized keys remembered per case.
+const maxCachedKeys = 10000
+
+// WithKeyCase normalizes the keys of fields, context fields included, before
+// entries are recorded, formatted or handed to sinks, so keys are consistent
+// however callers write them. Words are split at underscores, hyphens, spaces
+// and changes of case; dots are kept so namespaced keys stay namespaced. When
+// keys normalize to the same one, the key already in that form wins, then the
+// first in sorted order.
+// Fields added by the logger itself, such as sample_rate, keep their names.
+func WithKeyCase(keyCase KeyCase) Option {
+	return func(c *config) {
+		c.keyCase = keyCase
+	}
+}
+
+// normalizeKeys renames the fields of the entry, which must not be shared.
+func (c *config) normalizeKeys(entry *Entry) {
+	var renamed []string
+	for k := range entry.Data {
+		if normalizeKey(k, c.keyCase) != k {
+			renamed = append(renamed, k)
+		}
+	}
+	sortStrings(renamed)
+	for _, k := range renamed {
+		v := entry.Data[k]
+		delete(entry.Data, k)
+		n := normalizeKey(k, c.keyCase)
+		if _, ok := entry.Data[n]; !ok {
+			entry.Data[n] = v
+		}
+	}
+}
+
+type keyCache struct {
+	keys sync.Map // string to string
+	size int64
+}
+
+var keyCaches [3]keyCache
+
+// normalizeKey returns the key in the case.
+func normalizeKey(key string, keyCase KeyCase) string {
+	if keyCase != SnakeCase && keyCase != CamelCase {
+		return key
+	}
+	cache := &keyCaches[keyCase]
+	if n, ok := cache.keys.Load(key); ok {
+		return n.(string)
+	}
+	segments := strings.Split(key, ".")
+	for i, s := range segments {
+		words := splitWords(s)
+		for j, w := range words {
+			w = strings.ToLower(w)
+			if keyCase == CamelCase && j > 0 {
+				r, size := utf8.DecodeRuneInString(w)
+				w = string(unicode.ToUpper(r)) + w[size:]
+			}
+			words[j] = w
+		}
+		if keyCase == SnakeCase {
+			segments[i] = strings.Join(words, "_")
+		} else {
+			segments[i] = strings.Join(words, "")
+		}
+	}
+	n := strings.Join(segments, ".")
+	if atomic.AddInt64(&cache.size, 1) <= maxCachedKeys {
+		cache.keys.Store(key, n)
+	}
+	return n
+}
+
+// splitWords splits s at underscores, hyphens, spaces and changes of case,
+// keeping acronyms together: "HTTPStatus_code" is "HTTP", "Status", "code".
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		key, snake, camel string
+	}{
+		{"user", "user", "user"},
+		{"userID", "user_id", "userId"},
+		{"user_id", "user_id", "userId"},
+		{"User-Name", "user_name", "userName"},
+		{"HTTPStatus_code", "http_status_code", "httpStatusCode"},
+		{"request id", "request_id", "requestId"},
+		{"http.requestMethod", "http.request_method", "http.requestMethod"},
+		{"__private__", "private", "private"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.snake, normalizeKey(tt.key, SnakeCase), tt.key)
+		assert.Equal(t, tt.camel, normalizeKey(tt.key, CamelCase), tt.key)
+		assert.Equal(t, tt.key, normalizeKey(tt.key, KeysAsIs), tt.key)
+	}
+}
+
+func TestWithKeyCase(t *testing.T) {
+	Init(JSONFormatter, InfoLevel, key("requestId"))
+	defer Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(WithKeyCase(KeysAsIs))
+
+	ctx := context.WithValue(context.Background(), key("requestId"), "r1")
+	Configure(WithKeyCase(SnakeCase))
+	Info(ctx, "snake", Field("userID", 1), Field("statusCode", 200))
+	assert.Equal(t, map[string]interface{}{"request_id": "r1", "user_id": 1, "status_code": 200}, map[string]interface{}(sink.entries[0].Data))
+
+	Configure(WithKeyCase(CamelCase))
+	Info(ctx, "camel", Field("user_id", 1), Field("userId", 2), Field("User-ID", 3), Field("USER_ID", 4))
+	assert.Equal(t, map[string]interface{}{"requestId": "r1", "userId": 2}, map[string]interface{}(sink.entries[1].Data))
+
+	Info(ctx, "camel", Field("user_id", 1), Field("User-ID", 3))
+	assert.Equal(t, map[string]interface{}{"requestId": "r1", "userId": 3}, map[string]interface{}(sink.entries[2].Data))
+}
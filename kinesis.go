@@ -0,0 +1,246 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// KinesisMaxRecordSize is the largest record Kinesis and Firehose accept.
+	KinesisMaxRecordSize = 1000 * 1024
+
+	kinesisMaxBatchRecords = 500
+	kinesisMaxBatchBytes   = 4 * 1024 * 1024
+
+	// kinesisPartOverhead is the size of a part header without the ID, with
+	// room for the numbers.
+	kinesisPartOverhead = len(`{"id":"","part":,"parts":}`+"\n") + 2*10
+)
+
+// kinesisSplits numbers the entries split over several records.
+var kinesisSplits uint64
+
+// KinesisRecord is a record written by the Kinesis sink. Firehose ignores the
+// partition key.
+type KinesisRecord struct {
+	PartitionKey string
+	Data         []byte
+}
+
+// KinesisClient writes records to a Kinesis data stream or Firehose delivery
+// stream, e.g. with PutRecords or PutRecordBatch. It is implemented by a thin
+// adapter around the AWS SDK so this package does not depend on it.
+// When only some records are written, as reported by the FailedRecordCount
+// of the response, it should return a *KinesisPutError so that only the
+// others are sent again.
+type KinesisClient interface {
+	PutRecords(stream string, records []KinesisRecord) error
+}
+
+// KinesisPutError reports the records of a PutRecords call that were not
+// written.
+type KinesisPutError struct {
+	// Failed holds the indexes of the records that were not written.
+	Failed []int
+	// Err is the error of the first failed record.
+	Err error
+}
+
+func (e *KinesisPutError) Error() string {
+	return fmt.Sprintf("%d records not written: %v", len(e.Failed), e.Err)
+}
+
+func (e *KinesisPutError) Unwrap() error {
+	return e.Err
+}
+
+// KinesisConfig configures a Kinesis sink.
+type KinesisConfig struct {
+	// Client writes the records.
+	Client KinesisClient
+	// Stream is the data stream or delivery stream name.
+	Stream string
+	// PartitionKeyField names the entry field used as partition key. Entries
+	// without it are spread by timestamp.
+	PartitionKeyField string
+	// Formatter renders records and defaults to JSON.
+	Formatter logrus.Formatter
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+}
+
+// KinesisSink writes entries to Kinesis Data Streams or Firehose in batches.
+// Entries larger than KinesisMaxRecordSize are split over several records with
+// the same partition key, and batches are split to respect the request limits.
+// Each part of a split entry starts with a line {"id":"...","part":1,"parts":3}
+// numbering it from 1; joining the rest of the parts of an ID in order
+// restores the entry. Records that fail are sent again on their own, and if
+// retrying fails the entries they belong to are spooled or queued.
+type KinesisSink struct {
+	cfg     KinesisConfig
+	batcher *batcher
+}
+
+// NewKinesisSink creates a Kinesis sink.
+func NewKinesisSink(cfg KinesisConfig) *KinesisSink {
+	if cfg.Formatter == nil {
+		cfg.Formatter = new(logrus.JSONFormatter)
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	s := &KinesisSink{cfg: cfg}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *KinesisSink) Write(entry *Entry) error {
+	data, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	key := strconv.FormatInt(entry.Time.UnixNano(), 36)
+	if v, ok := entry.Data[s.cfg.PartitionKeyField]; ok && s.cfg.PartitionKeyField != "" {
+		key = stringValue(v)
+	}
+	s.batcher.add(batchItem{key: key, time: entry.Time, data: data})
+	return nil
+}
+
+// send writes the records of items in requests within the limits. If a
+// request fails, the items with records that were not written and those not
+// sent yet are handed back to the batcher.
+func (s *KinesisSink) send(items []batchItem) error {
+	var (
+		records []KinesisRecord
+		owners  []int // the index of the item of each record
+		size    int
+	)
+	flush := func(next int) error {
+		failed, err := s.put(records)
+		if err == nil {
+			records, owners, size = nil, nil, 0
+			return nil
+		}
+		var retry []batchItem
+		seen := map[int]bool{}
+		for _, r := range failed {
+			if i := owners[r]; i < next && !seen[i] {
+				seen[i] = true
+				retry = append(retry, items[i])
+			}
+		}
+		retry = append(retry, items[next:]...)
+		return &partialSendError{items: retry, err: err}
+	}
+	for i, item := range items {
+		for _, chunk := range kinesisParts(item) {
+			if len(records) == kinesisMaxBatchRecords || size+len(chunk)+len(item.key) > kinesisMaxBatchBytes {
+				if err := flush(i); err != nil {
+					return err
+				}
+			}
+			records = append(records, KinesisRecord{PartitionKey: item.key, Data: chunk})
+			owners = append(owners, i)
+			size += len(chunk) + len(item.key)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return flush(len(items))
+}
+
+// kinesisParts returns the data of item as a record, or as numbered parts if
+// it does not fit into one.
+func kinesisParts(item batchItem) [][]byte {
+	if len(item.data)+len(item.key) <= KinesisMaxRecordSize {
+		return [][]byte{item.data}
+	}
+	id := strconv.FormatUint(atomic.AddUint64(&kinesisSplits, 1), 36) + "-" + strconv.FormatInt(item.time.UnixNano(), 36)
+	chunks := splitRecord(item.data, KinesisMaxRecordSize-len(item.key)-len(id)-kinesisPartOverhead)
+	parts := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		part := []byte(`{"id":"` + id + `","part":` + strconv.Itoa(i+1) + `,"parts":` + strconv.Itoa(len(chunks)) + "}\n")
+		parts[i] = append(part, chunk...)
+	}
+	return parts
+}
+
+// put writes records, retrying those that were not written, and returns the
+// indexes of those that still were not if it fails.
+func (s *KinesisSink) put(records []KinesisRecord) ([]int, error) {
+	pending := make([]int, len(records))
+	for i := range pending {
+		pending[i] = i
+	}
+	err := withRetries(s.cfg.MaxRetries, func() error {
+		batch := make([]KinesisRecord, len(pending))
+		for i, r := range pending {
+			batch[i] = records[r]
+		}
+		err := s.cfg.Client.PutRecords(s.cfg.Stream, batch)
+		var putErr *KinesisPutError
+		if errors.As(err, &putErr) {
+			var still []int
+			for _, i := range putErr.Failed {
+				if i >= 0 && i < len(pending) {
+					still = append(still, pending[i])
+				}
+			}
+			pending = still
+			if len(pending) == 0 {
+				return nil
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return pending, err
+	}
+	return nil, nil
+}
+
+// splitRecord splits data into chunks of at most size bytes, preferring to cut
+// after a newline.
+func splitRecord(data []byte, size int) (chunks [][]byte) {
+	for len(data) > size {
+		n := bytes.LastIndexByte(data[:size], '\n') + 1
+		if n == 0 {
+			n = size
+		}
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return append(chunks, data)
+}
+
+func (s *KinesisSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *KinesisSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *KinesisSink) Status() SinkHealth {
+	return s.batcher.status()
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testKinesisClient struct {
+	mu      sync.Mutex
+	batches [][]KinesisRecord
+	// fail returns the indexes of the records of a call to fail
+	fail func(records []KinesisRecord) []int
+}
+
+func (c *testKinesisClient) PutRecords(stream string, records []KinesisRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, records)
+	if c.fail != nil {
+		if failed := c.fail(records); len(failed) > 0 {
+			return &KinesisPutError{Failed: failed, Err: errors.New("ProvisionedThroughputExceededException")}
+		}
+	}
+	return nil
+}
+
+func TestSplitRecord(t *testing.T) {
+	assert.Equal(t, [][]byte{[]byte("abc")}, splitRecord([]byte("abc"), 3))
+	assert.Equal(t, [][]byte{[]byte("ab"), []byte("cd"), []byte("e")}, splitRecord([]byte("abcde"), 2))
+	assert.Equal(t, [][]byte{[]byte("a\n"), []byte("bcd")}, splitRecord([]byte("a\nbcd"), 3))
+}
+
+func TestKinesisSink(t *testing.T) {
+	client := &testKinesisClient{}
+	sink := NewKinesisSink(KinesisConfig{Client: client, Stream: "logs", PartitionKeyField: "tenant"})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("kinesis", sink)
+	ctx := context.Background()
+	Info(ctx, "Informational Message 1", Field("tenant", "acme"))
+	Info(ctx, strings.Repeat("x", KinesisMaxRecordSize), Field("tenant", "acme"))
+	assert.NoError(t, RemoveSink("kinesis"))
+
+	assert.Len(t, client.batches, 1)
+	records := client.batches[0]
+	assert.Len(t, records, 3)
+	var joined []byte
+	for i, r := range records {
+		assert.Equal(t, "acme", r.PartitionKey)
+		assert.LessOrEqual(t, len(r.Data)+len(r.PartitionKey), KinesisMaxRecordSize)
+		if i > 0 {
+			header := r.Data[:bytes.IndexByte(r.Data, '\n')+1]
+			assert.Regexp(t, `^\{"id":"\w+-\w+","part":`+strconv.Itoa(i)+`,"parts":2\}\n$`, string(header))
+			joined = append(joined, r.Data[len(header):]...)
+		}
+	}
+	assert.Contains(t, string(joined), `"msg":"xxx`)
+	assert.True(t, bytes.HasSuffix(joined, []byte("}\n")))
+	assert.Equal(t, bytes.IndexByte(records[1].Data, '\n'), bytes.IndexByte(records[2].Data, '\n'), "same ID in both parts")
+}
+
+func TestKinesisSinkPartialFailure(t *testing.T) {
+	attempts := 0
+	client := &testKinesisClient{fail: func(records []KinesisRecord) []int {
+		attempts++
+		switch attempts {
+		case 1:
+			return []int{1, 2}
+		default:
+			for i, r := range records {
+				if string(r.Data) == "c" {
+					return []int{i}
+				}
+			}
+			return nil
+		}
+	}}
+	sink := NewKinesisSink(KinesisConfig{Client: client, Stream: "logs", MaxRetries: 1})
+	defer sink.Close()
+	err := sink.send([]batchItem{{key: "k", data: []byte("a")}, {key: "k", data: []byte("b")}, {key: "k", data: []byte("c")}})
+	assert.Error(t, err)
+
+	assert.Len(t, client.batches, 2)
+	assert.Equal(t, []KinesisRecord{{"k", []byte("b")}, {"k", []byte("c")}}, client.batches[1])
+	failed := failedItems(nil, err)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "c", string(failed[0].data))
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"context"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The fields WithLambda adds.
+const (
+	LambdaRequestIDKey     = "aws_request_id"
+	LambdaFunctionKey      = "function_name"
+	LambdaVersionKey       = "function_version"
+	LambdaRemainingTimeKey = "remaining_time_ms"
+)
+
+type lambdaRequestKey struct{}
+
+// LambdaContext returns a context under which entries carry the request ID
+// of a Lambda invocation and, once WithLambda is set, the milliseconds left
+// before its deadline. The module does not depend on aws-lambda-go, so the
+// handler passes the ID from its lambdacontext:
+//
+//	lc, _ := lambdacontext.FromContext(ctx)
+//	ctx = log.LambdaContext(ctx, lc.AwsRequestID)
+func LambdaContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, lambdaRequestKey{}, requestID)
+}
+
+// WithLambda adds the function name and version the Lambda runtime sets in
+// the environment to every entry, and the request ID and remaining time of
+// contexts from LambdaContext. Use it with LambdaFormatter for logs CloudWatch
+// parses. Fields of a call or its context take precedence.
+func WithLambda() Option {
+	return func(c *config) {
+		fields := map[string]interface{}{}
+		if name := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); name != "" {
+			fields[LambdaFunctionKey] = name
+		}
+		if version := os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"); version != "" {
+			fields[LambdaVersionKey] = version
+		}
+		c.setStatic(fields)
+		c.lambda = true
+	}
+}
+
+// addLambdaFields adds the request ID and remaining time of a LambdaContext
+// to fields that do not have them.
+func (c *config) addLambdaFields(ctx context.Context, fields logrus.Fields) {
+	if ctx == nil {
+		return
+	}
+	id, ok := ctx.Value(lambdaRequestKey{}).(string)
+	if !ok {
+		return
+	}
+	if _, ok := fields[LambdaRequestIDKey]; !ok {
+		fields[LambdaRequestIDKey] = id
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if _, ok := fields[LambdaRemainingTimeKey]; !ok {
+			fields[LambdaRemainingTimeKey] = deadline.Sub(c.now()).Milliseconds()
+		}
+	}
+}
+
+// lambdaLevels are the levels of the Lambda JSON log format, by logrus level.
+var lambdaLevels = [...]string{"FATAL", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE"}
+
+// lambdaFormatter writes entries in the JSON log format of Lambda, one object
+// per line with timestamp, level, message and requestId, which CloudWatch
+// parses and the log level filtering of Lambda reads. Other fields are keys
+// of the object, those with the names of these keys under a "fields." prefix.
+type lambdaFormatter struct{}
+
+func (f *lambdaFormatter) Format(entry *Entry) ([]byte, error) {
+	cfg := currentConfig()
+	data := getFields()
+	defer putFields(data)
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	for _, k := range [...]string{"timestamp", "level", "message", "requestId", "caller"} {
+		if v, ok := data[k]; ok {
+			data["fields."+k] = v
+			delete(data, k)
+		}
+	}
+	if id, ok := data[LambdaRequestIDKey]; ok {
+		data["requestId"] = id
+		delete(data, LambdaRequestIDKey)
+	}
+	data["timestamp"] = entry.Time.UTC().Format("2006-01-02T15:04:05.000Z")
+	data["level"] = "INFO"
+	if int(entry.Level) < len(lambdaLevels) {
+		data["level"] = lambdaLevels[entry.Level]
+	}
+	data["message"] = entry.Message
+	if entry.HasCaller() {
+		data["caller"] = callerLocation(entry)
+	}
+	return writeJSONLine(entry, cfg, data)
+}
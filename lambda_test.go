@@ -0,0 +1,66 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLambda(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "orders")
+	t.Setenv("AWS_LAMBDA_FUNCTION_VERSION", "7")
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ctx = LambdaContext(ctx, "req-1")
+	Info(ctx, "before")
+	assert.NotContains(t, sink.entries[0].Data, LambdaRequestIDKey)
+
+	Configure(WithLambda())
+	defer Configure(func(c *config) {
+		c.lambda = false
+		c.static = nil
+	})
+	Info(ctx, "invoked")
+	Info(context.Background(), "outside")
+	Info(ctx, "overridden", Field(LambdaRequestIDKey, "req-2"))
+
+	data := sink.entries[1].Data
+	assert.Equal(t, "req-1", data[LambdaRequestIDKey])
+	assert.Equal(t, "orders", data[LambdaFunctionKey])
+	assert.Equal(t, "7", data[LambdaVersionKey])
+	remaining := data[LambdaRemainingTimeKey].(int64)
+	assert.True(t, remaining > 50000 && remaining <= 60000, remaining)
+	assert.Equal(t, logrus.Fields{LambdaFunctionKey: "orders", LambdaVersionKey: "7"}, sink.entries[2].Data)
+	assert.Equal(t, "req-2", sink.entries[3].Data[LambdaRequestIDKey])
+}
+
+func TestLambdaFormatter(t *testing.T) {
+	assert.Equal(t, LambdaFormatter, FormatterFromName("lambda"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		LambdaRequestIDKey: "req-1",
+		"level":            "clash",
+		"user":             "jane",
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 120000000, time.FixedZone("CEST", 2*60*60))
+	entry.Level = WarnLevel
+	entry.Message = "slow"
+	got, err := new(lambdaFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fields.level":"clash","level":"WARN","message":"slow","requestId":"req-1",`+
+		`"timestamp":"2024-05-06T05:08:09.120Z","user":"jane"}`+"\n", string(got))
+
+	entry.Data = logrus.Fields{}
+	entry.Level = PanicLevel
+	got, err = new(lambdaFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"FATAL","message":"slow","timestamp":"2024-05-06T05:08:09.120Z"}`+"\n", string(got))
+}
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// multiError collects the errors of operations that continue after failures.
+type multiError []error
+
+func (m multiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the collected errors.
+func (m multiError) Unwrap() []error {
+	return m
+}
+
+// err returns nil if no error was collected, the error if only one was, and m
+// otherwise.
+func (m multiError) err() error {
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	}
+	return m
+}
+
+// Flush writes everything the logger holds: it writes pending repeat counts,
+// drains the async queue, flushes a buffered output and flushes every sink. It
+// returns the errors of all sinks that failed, or the context's error if ctx is
+// done first, in which case the flush continues in the background.
+func Flush(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- flush()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func flush() error {
+	flushRepeats()
+	drainAsync()
+
+	var errs multiError
+	if err := flushOutput(); err != nil {
+		errs = append(errs, fmt.Errorf("output: %w", err))
+	}
+	Sync()
+
+	sinksMu.Lock()
+	hooks := append([]*sinkHook(nil), sinks...)
+	sinksMu.Unlock()
+	for _, h := range hooks {
+		if err := h.sink.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", h.name, err))
+		}
+	}
+	return errs.err()
+}
+
+// Close shuts the logger down, typically deferred in main: it writes what is
+// queued, stops asynchronous logging, logs the summary of dropped entries that
+// is still due, and flushes the output. Then it detaches and closes every sink,
+// which stops their background goroutines. Entries logged afterwards are
+// written synchronously to stderr.
+func Close() error {
+	flushRepeats()
+	disableAsync()
+	stopDropSummary()
+
+	var errs multiError
+	if err := flushOutput(); err != nil {
+		errs = append(errs, fmt.Errorf("output: %w", err))
+	}
+
+	sinksMu.Lock()
+	hooks := sinks
+	sinks = nil
+	replaceHooks()
+	sinksMu.Unlock()
+	for _, h := range hooks {
+		if err := h.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", h.name, err))
+		}
+	}
+
+	logger.SetOutput(os.Stderr)
+	return errs.err()
+}
+
+// SetExitFunc sets the function Fatal exits the program with, os.Exit by
+// default, so tests can run Fatal paths without the process dying. A nil
+// function restores os.Exit.
+func SetExitFunc(exit func(code int)) {
+	logger.ExitFunc = exit
+}
+
+// RegisterExitHandler adds a function Fatal runs after writing the entry and
+// flushing the sinks and before exiting, such as closing a database or
+// flushing traces. Handlers run in the order they were registered; a handler
+// that panics is reported on stderr and does not stop the others.
+func RegisterExitHandler(handler func()) {
+	logrus.RegisterExitHandler(handler)
+}
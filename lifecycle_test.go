@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flushSink struct {
+	memorySink
+	flushed int
+	err     error
+	delay   time.Duration
+}
+
+func (s *flushSink) Flush() error {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushed++
+	return s.err
+}
+
+func TestFlush(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	ok := &flushSink{}
+	failing1 := &flushSink{err: errors.New("unavailable")}
+	failing2 := &flushSink{err: errors.New("disk full")}
+	AddSink("ok", ok)
+	AddSink("failing1", failing1)
+	AddSink("failing2", failing2)
+	defer func() {
+		_ = RemoveSink("ok")
+		_ = RemoveSink("failing1")
+		_ = RemoveSink("failing2")
+	}()
+
+	EnableAsync(AsyncConfig{})
+	defer Close()
+	Info(ctx, "Informational Message 1")
+	err := Flush(ctx)
+	assert.EqualError(t, err, "sink failing1: unavailable; sink failing2: disk full")
+	assert.Len(t, err.(multiError).Unwrap(), 2)
+	assert.Len(t, ok.entries, 1)
+	assert.Equal(t, 1, ok.flushed)
+}
+
+func TestFlushDeadline(t *testing.T) {
+	sink := &flushSink{delay: time.Second}
+	AddSink("slow", sink)
+	defer func() { _ = RemoveSink("slow") }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.Equal(t, context.DeadlineExceeded, Flush(ctx))
+	assert.True(t, time.Since(start) < sink.delay)
+}
+
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	buf := &bytes.Buffer{}
+	logger.SetOutput(buf)
+	sink := &flushSink{}
+	AddSink("memory", sink)
+	EnableAsync(AsyncConfig{})
+	SetDropSummaryInterval(time.Hour)
+	defer SetDropSummaryInterval(DefaultDropSummaryInterval)
+
+	Info(ctx, "Informational Message 1")
+	countDrop(dropQueueFull, DebugLevel)
+	assert.NoError(t, Close())
+	assert.True(t, sink.closed)
+	assert.Len(t, sink.entries, 2)
+	assert.Contains(t, sink.entries[1].Message, "debug entries in last 1h0m0s")
+	assert.Contains(t, buf.String(), "Informational Message 1")
+	assert.Equal(t, os.Stderr, logger.Out)
+	assert.Empty(t, SinkStatus())
+
+	Info(ctx, "Informational Message 2")
+	assert.Len(t, sink.entries, 2)
+	assert.NotContains(t, buf.String(), "Informational Message 2")
+}
+
+func TestExitHandlers(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	SetOutput(io.Discard)
+	defer SetOutput(os.Stderr)
+	var calls []string
+	RegisterExitHandler(func() { calls = append(calls, "first") })
+	RegisterExitHandler(func() { panic("failed") })
+	RegisterExitHandler(func() { calls = append(calls, "last") })
+	code := -1
+	SetExitFunc(func(c int) { code = c })
+	defer SetExitFunc(nil)
+
+	Fatal(context.Background(), "Fatal Message 1")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, []string{"first", "last"}, calls)
+}
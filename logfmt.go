@@ -0,0 +1,81 @@
+package log
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// logfmtFormatter writes entries as logfmt, such as
+// ts=2024-05-06T07:08:09Z level=info msg="order placed" id=42. Fields follow
+// in the order set with WithFieldOrder, those named ts, level, msg or caller
+// under a "fields." prefix. Values are quoted when they contain spaces, equals
+// signs, quotes or control characters; characters keys cannot contain are
+// replaced with underscores.
+type logfmtFormatter struct{}
+
+func (f *logfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	writeLogfmt(b, "ts", entry.Time.Format(time.RFC3339))
+	b.WriteByte(' ')
+	writeLogfmt(b, "level", entry.Level.String())
+	b.WriteByte(' ')
+	writeLogfmt(b, "msg", entry.Message)
+	if entry.HasCaller() {
+		b.WriteByte(' ')
+		writeLogfmt(b, "caller", shortPath(entry.Caller.File)+":"+strconv.Itoa(entry.Caller.Line))
+	}
+	for _, k := range fieldKeys(entry) {
+		v := entry.Data[k]
+		s, ok := v.(string)
+		if !ok {
+			if err, isErr := v.(error); isErr {
+				s = err.Error()
+			} else {
+				s = jsonString(v)
+			}
+		}
+		switch k {
+		case "ts", "level", "msg", "caller":
+			k = "fields." + k
+		}
+		b.WriteByte(' ')
+		writeLogfmt(b, k, s)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// writeLogfmt writes a key=value pair.
+func writeLogfmt(b *bytes.Buffer, k, v string) {
+	if k == "" {
+		k = "_"
+	}
+	for _, r := range k {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError || !unicode.IsPrint(r) {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('=')
+	if logfmtNeedsQuoting(v) {
+		b.WriteString(strconv.Quote(v))
+		return
+	}
+	b.WriteString(v)
+}
+
+func logfmtNeedsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || r == utf8.RuneError || !unicode.IsPrint(r)
+	}) >= 0
+}
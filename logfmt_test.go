@@ -0,0 +1,33 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	assert.Equal(t, LogfmtFormatter, FormatterFromName("logfmt"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"id":      42,
+		"user":    "jane doe",
+		"path":    "/a=b",
+		"quote":   `say "hi"`,
+		"empty":   "",
+		"err":     errors.New("failed"),
+		"tags":    []string{"a", "b"},
+		"msg":     "clash",
+		"bad key": "x\ny",
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entry.Level = InfoLevel
+	entry.Message = "order placed"
+	got, err := new(logfmtFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `ts=2024-05-06T07:08:09Z level=info msg="order placed" bad_key="x\ny" empty="" err=failed `+
+		`id=42 fields.msg=clash path="/a=b" quote="say \"hi\"" tags="[\"a\",\"b\"]" user="jane doe"`+"\n", string(got))
+}
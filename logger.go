@@ -1,11 +1,18 @@
 package log
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/sirupsen/logrus"
 )
@@ -21,6 +28,35 @@ const (
 	SimpleFormatter Formatter = iota
 	TextFormatter
 	JSONFormatter
+	// DevFormatter writes entries over several lines, with fields indented
+	// below the message, for reading during development.
+	DevFormatter
+	// LogfmtFormatter writes entries as logfmt: ts=... level=info msg=...
+	// key=value.
+	LogfmtFormatter
+	// ECSFormatter writes entries as Elastic Common Schema JSON, with fields
+	// under labels.
+	ECSFormatter
+	// GCPFormatter writes entries as the JSON Google Cloud Logging parses from
+	// stdout.
+	GCPFormatter
+	// LogstashFormatter writes entries as Logstash v1 JSON events.
+	LogstashFormatter
+	// LTSVFormatter writes entries as Labeled Tab-Separated Values.
+	LTSVFormatter
+	// MsgpackFormatter writes entries as MessagePack maps, read back with
+	// MsgpackDecoder.
+	MsgpackFormatter
+	// CBORFormatter writes entries as CBOR maps.
+	CBORFormatter
+	// SyslogFormatter writes entries as RFC 5424 syslog lines.
+	SyslogFormatter
+	// CombinedFormatter writes entries in the Apache Combined Log Format,
+	// taking the request from a GCPHTTPRequest field.
+	CombinedFormatter
+	// LambdaFormatter writes entries in the JSON log format of AWS Lambda,
+	// which CloudWatch parses; see WithLambda.
+	LambdaFormatter
 )
 
 type Level = logrus.Level
@@ -46,42 +82,193 @@ const (
 	TraceLevel = logrus.TraceLevel
 )
 
+// SimpleLayout sets what the simple formatter writes besides the message and
+// fields.
+type SimpleLayout struct {
+	// TimeLayout, if set, is the layout the entry time is written with, such
+	// as time.RFC3339.
+	TimeLayout string
+	// Level writes the entry level.
+	Level bool
+	// AsFields writes the time and level as the first fields, time and level,
+	// instead of before the message.
+	AsFields bool
+}
+
+// WithSimpleLayout sets what the simple formatter writes besides the message
+// and fields. By default it writes neither the time nor the level.
+func WithSimpleLayout(layout SimpleLayout) Option {
+	return func(c *config) {
+		c.simpleLayout = layout
+	}
+}
+
+// simpleFormatter writes the message and the fields as "msg | k=v | k=v",
+// optionally preceded by the time and level; see WithSimpleLayout. The
+// message, keys and values are quoted as Go strings when they would be
+// ambiguous: when they contain the separator, an equals sign (except in the
+// message), a control character or surrounding spaces. With colors, the
+// message and level have the color of the level and keys are dimmed; see
+// WithColor.
 type simpleFormatter struct{}
 
 func (s *simpleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
-	if len(entry.Data) > 0 {
-		data := strings.Builder{}
-		for k, v := range entry.Data {
-			data.WriteString(" | ")
-			data.WriteString(k)
-			data.WriteRune('=')
-			sv, ok := v.(string)
-			if !ok {
-				sv = jsonString(v)
-			}
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	cfg := currentConfig()
+	layout := cfg.simpleLayout
+	color := colored(entry)
+	if !layout.AsFields {
+		if layout.TimeLayout != "" {
+			b.WriteString(entry.Time.Format(layout.TimeLayout))
+			b.WriteByte(' ')
+		}
+		if layout.Level {
+			writeColored(b, color, levelColor(entry.Level), strings.ToUpper(entry.Level.String()))
+			b.WriteByte(' ')
+		}
+	}
+	if color {
+		b.WriteString(levelColor(entry.Level))
+	}
+	writeSimple(b, entry.Message, "|")
+	if color {
+		b.WriteString(colorReset)
+	}
+	if layout.AsFields {
+		if layout.TimeLayout != "" {
+			writeSimpleField(b, color, logrus.FieldKeyTime, entry.Time.Format(layout.TimeLayout))
+		}
+		if layout.Level {
+			writeSimpleField(b, color, logrus.FieldKeyLevel, entry.Level.String())
+		}
+	}
+	if entry.HasCaller() {
+		writeSimpleField(b, color, "caller", callerLocation(entry))
+	}
+	for _, k := range fieldKeys(entry) {
+		v := entry.Data[k]
+		if ns, ok := v.(namespace); ok {
+			writeSimpleNamespace(b, cfg, color, k+".", ns)
+			continue
+		}
+		sv, ok := v.(string)
+		if !ok {
+			sv = jsonString(v)
+		}
+		writeSimpleField(b, color, k, sv)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func writeSimpleField(b *bytes.Buffer, color bool, k, v string) {
+	b.WriteString(" | ")
+	if color {
+		b.WriteString(colorDim)
+	}
+	writeSimple(b, k, "|=")
+	if color {
+		b.WriteString(colorReset)
+	}
+	b.WriteByte('=')
+	writeSimple(b, v, "|=")
+}
 
-			data.WriteString(sv)
+// writeSimple writes s, quoted if it contains any of the special characters,
+// a control character or surrounding spaces.
+func writeSimple(b *bytes.Buffer, s, special string) {
+	if needsQuoting(s, special) {
+		b.WriteString(strconv.Quote(s))
+		return
+	}
+	b.WriteString(s)
+}
+
+func needsQuoting(s, special string) bool {
+	if s == "" {
+		return false
+	}
+	if s[0] == ' ' || s[len(s)-1] == ' ' {
+		return true
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f || r == utf8.RuneError || r == '\u2028' || r == '\u2029' || strings.ContainsRune(special, r) {
+			return true
 		}
-		return []byte(entry.Message + "  " + data.String() + "\n"), nil
 	}
-	return []byte(entry.Message + "\n"), nil
+	return false
 }
 
+var (
+	formattersMu     sync.RWMutex
+	customFormatters = map[Formatter]logrus.Formatter{}
+)
+
 var formatMap = map[string]Formatter{
-	"simple": SimpleFormatter,
-	"text":   TextFormatter,
-	"json":   JSONFormatter,
+	"simple":   SimpleFormatter,
+	"text":     TextFormatter,
+	"json":     JSONFormatter,
+	"dev":      DevFormatter,
+	"logfmt":   LogfmtFormatter,
+	"ecs":      ECSFormatter,
+	"gcp":      GCPFormatter,
+	"logstash": LogstashFormatter,
+	"ltsv":     LTSVFormatter,
+	"msgpack":  MsgpackFormatter,
+	"cbor":     CBORFormatter,
+	"syslog":   SyslogFormatter,
+	"combined": CombinedFormatter,
+	"lambda":   LambdaFormatter,
 }
 
-func FormatterFromName(name string) (f Formatter) {
-	var ok bool
+// RegisterFormatter makes a custom formatter available by name, case
+// insensitively, to FormatterFromName and ParseFormatter, and returns the
+// Formatter to pass to Init. It panics if the name is registered twice or the
+// formatter is nil.
+func RegisterFormatter(name string, f logrus.Formatter) Formatter {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	if f == nil {
+		panic("log: RegisterFormatter formatter is nil")
+	}
 	name = strings.ToLower(name)
-	if f, ok = formatMap[name]; ok {
+	if _, dup := formatMap[name]; dup {
+		panic("log: RegisterFormatter called twice for formatter " + name)
+	}
+	id := LambdaFormatter + 1 + Formatter(len(customFormatters))
+	formatMap[name] = id
+	customFormatters[id] = f
+	return id
+}
+
+// FormatterFromName returns the formatter of the given name, or
+// JSONFormatter if there is none.
+func FormatterFromName(name string) (f Formatter) {
+	if f, err := ParseFormatter(name); err == nil {
 		return f
 	}
 	return JSONFormatter
 }
 
+// ParseFormatter returns the formatter of the given name, or an error if
+// there is none.
+func ParseFormatter(name string) (Formatter, error) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	if f, ok := formatMap[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	return 0, fmt.Errorf("unknown formatter %q", name)
+}
+
+// SetOutput sets where entries are written, by default stderr.
+func SetOutput(w io.Writer) {
+	logger.SetOutput(w)
+}
+
 func SetLevel(level Level) {
 	logger.SetLevel(level)
 }
@@ -94,28 +281,139 @@ func IsLevelEnabled(level Level) bool {
 	return logger.IsLevelEnabled(level)
 }
 
+// Enabled reports whether entries of the level logged with ctx are kept
+// anywhere: written, held by the flight recorder or by a debug buffer in ctx.
+// Use it to skip building expensive arguments.
+func Enabled(ctx context.Context, level Level) bool {
+	return logged(ctx, level)
+}
+
+// DebugEnabled reports whether Debug entries logged with ctx are kept.
+func DebugEnabled(ctx context.Context) bool {
+	return logged(ctx, DebugLevel)
+}
+
 func Init(formatter Formatter, level Level, contextFields ...interface{}) {
 	switch formatter {
 	case JSONFormatter:
-		logger.SetFormatter(new(logrus.JSONFormatter))
+		logger.SetFormatter(new(jsonFormatter))
 	case TextFormatter:
-		logger.SetFormatter(new(logrus.TextFormatter))
+		logger.SetFormatter(newTextFormatter())
 	case SimpleFormatter:
 		logger.SetFormatter(new(simpleFormatter))
+	case DevFormatter:
+		logger.SetFormatter(new(devFormatter))
+	case LogfmtFormatter:
+		logger.SetFormatter(new(logfmtFormatter))
+	case ECSFormatter:
+		logger.SetFormatter(new(ecsFormatter))
+	case GCPFormatter:
+		logger.SetFormatter(new(gcpFormatter))
+	case LogstashFormatter:
+		logger.SetFormatter(new(logstashFormatter))
+	case LTSVFormatter:
+		logger.SetFormatter(new(ltsvFormatter))
+	case MsgpackFormatter:
+		logger.SetFormatter(new(msgpackFormatter))
+	case CBORFormatter:
+		logger.SetFormatter(new(cborFormatter))
+	case SyslogFormatter:
+		logger.SetFormatter(new(syslogFormatter))
+	case CombinedFormatter:
+		logger.SetFormatter(new(combinedFormatter))
+	case LambdaFormatter:
+		logger.SetFormatter(new(lambdaFormatter))
+	default:
+		formattersMu.RLock()
+		f, ok := customFormatters[formatter]
+		formattersMu.RUnlock()
+		if ok {
+			logger.SetFormatter(f)
+		}
 	}
 	logger.SetLevel(level)
 	ctxFields = contextFields
 }
 
+// fieldsPool holds the maps fields are collected in while formatting.
+var fieldsPool = sync.Pool{
+	New: func() interface{} {
+		return make(logrus.Fields)
+	},
+}
+
+func getFields() logrus.Fields {
+	return fieldsPool.Get().(logrus.Fields)
+}
+
+func putFields(fields logrus.Fields) {
+	for k := range fields {
+		delete(fields, k)
+	}
+	fieldsPool.Put(fields)
+}
+
 func withContext(ctx context.Context) *logrus.Entry {
-	fields := logrus.Fields{}
-	for _, f := range ctxFields {
-		val := ctx.Value(f)
-		if val != nil {
-			fields[fmt.Sprintf("%v", f)] = val.(string)
+	return newEntry(ctx, nil)
+}
+
+// newEntry builds the entry for a call, merging the context fields, the
+// call's fields, the dynamic fields and the static fields, each taking
+// precedence over those after it, into a single map.
+func newEntry(ctx context.Context, flds []Fld) *logrus.Entry {
+	cfg := currentConfig()
+	bound := contextFields(ctx)
+	fields := make(logrus.Fields, len(cfg.static)+len(cfg.dynamic)+len(ctxFields)+len(bound.fields)+len(flds))
+	if cfg.collisions == Overwrite {
+		for _, f := range ctxFields {
+			val := ctx.Value(f)
+			if val != nil {
+				fields[fmt.Sprintf("%v", f)] = val.(string)
+			}
+		}
+		bound.apply(fields)
+		for _, f := range flds {
+			f.apply(fields)
+		}
+	} else {
+		for _, f := range flds {
+			f.apply(fields)
+		}
+		for _, f := range ctxFields {
+			val := ctx.Value(f)
+			if val == nil {
+				continue
+			}
+			k := fmt.Sprintf("%v", f)
+			if v, ok := fields[k]; ok {
+				cfg.keepCollision(fields, k, v, "a context field")
+			}
+			fields[k] = val.(string)
+		}
+		for k, v := range bound.fields {
+			if old, ok := fields[k]; ok {
+				cfg.keepCollision(fields, k, old, "a context field")
+			}
+			fields[k] = v
+		}
+	}
+	if cfg.lambda {
+		cfg.addLambdaFields(ctx, fields)
+	}
+	for _, f := range cfg.dynamic {
+		if _, ok := fields[f.key]; !ok {
+			fields[f.key] = f.get()
 		}
 	}
-	return logger.WithFields(fields)
+	for k, v := range cfg.static {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	if cfg.fieldOrder == InsertionOrder {
+		ctx = withFieldOrder(ctx, flds)
+	}
+	return &logrus.Entry{Logger: logger, Data: fields, Context: ctx}
 }
 
 type Fld interface {
@@ -132,70 +430,152 @@ func (f *fld) apply(fields logrus.Fields) {
 }
 
 func Field(key string, value interface{}) Fld {
-	if err, ok := value.(error); ok {
-		value = err.Error()
+	switch v := value.(type) {
+	case nil:
+	case LogMarshaler:
+		value = marshalLog(v)
+	case error:
+		value = v.Error()
+	default:
+		// keep fields tagged log:"-" or log:"mask" from any formatter or sink
+		if hasLogTags(reflect.TypeOf(v)) {
+			if data, err := appendJSON(nil, v); err == nil {
+				value = &encodedJSON{value: string(data), data: data}
+			}
+		}
 	}
 	return &fld{key: key, value: value}
 }
 
-func withFields(entry *logrus.Entry, flds []Fld) *logrus.Entry {
-	fields := make(logrus.Fields)
-	for _, f := range flds {
-		f.apply(fields)
-	}
-	return entry.WithFields(fields)
-}
-
 // Info prints logs while attempting to JSON dump any non-primitive argument.
 func Info(ctx context.Context, i interface{}, flds ...Fld) {
-	withFields(withContext(ctx), flds).Info(i)
+	if !logged(ctx, InfoLevel) {
+		return
+	}
+	emit(newEntry(ctx, flds), InfoLevel, i)
 }
 
 // Infof prints formatted logs while attempting to JSON dump any non-primitive argument.
 func Infof(ctx context.Context, format string, a ...interface{}) {
-	withContext(ctx).Infof(format, normalizeArgs(a)...)
+	if !logged(ctx, InfoLevel) {
+		return
+	}
+	emit(withContext(ctx), InfoLevel, fmt.Sprintf(format, normalizeArgs(a)...))
 }
 
 // Warn prints logs while attempting to JSON dump any non-primitive argument.
 func Warn(ctx context.Context, w interface{}, flds ...Fld) {
-	withFields(withContext(ctx), flds).Warn(w)
+	if !logged(ctx, WarnLevel) {
+		return
+	}
+	emit(newEntry(ctx, flds), WarnLevel, w)
 }
 
 // Warnf prints formatted logs while attempting to JSON dump any non-primitive argument.
 func Warnf(ctx context.Context, format string, a ...interface{}) {
-	withContext(ctx).Warnf(format, normalizeArgs(a)...)
+	if !logged(ctx, WarnLevel) {
+		return
+	}
+	emit(withContext(ctx), WarnLevel, fmt.Sprintf(format, normalizeArgs(a)...))
 }
 
 // Error prints logs while attempting to JSON dump any non-primitive argument.
 func Error(ctx context.Context, e interface{}, flds ...Fld) {
-	withFields(withContext(ctx), flds).Error(e)
+	if !logged(ctx, ErrorLevel) {
+		return
+	}
+	emit(newEntry(ctx, flds), ErrorLevel, e)
 }
 
 func Errorf(ctx context.Context, format string, a ...interface{}) {
-	withContext(ctx).Errorf(format, normalizeArgs(a)...)
+	if !logged(ctx, ErrorLevel) {
+		return
+	}
+	emit(withContext(ctx), ErrorLevel, fmt.Sprintf(format, normalizeArgs(a)...))
 }
 
 // Debug prints debug logs while attempting to JSON dump any non-primitive argument.
 func Debug(ctx context.Context, d interface{}, flds ...Fld) {
-	withFields(withContext(ctx), flds).Debug(d)
+	if !logged(ctx, DebugLevel) {
+		return
+	}
+	emit(newEntry(ctx, flds), DebugLevel, d)
 }
 
 // Debugf prints formatted debug logs while attempting to JSON dump any non-primitive argument.
 func Debugf(ctx context.Context, format string, a ...interface{}) {
-	withContext(ctx).Debugf(format, normalizeArgs(a)...)
+	if !logged(ctx, DebugLevel) {
+		return
+	}
+	emit(withContext(ctx), DebugLevel, fmt.Sprintf(format, normalizeArgs(a)...))
 }
 
-func Fatal(ctx context.Context, err error) {
-	withContext(ctx).Fatal(err)
+// Fatal logs v and the fields at Fatal level, then flushes the output and
+// every sink, waiting at most fatalFlushTimeout, and exits with status 1.
+func Fatal(ctx context.Context, v interface{}, flds ...Fld) {
+	beforeFatal(ctx)
+	entry := newEntry(ctx, flds)
+	addErrorFields(entry, v)
+	fatal(entry, fmt.Sprint(v))
 }
 
+// Fatalf logs a formatted message at Fatal level and exits like Fatal.
 func Fatalf(ctx context.Context, format string, args ...interface{}) {
-	withContext(ctx).Fatalf(format, args...)
+	beforeFatal(ctx)
+	fatal(withContext(ctx), fmt.Sprintf(format, args...))
+}
+
+// fatalFlushTimeout bounds how long Fatal waits for the output and sinks
+// before exiting.
+const fatalFlushTimeout = 5 * time.Second
+
+// fatal logs the Fatal entry, prepared like any other, flushes and exits.
+func fatal(entry *Entry, msg string) {
+	cfg := currentConfig()
+	// Fatal entries are never suppressed
+	takeRateLimit(entry)
+	entry.Time = cfg.now()
+	if cfg.caller {
+		entry.Context = withCaller(entry.Context, 2)
+	}
+	if cfg.goroutineID {
+		entry.Data[GoroutineKey] = goroutineID()
+	}
+	cfg.attachStack(entry, FatalLevel, 1)
+	if cfg.sequence {
+		entry.Data[SequenceKey] = nextSequence()
+	}
+	msg = cfg.prepare(entry, msg)
+	if cfg.allowedKeys != nil {
+		// the limits may have added the truncated field
+		cfg.dropDisallowed(entry)
+	}
+	entry.Log(FatalLevel, msg)
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	defer cancel()
+	if err := Flush(ctx); err != nil {
+		reportError(err)
+	}
+	entry.Logger.Exit(1)
+}
+
+// beforeFatal writes everything that must precede a Fatal entry.
+func beforeFatal(ctx context.Context) {
+	flushRepeats()
+	drainAsync()
+	if err := DumpFlightRecorder(); err != nil {
+		reportError(err)
+	}
+	if b := debugBufferFrom(ctx); b != nil {
+		b.flush()
+	}
 }
 
 func normalizeArgs(a []interface{}) (n []interface{}) {
 	for _, i := range a {
 		switch v := i.(type) {
+		case LogMarshaler:
+			n = append(n, jsonString(marshalLog(v)))
 		case string, int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, float32, float64, bool, fmt.Stringer, error:
 			n = append(n, v)
 		default:
@@ -205,14 +585,31 @@ func normalizeArgs(a []interface{}) (n []interface{}) {
 	return
 }
 
+var marshalErrors uint64
+
+// MarshalErrors returns the number of values that could not be encoded as
+// JSON and were logged as "<marshal error: ...>" instead.
+func MarshalErrors() uint64 {
+	return atomic.LoadUint64(&marshalErrors)
+}
+
+// jsonString returns the JSON encoding of v, or a placeholder with the error
+// if it cannot be encoded.
 func jsonString(v interface{}) string {
-	b, err := json.Marshal(v)
+	scratch := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(scratch)
+	b, err := appendJSON((*scratch)[:0], v)
+	*scratch = b
 	if err != nil {
-		return ""
+		atomic.AddUint64(&marshalErrors, 1)
+		return "<marshal error: " + err.Error() + ">"
 	}
 	return string(b)
 }
 
+// Sync commits stdout and stderr to stable storage.
+//
+// Deprecated: Sync does not write queued or buffered entries; use Flush.
 func Sync() {
 	_ = os.Stderr.Sync()
 	_ = os.Stdout.Sync()
@@ -1,8 +1,15 @@
 package log
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -37,6 +44,14 @@ func TestNormalizeArgs(t *testing.T) {
 	assert.Equal(t, res, []interface{}{testJSON, testStr, testInt, testBool, testFloat, testJSONResult, testStruct0Result})
 }
 
+func TestNormalizeArgsMarshalError(t *testing.T) {
+	errs := MarshalErrors()
+	res := normalizeArgs([]interface{}{map[string]interface{}{"f": func() {}}, math.Inf(1)})
+	assert.Equal(t, []interface{}{"<marshal error: json: unsupported type: func()>", math.Inf(1)}, res)
+	assert.Equal(t, "<marshal error: json: unsupported value: NaN>", jsonString(math.NaN()))
+	assert.Equal(t, errs+2, MarshalErrors())
+}
+
 type key string
 
 func TestLogging(t *testing.T) {
@@ -83,3 +98,172 @@ func TestLogging(t *testing.T) {
 	Error(ctx, "Error Message 1")
 	Errorf(ctx, "Error Message %d", 2)
 }
+
+func TestSimpleFormatter(t *testing.T) {
+	entry := logger.WithFields(logrus.Fields{
+		"plain": "value",
+		"pipe":  "a | b",
+		"eq":    "k=v",
+		"multi": "line 1\nline 2",
+		"pad":   " padded",
+		"empty": "",
+		"map":   map[string]string{"a": "b"},
+		"a key": "x",
+		"k=v":   1,
+	})
+	entry.Message = "message with = and\ttab"
+	got, err := new(simpleFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `"message with = and\ttab" | a key=x | empty= | eq="k=v" | "k=v"=1 | map={"a":"b"} | `+
+		`multi="line 1\nline 2" | pad=" padded" | pipe="a | b" | plain=value`+"\n", string(got))
+
+	entry = logger.WithFields(logrus.Fields{})
+	entry.Message = "a | b"
+	got, err = new(simpleFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "\"a | b\"\n", string(got))
+}
+
+func TestSimpleLayout(t *testing.T) {
+	entry := logger.WithField("k", "v")
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entry.Level = WarnLevel
+	entry.Message = "disk low"
+	defer Configure(WithSimpleLayout(SimpleLayout{}))
+
+	tests := []struct {
+		layout SimpleLayout
+		want   string
+	}{
+		{SimpleLayout{}, "disk low | k=v\n"},
+		{SimpleLayout{TimeLayout: time.RFC3339}, "2024-05-06T07:08:09Z disk low | k=v\n"},
+		{SimpleLayout{Level: true}, "WARNING disk low | k=v\n"},
+		{SimpleLayout{TimeLayout: time.Kitchen, Level: true}, "7:08AM WARNING disk low | k=v\n"},
+		{SimpleLayout{TimeLayout: time.RFC3339, Level: true, AsFields: true}, "disk low | time=2024-05-06T07:08:09Z | level=warning | k=v\n"},
+	}
+	for _, tt := range tests {
+		Configure(WithSimpleLayout(tt.layout))
+		got, err := new(simpleFormatter).Format(entry)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, string(got))
+	}
+}
+
+type upperFormatter struct{}
+
+func (upperFormatter) Format(entry *Entry) ([]byte, error) {
+	return []byte(strings.ToUpper(entry.Message) + "\n"), nil
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	f := RegisterFormatter("Upper", upperFormatter{})
+	t.Cleanup(func() {
+		formattersMu.Lock()
+		defer formattersMu.Unlock()
+		delete(formatMap, "upper")
+		delete(customFormatters, f)
+	})
+	assert.Equal(t, f, FormatterFromName("UPPER"))
+	got, err := ParseFormatter("upper")
+	assert.NoError(t, err)
+	assert.Equal(t, f, got)
+	assert.Panics(t, func() { RegisterFormatter("upper", upperFormatter{}) })
+	assert.Panics(t, func() { RegisterFormatter("json", upperFormatter{}) })
+	assert.Panics(t, func() { RegisterFormatter("other", nil) })
+
+	_, err = ParseFormatter("nope")
+	assert.EqualError(t, err, `unknown formatter "nope"`)
+	assert.Equal(t, JSONFormatter, FormatterFromName("nope"))
+	got, err = ParseFormatter("Logfmt")
+	assert.NoError(t, err)
+	assert.Equal(t, LogfmtFormatter, got)
+
+	var buf bytes.Buffer
+	Init(f, InfoLevel)
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+	defer Init(JSONFormatter, InfoLevel)
+	Info(context.Background(), "hello")
+	assert.Equal(t, "HELLO\n", buf.String())
+}
+
+func TestFatal(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	SetOutput(io.Discard)
+	defer SetOutput(os.Stderr)
+	sink := &flushSink{}
+	AddSink("flush", sink)
+	defer func() { _ = RemoveSink("flush") }()
+	exited, flushed := -1, 0
+	SetExitFunc(func(code int) {
+		exited, flushed = code, sink.flushed
+	})
+	defer SetExitFunc(nil)
+
+	Fatal(context.Background(), errors.New("Fatal Message 1"), Field("n", 1), RateLimited("fatal", 1))
+	assert.Equal(t, 1, exited)
+	assert.Equal(t, 1, flushed)
+	assert.Len(t, sink.entries, 1)
+	assert.Equal(t, FatalLevel, sink.entries[0].Level)
+	assert.Equal(t, "Fatal Message 1", sink.entries[0].Message)
+	assert.Equal(t, logrus.Fields{"n": 1}, sink.entries[0].Data)
+
+	Fatalf(context.Background(), "Fatal Message %d", 2)
+	assert.Equal(t, "Fatal Message 2", sink.entries[1].Message)
+	assert.Equal(t, 2, flushed)
+}
+
+func TestDisabledLevelAllocs(t *testing.T) {
+	Init(JSONFormatter, InfoLevel, key("requestId"))
+	ctx := context.WithValue(context.Background(), key("requestId"), "request-id")
+	allocs := testing.AllocsPerRun(100, func() {
+		Debug(ctx, "not logged")
+		Debugf(ctx, "not logged")
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkDisabledDebug(b *testing.B) {
+	Init(JSONFormatter, InfoLevel)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Debug(ctx, "not logged")
+	}
+}
+
+func TestFieldsPool(t *testing.T) {
+	fields := getFields()
+	fields["a"] = 1
+	putFields(fields)
+	assert.Empty(t, fields)
+}
+
+func TestNewEntry(t *testing.T) {
+	Init(JSONFormatter, InfoLevel, key("requestId"))
+	ctx := context.WithValue(context.Background(), key("requestId"), "request-id")
+	entry := newEntry(ctx, []Fld{Field("a", 1), Prebind(Field("b", "2"))})
+	assert.Equal(t, logrus.Fields{"requestId": "request-id", "a": 1, "b": "2"}, entry.Data)
+	assert.Equal(t, ctx, entry.Context)
+	assert.Equal(t, logger, entry.Logger)
+}
+
+func BenchmarkInfoFields(b *testing.B) {
+	Init(JSONFormatter, InfoLevel, key("requestId"))
+	SetOutput(io.Discard)
+	defer SetOutput(os.Stderr)
+	ctx := context.WithValue(context.Background(), key("requestId"), "request-id")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Info(ctx, "request handled", Field("status", 200), Field("path", "/"))
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	ctx := context.Background()
+	assert.True(t, Enabled(ctx, InfoLevel))
+	assert.False(t, Enabled(ctx, DebugLevel))
+	assert.False(t, DebugEnabled(ctx))
+	assert.True(t, DebugEnabled(WithDebugBuffer(ctx, 10)))
+}
@@ -0,0 +1,39 @@
+package log
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logstashFormatter writes entries as Logstash v1 events: @timestamp,
+// @version, message and level, with the fields at the top level, those named
+// like these keys under a "fields." prefix.
+type logstashFormatter struct{}
+
+func (f *logstashFormatter) Format(entry *Entry) ([]byte, error) {
+	data := getFields()
+	defer putFields(data)
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	for _, k := range [...]string{"@timestamp", "@version", "message", "level", logrus.FieldKeyFunc, logrus.FieldKeyFile} {
+		if v, ok := data[k]; ok {
+			data["fields."+k] = v
+			delete(data, k)
+		}
+	}
+	data["@timestamp"] = entry.Time.Format(time.RFC3339Nano)
+	data["@version"] = "1"
+	data["message"] = entry.Message
+	data["level"] = entry.Level.String()
+	if entry.HasCaller() {
+		data[logrus.FieldKeyFunc] = entry.Caller.Function
+		data[logrus.FieldKeyFile] = shortPath(entry.Caller.File) + ":" + strconv.Itoa(entry.Caller.Line)
+	}
+	return writeJSONLine(entry, currentConfig(), data)
+}
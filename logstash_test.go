@@ -0,0 +1,28 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogstashFormatter(t *testing.T) {
+	assert.Equal(t, LogstashFormatter, FormatterFromName("logstash"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"user":    "jane",
+		"message": "clash",
+		"err":     errors.New("failed"),
+		"order":   map[string]int{"id": 42},
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entry.Level = InfoLevel
+	entry.Message = "order placed"
+	got, err := new(logstashFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"@timestamp":"2024-05-06T07:08:09Z","@version":"1","err":"failed","fields.message":"clash","level":"info",`+
+		`"message":"order placed","order":{"id":42},"user":"jane"}`+"\n", string(got))
+}
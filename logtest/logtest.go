@@ -0,0 +1,127 @@
+// Package logtest captures the entries logged through the log package so
+// tests can assert on what an application logs.
+//
+//	func TestCharge(t *testing.T) {
+//		logs := logtest.Capture()
+//		defer logs.Close()
+//		charge(ctx, order)
+//		logs.AssertLogged(t, log.WarnLevel, "declined", log.Field("order", order.ID))
+//	}
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/andyday/go-log"
+)
+
+// Entry is a captured entry.
+type Entry struct {
+	Level   log.Level
+	Message string
+	Fields  map[string]interface{}
+	Time    time.Time
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s %q %v", e.Level, e.Message, e.Fields)
+}
+
+// Recorder holds the entries logged since Capture.
+type Recorder struct {
+	name    string
+	mu      sync.Mutex
+	entries []Entry
+}
+
+var captures uint64
+
+// Capture records the entries logged from now on until Close, as a sink does:
+// entries below the level set with log.Init or log.SetLevel are not
+// captured, and entries logged asynchronously only once flushed.
+func Capture() *Recorder {
+	r := &Recorder{name: "logtest-" + strconv.FormatUint(atomic.AddUint64(&captures, 1), 10)}
+	log.AddSink(r.name, sink{r})
+	return r
+}
+
+// Entries returns the entries captured so far, oldest first.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Reset discards the entries captured so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Close stops capturing.
+func (r *Recorder) Close() error {
+	return log.RemoveSink(r.name)
+}
+
+// AssertLogged reports an error to t unless an entry was captured at level
+// with a message containing msg and the given fields, among any others, and
+// returns whether there was one. Field values are compared as logged, so an
+// error is matched by its message.
+func (r *Recorder) AssertLogged(t testing.TB, level log.Level, msg string, flds ...log.Fld) bool {
+	t.Helper()
+	want := log.FieldsToMap(flds...)
+	entries := r.Entries()
+	for _, e := range entries {
+		if e.Level == level && strings.Contains(e.Message, msg) && hasFields(e.Fields, want) {
+			return true
+		}
+	}
+	captured := make([]string, len(entries))
+	for i, e := range entries {
+		captured[i] = "\n\t" + e.String()
+	}
+	t.Errorf("no %s entry containing %q with fields %v was logged; captured:%s", level, msg, want, strings.Join(captured, ""))
+	return false
+}
+
+func hasFields(fields, want map[string]interface{}) bool {
+	for k, w := range want {
+		v, ok := fields[k]
+		if !ok || !reflect.DeepEqual(v, w) && fmt.Sprint(v) != fmt.Sprint(w) {
+			return false
+		}
+	}
+	return true
+}
+
+// sink records entries in a Recorder.
+type sink struct {
+	r *Recorder
+}
+
+func (s sink) Write(entry *log.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.r.entries = append(s.r.entries, Entry{Level: entry.Level, Message: entry.Message, Fields: fields, Time: entry.Time})
+	return nil
+}
+
+func (s sink) Flush() error {
+	return nil
+}
+
+func (s sink) Close() error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package logtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	log "github.com/andyday/go-log"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records the errors reported to it.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCapture(t *testing.T) {
+	ctx := context.Background()
+	log.Init(log.JSONFormatter, log.InfoLevel)
+	log.SetOutput(io.Discard)
+
+	logs := Capture()
+	log.Info(ctx, "order paid", log.Field("order", 7), log.Field("user", "jane"))
+	log.Warn(ctx, "card declined", log.Err(errors.New("insufficient funds")))
+	log.Debug(ctx, "not captured")
+
+	entries := logs.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, log.InfoLevel, entries[0].Level)
+	assert.Equal(t, "order paid", entries[0].Message)
+	assert.Equal(t, map[string]interface{}{"order": 7, "user": "jane"}, entries[0].Fields)
+	assert.False(t, entries[0].Time.IsZero())
+
+	assert.True(t, logs.AssertLogged(t, log.InfoLevel, "paid", log.Field("order", 7)))
+	assert.True(t, logs.AssertLogged(t, log.WarnLevel, "declined", log.Field(log.ErrorKey, errors.New("insufficient funds"))))
+	assert.True(t, logs.AssertLogged(t, log.InfoLevel, ""))
+
+	rt := &recordingT{TB: t}
+	assert.False(t, logs.AssertLogged(rt, log.ErrorLevel, "paid"))
+	assert.False(t, logs.AssertLogged(rt, log.InfoLevel, "paid", log.Field("order", 8)))
+	assert.Len(t, rt.errors, 2)
+	assert.Contains(t, rt.errors[0], `no error entry containing "paid"`)
+	assert.Contains(t, rt.errors[0], `info "order paid" map[order:7 user:jane]`)
+
+	other := Capture()
+	logs.Reset()
+	assert.NoError(t, logs.Close())
+	log.Info(ctx, "after close")
+	assert.Empty(t, logs.Entries())
+	assert.Len(t, other.Entries(), 1)
+	assert.NoError(t, other.Close())
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LokiConfig configures a Grafana Loki sink.
+type LokiConfig struct {
+	// URL is the Loki base URL, e.g. http://loki:3100.
+	URL string
+	// TenantID is sent as X-Scope-OrgID for multi-tenant Loki.
+	TenantID string
+	// Labels are static stream labels added to every entry.
+	Labels map[string]string
+	// LabelFields names entry fields promoted to stream labels, e.g. "service"
+	// or "tenant". The name "level" selects the entry level.
+	LabelFields []string
+	// Formatter renders log lines and defaults to JSON.
+	Formatter logrus.Formatter
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// LokiSink batches entries and sends them to Loki's push API.
+type LokiSink struct {
+	cfg     LokiConfig
+	url     string
+	batcher *batcher
+}
+
+type lokiStream struct {
+	Stream json.RawMessage `json:"stream"`
+	Values [][2]string     `json:"values"`
+}
+
+// NewLokiSink creates a Loki sink.
+func NewLokiSink(cfg LokiConfig) *LokiSink {
+	if cfg.Formatter == nil {
+		cfg.Formatter = new(logrus.JSONFormatter)
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+	s := &LokiSink{cfg: cfg, url: strings.TrimSuffix(cfg.URL, "/") + "/loki/api/v1/push"}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, 0, cfg.Spool, s.send)
+	return s
+}
+
+func (s *LokiSink) Write(entry *Entry) error {
+	line, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{
+		key:  s.labels(entry),
+		time: entry.Time,
+		data: bytes.TrimRight(line, "\n"),
+	})
+	return nil
+}
+
+// labels returns the JSON encoded label set of the entry's stream. Map keys are
+// marshaled in sorted order, so equal label sets encode identically.
+func (s *LokiSink) labels(entry *Entry) string {
+	labels := make(map[string]string, len(s.cfg.Labels)+len(s.cfg.LabelFields))
+	for k, v := range s.cfg.Labels {
+		labels[k] = v
+	}
+	for _, k := range s.cfg.LabelFields {
+		if k == "level" {
+			labels[k] = entry.Level.String()
+		} else if v, ok := entry.Data[k]; ok {
+			labels[k] = stringValue(v)
+		}
+	}
+	return jsonString(labels)
+}
+
+func (s *LokiSink) send(items []batchItem) error {
+	var streams []*lokiStream
+	index := map[string]*lokiStream{}
+	for _, item := range items {
+		stream, ok := index[item.key]
+		if !ok {
+			stream = &lokiStream{Stream: json.RawMessage(item.key)}
+			index[item.key] = stream
+			streams = append(streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(item.time.UnixNano(), 10), string(item.data)})
+	}
+	body, err := json.Marshal(map[string]interface{}{"streams": streams})
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{"Content-Type": {"application/json"}}
+	if s.cfg.TenantID != "" {
+		header.Set("X-Scope-OrgID", s.cfg.TenantID)
+	}
+	return post(s.cfg.Client, s.url, header, body, s.cfg.MaxRetries)
+}
+
+func (s *LokiSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *LokiSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *LokiSink) Status() SinkHealth {
+	return s.batcher.status()
+}
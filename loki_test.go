@@ -0,0 +1,62 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLokiSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		pushed   []lokiStream
+		tenant   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		tenant = r.Header.Get("X-Scope-OrgID")
+		var body struct {
+			Streams []lokiStream `json:"streams"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		pushed = append(pushed, body.Streams...)
+	}))
+	defer srv.Close()
+
+	sink := NewLokiSink(LokiConfig{
+		URL:         srv.URL,
+		TenantID:    "tenant-1",
+		Labels:      map[string]string{"service": "api"},
+		LabelFields: []string{"level", "shard"},
+	})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("loki", sink)
+	ctx := context.Background()
+	Info(ctx, "Informational Message 1", Field("shard", "a"))
+	Info(ctx, "Informational Message 2", Field("shard", "a"))
+	Warn(ctx, "Warning Message 1", Field("shard", "a"))
+	assert.NoError(t, RemoveSink("loki"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, "tenant-1", tenant)
+	assert.Len(t, pushed, 2)
+	assert.JSONEq(t, `{"level":"info","service":"api","shard":"a"}`, string(pushed[0].Stream))
+	assert.Len(t, pushed[0].Values, 2)
+	assert.JSONEq(t, `{"level":"warning","service":"api","shard":"a"}`, string(pushed[1].Stream))
+	assert.Contains(t, pushed[1].Values[0][1], "Warning Message 1")
+}
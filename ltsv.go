@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+// ltsvFormatter writes entries as Labeled Tab-Separated Values:
+// time, level and msg, then the fields, as label:value pairs separated by
+// tabs. Fields named like these labels get a "fields." prefix. Backslashes,
+// tabs and line breaks in values are escaped as \\, \t, \n and \r, and
+// characters labels cannot contain are replaced with underscores.
+type ltsvFormatter struct{}
+
+func (f *ltsvFormatter) Format(entry *Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	writeLTSV(b, "time", entry.Time.Format(time.RFC3339))
+	b.WriteByte('\t')
+	writeLTSV(b, "level", entry.Level.String())
+	b.WriteByte('\t')
+	writeLTSV(b, "msg", entry.Message)
+	if entry.HasCaller() {
+		b.WriteByte('\t')
+		writeLTSV(b, "caller", shortPath(entry.Caller.File)+":"+strconv.Itoa(entry.Caller.Line))
+	}
+	for _, k := range fieldKeys(entry) {
+		v := entry.Data[k]
+		s, ok := v.(string)
+		if !ok {
+			if err, isErr := v.(error); isErr {
+				s = err.Error()
+			} else {
+				s = jsonString(v)
+			}
+		}
+		switch k {
+		case "time", "level", "msg", "caller":
+			k = "fields." + k
+		}
+		b.WriteByte('\t')
+		writeLTSV(b, k, s)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// writeLTSV writes a label:value pair.
+func writeLTSV(b *bytes.Buffer, label, v string) {
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '.' || c == '-' {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	b.WriteByte(':')
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLTSVFormatter(t *testing.T) {
+	assert.Equal(t, LTSVFormatter, FormatterFromName("LTSV"))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"user":      "jane",
+		"trace":     "line 1\n\tat main.go\\x",
+		"status":    200,
+		"msg":       "clash",
+		"user:name": "j",
+	})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entry.Level = WarnLevel
+	entry.Message = "slow\trequest"
+	got, err := new(ltsvFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "time:2024-05-06T07:08:09Z\tlevel:warning\tmsg:slow\\trequest\tfields.msg:clash\tstatus:200\t"+
+		"trace:line 1\\n\\tat main.go\\\\x\tuser:jane\tuser_name:j\n", string(got))
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"reflect"
+	"time"
+)
+
+// LogMarshaler is implemented by types that choose the fields they expose when
+// logged, instead of being encoded whole. Field, the formatting functions and
+// the JSON formatter honor it.
+//
+//	func (u *User) MarshalLog(enc log.FieldEncoder) {
+//		enc.AddString("id", u.ID)
+//		enc.AddInt("logins", int64(u.Logins))
+//	}
+type LogMarshaler interface {
+	MarshalLog(enc FieldEncoder)
+}
+
+// FieldEncoder receives the fields of a LogMarshaler.
+type FieldEncoder interface {
+	AddString(key, value string)
+	AddInt(key string, value int64)
+	AddFloat(key string, value float64)
+	AddBool(key string, value bool)
+	AddTime(key string, value time.Time)
+	AddObject(key string, value LogMarshaler)
+	AddAny(key string, value interface{})
+}
+
+var logMarshalerType = reflect.TypeOf((*LogMarshaler)(nil)).Elem()
+
+// mapEncoder collects the fields of a LogMarshaler.
+type mapEncoder map[string]interface{}
+
+// marshalLog returns the fields m exposes.
+func marshalLog(m LogMarshaler) map[string]interface{} {
+	enc := mapEncoder{}
+	m.MarshalLog(enc)
+	return enc
+}
+
+func (e mapEncoder) AddString(key, value string) {
+	e[key] = value
+}
+
+func (e mapEncoder) AddInt(key string, value int64) {
+	e[key] = value
+}
+
+func (e mapEncoder) AddFloat(key string, value float64) {
+	e[key] = value
+}
+
+func (e mapEncoder) AddBool(key string, value bool) {
+	e[key] = value
+}
+
+func (e mapEncoder) AddTime(key string, value time.Time) {
+	e[key] = value
+}
+
+func (e mapEncoder) AddObject(key string, value LogMarshaler) {
+	if value == nil {
+		e[key] = nil
+		return
+	}
+	e[key] = marshalLog(value)
+}
+
+func (e mapEncoder) AddAny(key string, value interface{}) {
+	if m, ok := value.(LogMarshaler); ok {
+		e.AddObject(key, m)
+		return
+	}
+	e[key] = value
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logUser struct {
+	ID       string
+	Password string
+	Logins   int
+	Manager  *logUser
+}
+
+func (u *logUser) MarshalLog(enc FieldEncoder) {
+	enc.AddString("id", u.ID)
+	enc.AddInt("logins", int64(u.Logins))
+	enc.AddFloat("score", 0.5)
+	enc.AddBool("admin", false)
+	enc.AddTime("seen", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if u.Manager != nil {
+		enc.AddObject("manager", u.Manager)
+	}
+	enc.AddAny("tags", []string{"a"})
+}
+
+type logTeam struct {
+	Lead    logUser `json:"lead"`
+	Members []*logUser
+}
+
+func TestLogMarshaler(t *testing.T) {
+	user := &logUser{ID: "u1", Password: "hunter2", Logins: 3, Manager: &logUser{ID: "u0"}}
+	userJSON := `{"admin":false,"id":"u1","logins":3,"manager":{"admin":false,"id":"u0","logins":0,"score":0.5,"seen":"2024-01-02T03:04:05Z","tags":["a"]},"score":0.5,"seen":"2024-01-02T03:04:05Z","tags":["a"]}`
+
+	f := Field("user", user).(*fld)
+	assert.Equal(t, "u1", f.value.(map[string]interface{})["id"])
+	assert.NotContains(t, f.value, "Password")
+	assert.Equal(t, []interface{}{userJSON}, normalizeArgs([]interface{}{user}))
+	assert.Equal(t, userJSON, jsonString(user))
+
+	// values and pointers within other values
+	team := &logTeam{Lead: logUser{ID: "u2"}, Members: []*logUser{user}}
+	got := jsonString(team)
+	assert.Contains(t, got, `"lead":{"admin":false,"id":"u2"`)
+	assert.Contains(t, got, `"Members":[`+userJSON+`]`)
+	assert.NotContains(t, got, "hunter2")
+}
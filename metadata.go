@@ -0,0 +1,132 @@
+package log
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The fields WithInstanceMetadata adds.
+const (
+	InstanceIDKey   = "instance_id"
+	ZoneKey         = "zone"
+	InstanceTypeKey = "instance_type"
+)
+
+// Default endpoints of the instance metadata services.
+const (
+	DefaultEC2MetadataURL = "http://169.254.169.254"
+	DefaultGCEMetadataURL = "http://metadata.google.internal"
+)
+
+// DefaultMetadataTimeout bounds the instance metadata probe.
+const DefaultMetadataTimeout = time.Second
+
+// InstanceMetadataConfig configures the probe of WithInstanceMetadata.
+type InstanceMetadataConfig struct {
+	// Timeout defaults to DefaultMetadataTimeout.
+	Timeout time.Duration
+	// EC2URL defaults to DefaultEC2MetadataURL.
+	EC2URL string
+	// GCEURL defaults to DefaultGCEMetadataURL.
+	GCEURL string
+	// Client defaults to an http.Client that does not use a proxy.
+	Client *http.Client
+}
+
+// WithInstanceMetadata adds the instance ID, zone and instance type of the
+// EC2 or Compute Engine instance the program runs on to every entry. The
+// metadata services are probed once, concurrently, when WithInstanceMetadata
+// is called, so startup waits up to the timeout off the cloud; no fields are
+// added if neither answers. EC2 is queried with IMDSv2. Fields of a call or
+// its context take precedence.
+func WithInstanceMetadata(cfg InstanceMetadataConfig) Option {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultMetadataTimeout
+	}
+	if cfg.EC2URL == "" {
+		cfg.EC2URL = DefaultEC2MetadataURL
+	}
+	if cfg.GCEURL == "" {
+		cfg.GCEURL = DefaultGCEMetadataURL
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Transport: &http.Transport{}}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	ec2, gce := make(chan map[string]interface{}, 1), make(chan map[string]interface{}, 1)
+	go func() { ec2 <- probeEC2(ctx, cfg) }()
+	go func() { gce <- probeGCE(ctx, cfg) }()
+	fields := <-ec2
+	if g := <-gce; fields == nil {
+		fields = g
+	}
+	return func(c *config) {
+		if fields != nil {
+			c.setStatic(fields)
+		}
+	}
+}
+
+// probeEC2 returns the fields from the EC2 instance metadata service, or nil.
+func probeEC2(ctx context.Context, cfg InstanceMetadataConfig) map[string]interface{} {
+	token, err := metadataGet(ctx, cfg.Client, http.MethodPut, cfg.EC2URL+"/latest/api/token",
+		http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {"60"}})
+	if err != nil {
+		return nil
+	}
+	header := http.Header{"X-Aws-Ec2-Metadata-Token": {token}}
+	return metadataFields(ctx, cfg.Client, cfg.EC2URL+"/latest/meta-data/", header,
+		"instance-id", "placement/availability-zone", "instance-type")
+}
+
+// probeGCE returns the fields from the Compute Engine metadata server, or nil.
+func probeGCE(ctx context.Context, cfg InstanceMetadataConfig) map[string]interface{} {
+	fields := metadataFields(ctx, cfg.Client, cfg.GCEURL+"/computeMetadata/v1/instance/",
+		http.Header{"Metadata-Flavor": {"Google"}}, "id", "zone", "machine-type")
+	if fields == nil {
+		return nil
+	}
+	// The zone and machine type are resource names such as
+	// projects/123/zones/us-central1-a.
+	for _, k := range []string{ZoneKey, InstanceTypeKey} {
+		s := fields[k].(string)
+		fields[k] = s[strings.LastIndexByte(s, '/')+1:]
+	}
+	return fields
+}
+
+// metadataFields reads the instance ID, zone and instance type from the given
+// paths under base, returning nil if any fails.
+func metadataFields(ctx context.Context, client *http.Client, base string, header http.Header, id, zone, typ string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for k, path := range map[string]string{InstanceIDKey: id, ZoneKey: zone, InstanceTypeKey: typ} {
+		v, err := metadataGet(ctx, client, http.MethodGet, base+path, header)
+		if err != nil {
+			return nil
+		}
+		fields[k] = v
+	}
+	return fields
+}
+
+func metadataGet(ctx context.Context, client *http.Client, method, url string, header http.Header) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header = header
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &httpStatusError{code: resp.StatusCode}
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return strings.TrimSpace(string(b)), err
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithInstanceMetadata(t *testing.T) {
+	ec2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			assert.Equal(t, http.MethodPut, r.Method)
+			_, _ = w.Write([]byte("tok"))
+			return
+		}
+		if r.Header.Get("X-Aws-Ec2-Metadata-Token") != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(map[string]string{
+			"/latest/meta-data/instance-id":                 "i-0abc",
+			"/latest/meta-data/placement/availability-zone": "eu-west-1a",
+			"/latest/meta-data/instance-type":               "t3.micro",
+		}[r.URL.Path]))
+	}))
+	defer ec2.Close()
+	gce := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(map[string]string{
+			"/computeMetadata/v1/instance/id":           "4567",
+			"/computeMetadata/v1/instance/zone":         "projects/123/zones/us-central1-a",
+			"/computeMetadata/v1/instance/machine-type": "projects/123/machineTypes/e2-medium\n",
+		}[r.URL.Path]))
+	}))
+	defer gce.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	defer Configure(func(c *config) { c.static = nil })
+
+	Configure(WithInstanceMetadata(InstanceMetadataConfig{EC2URL: ec2.URL, GCEURL: closed.URL}))
+	assert.Equal(t, map[string]interface{}{
+		InstanceIDKey: "i-0abc", ZoneKey: "eu-west-1a", InstanceTypeKey: "t3.micro",
+	}, currentConfig().static)
+
+	Configure(func(c *config) { c.static = nil })
+	Configure(WithInstanceMetadata(InstanceMetadataConfig{EC2URL: closed.URL, GCEURL: gce.URL}))
+	assert.Equal(t, map[string]interface{}{
+		InstanceIDKey: "4567", ZoneKey: "us-central1-a", InstanceTypeKey: "e2-medium",
+	}, currentConfig().static)
+
+	Configure(func(c *config) { c.static = nil })
+	Configure(WithInstanceMetadata(InstanceMetadataConfig{EC2URL: closed.URL, GCEURL: closed.URL}))
+	assert.Empty(t, currentConfig().static)
+}
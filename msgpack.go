@@ -0,0 +1,481 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// msgpackFormatter writes each entry as a MessagePack map of time, level,
+// msg, caller and the fields, those named like these keys under a "fields."
+// prefix. The time uses the MessagePack timestamp extension. Entries are not
+// separated; read them back with MsgpackDecoder.
+type msgpackFormatter struct{}
+
+func (f *msgpackFormatter) Format(entry *Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	scratch := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(scratch)
+	n := len(entry.Data) + 3
+	if entry.HasCaller() {
+		n++
+	}
+	out := appendMsgpackMapHeader((*scratch)[:0], n)
+	out = appendMsgpackString(out, logrus.FieldKeyTime)
+	out = appendMsgpackTimestamp(out, entry.Time)
+	out = appendMsgpackString(out, logrus.FieldKeyLevel)
+	out = appendMsgpackString(out, entry.Level.String())
+	out = appendMsgpackString(out, logrus.FieldKeyMsg)
+	out = appendMsgpackString(out, entry.Message)
+	if entry.HasCaller() {
+		out = appendMsgpackString(out, "caller")
+		out = appendMsgpackString(out, callerLocation(entry))
+	}
+	for _, k := range fieldKeys(entry) {
+		v := entry.Data[k]
+		switch k {
+		case logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg, "caller":
+			k = "fields." + k
+		}
+		out = appendMsgpackString(out, k)
+		out = appendMsgpack(out, v)
+	}
+	*scratch = out
+	b.Write(out)
+	return b.Bytes(), nil
+}
+
+// appendMsgpack appends the MessagePack encoding of v to b. Types without a
+// direct MessagePack representation are encoded as strings.
+func appendMsgpack(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return appendMsgpackInt(b, int64(v))
+	case int8:
+		return appendMsgpackInt(b, int64(v))
+	case int16:
+		return appendMsgpackInt(b, int64(v))
+	case int32:
+		return appendMsgpackInt(b, int64(v))
+	case int64:
+		return appendMsgpackInt(b, v)
+	case uint:
+		return appendMsgpackUint(b, uint64(v))
+	case uint8:
+		return appendMsgpackUint(b, uint64(v))
+	case uint16:
+		return appendMsgpackUint(b, uint64(v))
+	case uint32:
+		return appendMsgpackUint(b, uint64(v))
+	case uint64:
+		return appendMsgpackUint(b, v)
+	case float32:
+		b = append(b, 0xca)
+		return appendUint32(b, math.Float32bits(v))
+	case float64:
+		b = append(b, 0xcb)
+		return appendUint64(b, math.Float64bits(v))
+	case string:
+		return appendMsgpackString(b, v)
+	case []byte:
+		return appendMsgpackBin(b, v)
+	case time.Time:
+		return appendMsgpackEventTime(b, v)
+	case []interface{}:
+		b = appendMsgpackArrayHeader(b, len(v))
+		for _, e := range v {
+			b = appendMsgpack(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		return appendMsgpackMap(b, v)
+	case logrus.Fields:
+		return appendMsgpackMap(b, v)
+	case namespace:
+		return appendMsgpackMap(b, v)
+	case map[string]string:
+		b = appendMsgpackMapHeader(b, len(v))
+		for k, e := range v {
+			b = appendMsgpackString(b, k)
+			b = appendMsgpackString(b, e)
+		}
+		return b
+	case error:
+		return appendMsgpackString(b, v.Error())
+	default:
+		return appendMsgpackString(b, stringValue(v))
+	}
+}
+
+func appendMsgpackMap(b []byte, m map[string]interface{}) []byte {
+	b = appendMsgpackMapHeader(b, len(m))
+	for k, v := range m {
+		b = appendMsgpackString(b, k)
+		b = appendMsgpack(b, v)
+	}
+	return b
+}
+
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendMsgpackUint(b, uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return appendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return appendUint32(append(b, 0xd2), uint32(i))
+	default:
+		return appendUint64(append(b, 0xd3), uint64(i))
+	}
+}
+
+func appendMsgpackUint(b []byte, u uint64) []byte {
+	switch {
+	case u <= 0x7f:
+		return append(b, byte(u))
+	case u <= math.MaxUint8:
+		return append(b, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return appendUint16(append(b, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return appendUint32(append(b, 0xce), uint32(u))
+	default:
+		return appendUint64(append(b, 0xcf), u)
+	}
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = appendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = appendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackBin(b []byte, data []byte) []byte {
+	switch n := len(data); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = appendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = appendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, data...)
+}
+
+func appendMsgpackArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return appendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(b, 0xde), uint16(n))
+	default:
+		return appendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+// appendMsgpackEventTime encodes t as the Fluentd EventTime extension: type 0
+// with big endian seconds and nanoseconds.
+func appendMsgpackEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = appendUint32(b, uint32(t.Unix()))
+	return appendUint32(b, uint32(t.Nanosecond()))
+}
+
+// appendMsgpackTimestamp encodes t as the timestamp extension, type -1, in
+// its 96-bit form.
+func appendMsgpackTimestamp(b []byte, t time.Time) []byte {
+	b = append(b, 0xc7, 12, 0xff)
+	b = appendUint32(b, uint32(t.Nanosecond()))
+	return appendUint64(b, uint64(t.Unix()))
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
+
+// maxMsgpackDepth is how deeply arrays and maps may be nested in decoded
+// values.
+const maxMsgpackDepth = 1000
+
+// ErrMsgpack is returned for input that is not valid MessagePack.
+var ErrMsgpack = errors.New("invalid msgpack")
+
+// MsgpackDecoder reads the entries written by the msgpack formatter, or any
+// sequence of MessagePack values.
+type MsgpackDecoder struct {
+	r *bufio.Reader
+}
+
+// NewMsgpackDecoder returns a decoder reading from r.
+func NewMsgpackDecoder(r io.Reader) *MsgpackDecoder {
+	return &MsgpackDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next entry, returning io.EOF when there are no more. Maps
+// decode as map[string]interface{}, with other keys formatted as strings,
+// arrays as []interface{}, integers as int64 or uint64 if too large, and the
+// timestamp and Fluentd EventTime extensions as time.Time; other extensions
+// decode as their data.
+func (d *MsgpackDecoder) Decode() (map[string]interface{}, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: entry is a %T, not a map", ErrMsgpack, v)
+	}
+	return m, nil
+}
+
+// DecodeValue reads the next value, returning io.EOF when there are no more.
+func (d *MsgpackDecoder) DecodeValue() (interface{}, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	return d.value(0)
+}
+
+func (d *MsgpackDecoder) value(depth int) (interface{}, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.mapOf(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.arrayOf(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(c - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return d.bytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(c - 0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (c - 0xcc))
+		if u > math.MaxInt64 {
+			return u, err
+		}
+		return int64(u), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := d.uint(size)
+		shift := uint(64 - 8*size)
+		return int64(u<<shift) >> shift, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(c - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(c - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(c - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+	return nil, fmt.Errorf("%w: unknown type 0x%02x", ErrMsgpack, c)
+}
+
+// length reads a length of 1, 2 or 4 bytes for size 0, 1 or 2.
+func (d *MsgpackDecoder) length(size byte) (int, error) {
+	u, err := d.uint(1 << size)
+	if u > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: length %d", ErrMsgpack, u)
+	}
+	return int(u), err
+}
+
+func (d *MsgpackDecoder) uint(size int) (uint64, error) {
+	var u uint64
+	for i := 0; i < size; i++ {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return 0, unexpected(err)
+		}
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// bytes reads n bytes, growing the buffer as data arrives rather than
+// trusting n.
+func (d *MsgpackDecoder) bytes(n int) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(n)); err != nil {
+		return nil, unexpected(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *MsgpackDecoder) str(n int) (string, error) {
+	b, err := d.bytes(n)
+	return string(b), err
+}
+
+func (d *MsgpackDecoder) ext(n int) (interface{}, error) {
+	typ, err := d.r.ReadByte()
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	data, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case typ == 0 && n == 8: // Fluentd EventTime
+		return time.Unix(int64(readUint32(data[:4])), int64(readUint32(data[4:]))), nil
+	case typ == 0xff && n == 4:
+		return time.Unix(int64(readUint32(data)), 0), nil
+	case typ == 0xff && n == 8:
+		u := uint64(readUint32(data[:4]))<<32 | uint64(readUint32(data[4:]))
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)), nil
+	case typ == 0xff && n == 12:
+		sec := uint64(readUint32(data[4:8]))<<32 | uint64(readUint32(data[8:]))
+		return time.Unix(int64(sec), int64(readUint32(data[:4]))), nil
+	}
+	return data, nil
+}
+
+func (d *MsgpackDecoder) arrayOf(n, depth int) ([]interface{}, error) {
+	if depth >= maxMsgpackDepth {
+		return nil, fmt.Errorf("%w: nested too deeply", ErrMsgpack)
+	}
+	a := make([]interface{}, 0, minInt(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (d *MsgpackDecoder) mapOf(n, depth int) (map[string]interface{}, error) {
+	if depth >= maxMsgpackDepth {
+		return nil, fmt.Errorf("%w: nested too deeply", ErrMsgpack)
+	}
+	m := make(map[string]interface{}, minInt(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := k.(string); ok {
+			m[s] = v
+		} else {
+			m[fmt.Sprint(k)] = v
+		}
+	}
+	return m, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func readUint32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// unexpected turns the end of input inside a value into io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
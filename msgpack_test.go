@@ -0,0 +1,138 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendMsgpack(t *testing.T) {
+	cases := []struct {
+		v        interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{1, []byte{0x01}},
+		{-1, []byte{0xff}},
+		{-100, []byte{0xd0, 0x9c}},
+		{200, []byte{0xcc, 0xc8}},
+		{70000, []byte{0xce, 0x00, 0x01, 0x11, 0x70}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{[]interface{}{1, "a"}, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{map[string]interface{}{"a": 1}, []byte{0x81, 0xa1, 'a', 0x01}},
+		{time.Unix(1, 2), []byte{0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2}},
+		{testStruct0, append([]byte{0xb5}, testStruct0Result...)},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, appendMsgpack(nil, c.v), "%v", c.v)
+	}
+}
+
+func TestMsgpackFormatter(t *testing.T) {
+	assert.Equal(t, MsgpackFormatter, FormatterFromName("MsgPack"))
+
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	var out bytes.Buffer
+	for _, msg := range []string{"first", "second"} {
+		entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+			"user":   "jane",
+			"status": 200,
+			"msg":    "clash",
+			"err":    errors.New("failed"),
+			"tags":   []interface{}{"a", int64(-5)},
+		})
+		entry.Time = ts
+		entry.Level = WarnLevel
+		entry.Message = msg
+		got, err := new(msgpackFormatter).Format(entry)
+		assert.NoError(t, err)
+		out.Write(got)
+	}
+
+	d := NewMsgpackDecoder(&out)
+	for _, msg := range []string{"first", "second"} {
+		m, err := d.Decode()
+		assert.NoError(t, err)
+		assert.True(t, ts.Equal(m["time"].(time.Time)))
+		delete(m, "time")
+		assert.Equal(t, map[string]interface{}{
+			"level":      "warning",
+			"msg":        msg,
+			"fields.msg": "clash",
+			"user":       "jane",
+			"status":     int64(200),
+			"err":        "failed",
+			"tags":       []interface{}{"a", int64(-5)},
+		}, m)
+	}
+	_, err := d.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMsgpackDecoder(t *testing.T) {
+	values := []interface{}{
+		nil, true, false, int64(0), int64(127), int64(-32), int64(-100), int64(200), int64(70000),
+		int64(math.MinInt64), int64(math.MaxInt64), uint64(math.MaxUint64), 1.5, "",
+		string(make([]byte, 40)), string(make([]byte, 300)), string(make([]byte, 70000)),
+		[]byte{1, 2}, make([]byte, 300), []interface{}{int64(1), "a", nil},
+		make([]interface{}, 20), map[string]interface{}{"a": int64(1), "b": map[string]interface{}{}},
+	}
+	var b []byte
+	for _, v := range values {
+		b = appendMsgpack(b, v)
+	}
+	d := NewMsgpackDecoder(bytes.NewReader(b))
+	for _, v := range values {
+		got, err := d.DecodeValue()
+		assert.NoError(t, err)
+		if s, ok := v.([]interface{}); ok && len(s) == 20 {
+			assert.Len(t, got, 20)
+			continue
+		}
+		assert.Equal(t, v, got)
+	}
+	_, err := d.DecodeValue()
+	assert.Equal(t, io.EOF, err)
+
+	for _, c := range []struct {
+		b    []byte
+		want interface{}
+	}{
+		{[]byte{0xca, 0x3f, 0xc0, 0, 0}, 1.5},
+		{[]byte{0xd1, 0xff, 0x9c}, int64(-100)},
+		{[]byte{0x81, 0x01, 0xa1, 'a'}, map[string]interface{}{"1": "a"}},
+		{[]byte{0xd6, 0xff, 0, 0, 0, 1}, time.Unix(1, 0)},
+		{[]byte{0xd7, 0xff, 0, 0, 0, 8, 0, 0, 0, 1}, time.Unix(1, 2)},
+		{appendMsgpackTimestamp(nil, time.Unix(-1, 5)), time.Unix(-1, 5)},
+		{[]byte{0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2}, time.Unix(1, 2)},
+		{[]byte{0xd4, 0x05, 0x07}, []byte{0x07}},
+	} {
+		got, err := NewMsgpackDecoder(bytes.NewReader(c.b)).DecodeValue()
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, got, "% x", c.b)
+	}
+
+	for _, b := range [][]byte{{0xa3, 'a'}, {0x92, 0x01}, {0xdb, 0x7f, 0xff, 0xff, 0xff}, {0xcd, 0x01}} {
+		_, err := NewMsgpackDecoder(bytes.NewReader(b)).DecodeValue()
+		assert.Equal(t, io.ErrUnexpectedEOF, err, "% x", b)
+	}
+	_, err = NewMsgpackDecoder(bytes.NewReader([]byte{0xc1})).DecodeValue()
+	assert.True(t, errors.Is(err, ErrMsgpack))
+	_, err = NewMsgpackDecoder(bytes.NewReader([]byte{0x01})).Decode()
+	assert.True(t, errors.Is(err, ErrMsgpack))
+	_, err = NewMsgpackDecoder(bytes.NewReader(bytes.Repeat([]byte{0x91}, 2000))).DecodeValue()
+	assert.True(t, errors.Is(err, ErrMsgpack))
+	_, err = NewMsgpackDecoder(bytes.NewReader([]byte{0xdf, 0x7f, 0xff, 0xff, 0xff})).DecodeValue()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = NewMsgpackDecoder(bytes.NewReader([]byte{0xc6, 0xff, 0xff, 0xff, 0xff})).DecodeValue()
+	assert.True(t, errors.Is(err, ErrMsgpack))
+}
@@ -0,0 +1,104 @@
+package log
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// namespace is the value of a Namespace field.
+type namespace map[string]interface{}
+
+// Namespace groups flds under key: JSON formatters and sinks write them as a
+// nested object, {"db":{"query":...,"took":...}}, and the simple and text
+// formatters as dotted keys, db.query=... db.took=.... Namespaces can be
+// nested.
+//
+//	log.Info(ctx, "query done", log.Namespace("db", log.String("query", q), log.Duration("took", d)))
+func Namespace(key string, flds ...Fld) Fld {
+	fields := make(map[string]interface{}, len(flds))
+	for _, f := range flds {
+		f.apply(fields)
+	}
+	delete(fields, rateLimitField)
+	return &fld{key: key, value: namespace(fields)}
+}
+
+// String is a field with a string value.
+func String(key, value string) Fld {
+	return &fld{key: key, value: value}
+}
+
+// Int is a field with an int value.
+func Int(key string, value int) Fld {
+	return &fld{key: key, value: value}
+}
+
+// Bool is a field with a bool value.
+func Bool(key string, value bool) Fld {
+	return &fld{key: key, value: value}
+}
+
+// Duration is a field with a duration value, written as set with
+// WithDurationFormat.
+func Duration(key string, value time.Duration) Fld {
+	return &fld{key: key, value: value}
+}
+
+// writeSimpleNamespace writes the fields of ns as simple fields with keys
+// under prefix, sorted, with durations and times formatted like those of the
+// entry.
+func writeSimpleNamespace(b *bytes.Buffer, cfg *config, color bool, prefix string, ns namespace) {
+	keys := make([]string, 0, len(ns))
+	for k := range ns {
+		keys = append(keys, k)
+	}
+	sortStrings(keys)
+	for _, k := range keys {
+		switch v := ns[k].(type) {
+		case namespace:
+			writeSimpleNamespace(b, cfg, color, prefix+k+".", v)
+		case string:
+			writeSimpleField(b, color, prefix+k, v)
+		case time.Duration:
+			writeSimpleField(b, color, prefix+k, stringValue(formatDuration(v, cfg.durationFormat)))
+		case time.Time:
+			if cfg.timeFormat != "" {
+				writeSimpleField(b, color, prefix+k, stringValue(formatTime(v, cfg.timeFormat)))
+			} else {
+				writeSimpleField(b, color, prefix+k, jsonString(v))
+			}
+		default:
+			writeSimpleField(b, color, prefix+k, jsonString(v))
+		}
+	}
+}
+
+// flattenNamespaces returns a copy of data with the fields of namespaces under
+// dotted keys, if it has any.
+func flattenNamespaces(data logrus.Fields) (logrus.Fields, bool) {
+	nested := false
+	for _, v := range data {
+		if _, ok := v.(namespace); ok {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return nil, false
+	}
+	flat := make(logrus.Fields, len(data))
+	addFlattened(flat, "", data)
+	return flat, true
+}
+
+func addFlattened(flat logrus.Fields, prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		if ns, ok := v.(namespace); ok {
+			addFlattened(flat, prefix+k+".", ns)
+			continue
+		}
+		flat[prefix+k] = v
+	}
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	Init(JSONFormatter, InfoLevel)
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+	defer Init(JSONFormatter, InfoLevel)
+	Configure(WithDurationFormat(DurationString), WithRedactedKeys("password"))
+	defer Configure(WithDurationFormat(DurationNanos), func(c *config) { c.redactedKeys = nil })
+
+	db := Namespace("db",
+		String("query", "select 1"),
+		Duration("took", 1500*time.Millisecond),
+		Namespace("pool", Int("open", 3), Bool("full", false), String("password", "hunter2")),
+	)
+	Info(ctx, "query done", db, Field("id", 1))
+	assert.Regexp(t, `^\{"db":\{"pool":\{"full":false,"open":3,"password":"\[REDACTED\]"\},"query":"select 1","took":"1\.5s"\},`+
+		`"id":1,"level":"info","msg":"query done","time":"[^"]+"\}\n$`, buf.String())
+
+	buf.Reset()
+	Init(SimpleFormatter, InfoLevel)
+	Info(ctx, "query done", db, Field("id", 1))
+	assert.Equal(t, "query done | db.pool.full=false | db.pool.open=3 | db.pool.password=[REDACTED] | db.query=select 1 | db.took=1.5s | id=1\n", buf.String())
+
+	buf.Reset()
+	Init(TextFormatter, InfoLevel)
+	Info(ctx, "query done", db, Field("id", 1))
+	assert.Contains(t, buf.String(), `msg="query done" db.pool.full=false db.pool.open=3 db.pool.password="[REDACTED]" db.query="select 1" db.took=1.5s id=1`+"\n")
+}
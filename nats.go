@@ -0,0 +1,74 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NATSPublisher publishes a message on a subject. *nats.Conn satisfies it
+// directly; a JetStream context needs a one-line adapter that discards the ack.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSConfig configures a NATS sink.
+type NATSConfig struct {
+	// Publisher sends the messages.
+	Publisher NATSPublisher
+	// Subject may contain {name} placeholders that are replaced by the entry
+	// field of that name, or by the entry level for {level}, e.g.
+	// "logs.{service}.{level}". Missing fields are replaced by "_".
+	Subject string
+	// Formatter renders messages and defaults to JSON.
+	Formatter logrus.Formatter
+}
+
+// NATSSink publishes entries to a NATS subject.
+type NATSSink struct {
+	cfg NATSConfig
+}
+
+// NewNATSSink creates a NATS sink.
+func NewNATSSink(cfg NATSConfig) *NATSSink {
+	if cfg.Formatter == nil {
+		cfg.Formatter = new(logrus.JSONFormatter)
+	}
+	return &NATSSink{cfg: cfg}
+}
+
+func (s *NATSSink) Write(entry *Entry) error {
+	data, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	return s.cfg.Publisher.Publish(natsSubject(s.cfg.Subject, entry), bytes.TrimRight(data, "\n"))
+}
+
+func natsSubject(template string, entry *Entry) string {
+	return expandTemplate(template, func(name string) string {
+		v, ok := templateValue(entry, name)
+		if !ok {
+			return "_"
+		}
+		// subjects are dot separated tokens without whitespace or wildcards
+		return strings.Map(func(r rune) rune {
+			if r == '.' || r == ' ' || r == '\t' || r == '*' || r == '>' {
+				return '_'
+			}
+			return r
+		}, v)
+	})
+}
+
+func (s *NATSSink) Flush() error {
+	if f, ok := s.cfg.Publisher.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (s *NATSSink) Close() error {
+	return s.Flush()
+}
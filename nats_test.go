@@ -0,0 +1,34 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPublisher struct {
+	subjects []string
+	data     [][]byte
+}
+
+func (p *testPublisher) Publish(subject string, data []byte) error {
+	p.subjects = append(p.subjects, subject)
+	p.data = append(p.data, data)
+	return nil
+}
+
+func TestNATSSink(t *testing.T) {
+	publisher := &testPublisher{}
+	sink := NewNATSSink(NATSConfig{Publisher: publisher, Subject: "logs.{service}.{level}"})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("nats", sink)
+	ctx := context.Background()
+	Info(ctx, "Informational Message 1", Field("service", "api.v1"))
+	Error(ctx, "Error Message 1")
+	assert.NoError(t, RemoveSink("nats"))
+
+	assert.Equal(t, []string{"logs.api_v1.info", "logs._.error"}, publisher.subjects)
+	assert.Contains(t, string(publisher.data[0]), "Informational Message 1")
+}
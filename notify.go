@@ -0,0 +1,155 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotifyService selects the chat webhook format of a NotifySink.
+type NotifyService int
+
+const (
+	Slack NotifyService = iota
+	Teams
+)
+
+// DefaultNotifyInterval is the default minimum time between two notifications.
+const DefaultNotifyInterval = time.Minute
+
+// NotifyConfig configures a chat notification sink.
+type NotifyConfig struct {
+	// Service is Slack (default) or Teams.
+	Service NotifyService
+	// URL is the incoming webhook URL of the channel.
+	URL string
+	// Level is the least severe level posted. The zero value selects FatalLevel;
+	// Panic entries are always posted.
+	Level Level
+	// Interval is the minimum time between two messages to the channel.
+	// Entries within it are counted and reported with the next message.
+	// Defaults to DefaultNotifyInterval.
+	Interval time.Duration
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// NotifySink posts a message to a Slack or Microsoft Teams channel when severe
+// entries occur, rate limited per channel. Fatal and Panic entries are posted
+// before they are written, so the process cannot exit first; others are posted
+// in the background.
+type NotifySink struct {
+	cfg        NotifyConfig
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+	wg         sync.WaitGroup
+}
+
+// NewNotifySink creates a chat notification sink.
+func NewNotifySink(cfg NotifyConfig) *NotifySink {
+	if cfg.Level == PanicLevel {
+		cfg.Level = FatalLevel
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultNotifyInterval
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+	return &NotifySink{cfg: cfg}
+}
+
+func (s *NotifySink) Write(entry *Entry) error {
+	if entry.Level > s.cfg.Level {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.last.IsZero() && entry.Time.Sub(s.last) < s.cfg.Interval {
+		s.suppressed++
+		s.mu.Unlock()
+		return nil
+	}
+	suppressed := s.suppressed
+	s.last, s.suppressed = entry.Time, 0
+	s.mu.Unlock()
+
+	body, err := s.message(entry, suppressed)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if entry.Level <= FatalLevel {
+		return post(s.cfg.Client, s.cfg.URL, header, body, s.cfg.MaxRetries)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := post(s.cfg.Client, s.cfg.URL, header, body, s.cfg.MaxRetries); err != nil {
+			reportError(fmt.Errorf("notify: %w", err))
+		}
+	}()
+	return nil
+}
+
+func (s *NotifySink) message(entry *Entry, suppressed int) ([]byte, error) {
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	title := strings.ToUpper(entry.Level.String()) + ": " + entry.Message
+	note := ""
+	if suppressed > 0 {
+		note = fmt.Sprintf("%d more entries were suppressed since the last notification", suppressed)
+	}
+
+	if s.cfg.Service == Teams {
+		facts := make([]map[string]string, 0, len(keys))
+		for _, k := range keys {
+			facts = append(facts, map[string]string{"name": k, "value": stringValue(entry.Data[k])})
+		}
+		return json.Marshal(map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "http://schema.org/extensions",
+			"summary":    title,
+			"title":      title,
+			"themeColor": "D70000",
+			"text":       note,
+			"sections":   []interface{}{map[string]interface{}{"facts": facts}},
+		})
+	}
+
+	text := strings.Builder{}
+	text.WriteString("*")
+	text.WriteString(title)
+	text.WriteString("*")
+	for _, k := range keys {
+		fmt.Fprintf(&text, "\n• %s: `%s`", k, stringValue(entry.Data[k]))
+	}
+	if note != "" {
+		text.WriteString("\n_")
+		text.WriteString(note)
+		text.WriteString("_")
+	}
+	return json.Marshal(map[string]string{"text": text.String()})
+}
+
+// Flush waits for notifications posted in the background.
+func (s *NotifySink) Flush() error {
+	s.wg.Wait()
+	return nil
+}
+
+func (s *NotifySink) Close() error {
+	return s.Flush()
+}
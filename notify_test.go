@@ -0,0 +1,49 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifySink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		messages []map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var msg map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		messages = append(messages, msg)
+	}))
+	defer srv.Close()
+
+	slack := NewNotifySink(NotifyConfig{URL: srv.URL, Level: ErrorLevel})
+	teams := NewNotifySink(NotifyConfig{Service: Teams, URL: srv.URL, Level: ErrorLevel})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("slack", slack)
+	ctx := context.Background()
+	Warn(ctx, "Warning Message 1")
+	Error(ctx, "Error Message 1", Field("field1", "value1"))
+	Error(ctx, "Error Message 2")
+	assert.NoError(t, RemoveSink("slack"))
+
+	AddSink("teams", teams)
+	Error(ctx, "Error Message 3", Field("field1", "value1"))
+	assert.NoError(t, RemoveSink("teams"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "*ERROR: Error Message 1*\n• field1: `value1`", messages[0]["text"])
+	assert.Equal(t, "MessageCard", messages[1]["@type"])
+	assert.Equal(t, "ERROR: Error Message 3", messages[1]["title"])
+}
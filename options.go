@@ -0,0 +1,109 @@
+package log
+
+import (
+	"context"
+	"crypto/cipher"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Option changes how entries are processed; see Configure.
+type Option func(*config)
+
+// config holds the settings made with Configure. It is replaced, never
+// modified, once in use.
+type config struct {
+	samplers  map[Level]*sampler
+	fractions map[Level]float64
+	dedup     *deduper
+	adaptive  *adaptiveSampler
+
+	redactedKeys map[string]bool
+	scrubbers    []textScrubber
+	hashedKeys   map[string]bool
+	hashSalt     string
+	aead         cipher.AEAD
+	allowedKeys  map[string]bool
+	limits       *SizeLimits
+
+	jsonMaxDepth    int
+	jsonMaxElements int
+	jsonFields      JSONFields
+
+	durationFormat DurationFormat
+	timeFormat     string
+	location       *time.Location
+
+	gcpProject string
+	gcpTrace   func(ctx context.Context) (traceID, spanID string)
+
+	keyCase      KeyCase
+	collisions   CollisionPolicy
+	fieldOrder   FieldOrder
+	color        ColorMode
+	simpleLayout SimpleLayout
+	syslogLayout SyslogLayout
+	caller       bool
+
+	stackLevel  Level
+	stackFrames int
+
+	static      map[string]interface{}
+	goroutineID bool
+	sequence    bool
+	lambda      bool
+	dynamic     []*dynamicField
+	propagated  []string
+}
+
+var (
+	configMu  sync.Mutex
+	configVal atomic.Value // *config
+)
+
+func init() {
+	configVal.Store(&config{})
+}
+
+// Configure applies options on top of the current configuration.
+func Configure(opts ...Option) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	c := currentConfig().clone()
+	for _, opt := range opts {
+		opt(c)
+	}
+	configVal.Store(c)
+	logger.SetReportCaller(c.caller)
+}
+
+// setStatic adds fields to every entry, copying the map so that entries
+// built from the previous configuration are unaffected.
+func (c *config) setStatic(fields map[string]interface{}) {
+	static := make(map[string]interface{}, len(c.static)+len(fields))
+	for k, v := range c.static {
+		static[k] = v
+	}
+	for k, v := range fields {
+		static[k] = v
+	}
+	c.static = static
+}
+
+func currentConfig() *config {
+	return configVal.Load().(*config)
+}
+
+func (c *config) clone() *config {
+	n := *c
+	n.samplers = make(map[Level]*sampler, len(c.samplers))
+	for level, s := range c.samplers {
+		n.samplers[level] = s
+	}
+	n.fractions = make(map[Level]float64, len(c.fractions))
+	for level, f := range c.fractions {
+		n.fractions[level] = f
+	}
+	return &n
+}
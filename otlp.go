@@ -0,0 +1,180 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultOTLPURL is the OTLP/HTTP endpoint of a local OpenTelemetry Collector.
+const DefaultOTLPURL = "http://localhost:4318"
+
+// OTLP severity numbers, see the OpenTelemetry logs data model.
+var otlpSeverity = map[Level]int{
+	TraceLevel: 1,
+	DebugLevel: 5,
+	InfoLevel:  9,
+	WarnLevel:  13,
+	ErrorLevel: 17,
+	FatalLevel: 21,
+	PanicLevel: 24,
+}
+
+// OTLPConfig configures an OTLP logs exporter.
+type OTLPConfig struct {
+	// URL is the collector's OTLP/HTTP base URL and defaults to DefaultOTLPURL.
+	// Records are posted to URL/v1/logs using the JSON encoding; OTLP/gRPC is not
+	// supported.
+	URL string
+	// Header is sent with every request, e.g. for authentication.
+	Header http.Header
+	// ServiceName sets the service.name resource attribute.
+	ServiceName string
+	// ResourceAttributes are added to the resource.
+	ResourceAttributes map[string]string
+	// Trace returns the hex encoded trace and span IDs of the entry's context,
+	// if any.
+	Trace func(ctx context.Context) (traceID, spanID string)
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// OTLPSink exports entries as OpenTelemetry log records over OTLP/HTTP. Fields
+// become record attributes and levels are mapped to OTel severity numbers.
+type OTLPSink struct {
+	cfg      OTLPConfig
+	url      string
+	header   http.Header
+	resource []otlpKeyValue
+	batcher  *batcher
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 interface{}    `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+// NewOTLPSink creates an OTLP logs exporter.
+func NewOTLPSink(cfg OTLPConfig) *OTLPSink {
+	if cfg.URL == "" {
+		cfg.URL = DefaultOTLPURL
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	for k, v := range cfg.Header {
+		header[k] = v
+	}
+	s := &OTLPSink{cfg: cfg, url: strings.TrimSuffix(cfg.URL, "/") + "/v1/logs", header: header}
+	if cfg.ServiceName != "" {
+		s.resource = append(s.resource, otlpKeyValue{Key: "service.name", Value: otlpValue(cfg.ServiceName)})
+	}
+	for k, v := range cfg.ResourceAttributes {
+		s.resource = append(s.resource, otlpKeyValue{Key: k, Value: otlpValue(v)})
+	}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *OTLPSink) Write(entry *Entry) error {
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(entry.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity[entry.Level],
+		SeverityText:         strings.ToUpper(entry.Level.String()),
+		Body:                 otlpValue(entry.Message),
+	}
+	for k, v := range entry.Data {
+		record.Attributes = append(record.Attributes, otlpKeyValue{Key: k, Value: otlpValue(v)})
+	}
+	if s.cfg.Trace != nil && entry.Context != nil {
+		record.TraceID, record.SpanID = s.cfg.Trace(entry.Context)
+	}
+	data, err := json.Marshal(&record)
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{time: entry.Time, data: data})
+	return nil
+}
+
+// otlpValue encodes v as an OTLP AnyValue.
+func otlpValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8:
+		// 64 bit integers are strings in the protobuf JSON mapping
+		return map[string]interface{}{"intValue": jsonString(v)}
+	case float32, float64:
+		return map[string]interface{}{"doubleValue": v}
+	default:
+		return map[string]interface{}{"stringValue": stringValue(v)}
+	}
+}
+
+func (s *OTLPSink) send(items []batchItem) error {
+	records := make([]json.RawMessage, len(items))
+	for i, item := range items {
+		records[i] = item.data
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": s.resource},
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      map[string]string{"name": packagePath},
+				"logRecords": records,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	return post(s.cfg.Client, s.url, s.header, body, s.cfg.MaxRetries)
+}
+
+func (s *OTLPSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *OTLPSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *OTLPSink) Status() SinkHealth {
+	return s.batcher.status()
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPSink(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		body struct {
+			ResourceLogs []struct {
+				Resource struct {
+					Attributes []otlpKeyValue `json:"attributes"`
+				} `json:"resource"`
+				ScopeLogs []struct {
+					LogRecords []otlpLogRecord `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer srv.Close()
+
+	sink := NewOTLPSink(OTLPConfig{URL: srv.URL, ServiceName: "api", Trace: testTrace})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("otlp", sink)
+	ctx := context.WithValue(context.Background(), traceKey{}, "0af7651916cd43dd8448eb211c80319c")
+	Warn(ctx, "Warning Message 1", Field("n", 2))
+	assert.NoError(t, RemoveSink("otlp"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	resource := body.ResourceLogs[0].Resource.Attributes
+	assert.Equal(t, []otlpKeyValue{{Key: "service.name", Value: map[string]interface{}{"stringValue": "api"}}}, resource)
+	record := body.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, 13, record.SeverityNumber)
+	assert.Equal(t, "WARNING", record.SeverityText)
+	assert.Equal(t, map[string]interface{}{"stringValue": "Warning Message 1"}, record.Body)
+	assert.Equal(t, []otlpKeyValue{{Key: "n", Value: map[string]interface{}{"intValue": "2"}}}, record.Attributes)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", record.TraceID)
+	assert.Equal(t, "span-1", record.SpanID)
+}
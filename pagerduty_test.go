@@ -0,0 +1,36 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagerDutySink(t *testing.T) {
+	var events []pagerDutyEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sink := NewPagerDutySink(PagerDutyConfig{RoutingKey: "key", URL: srv.URL, DetailFields: []string{"field1"}})
+	data := logrus.Fields{"dedup_key": "db-down", "field1": "value1", "field2": "value2"}
+	assert.NoError(t, sink.Write(&Entry{Level: ErrorLevel, Message: "Error Message 1", Time: time.Now()}))
+	assert.NoError(t, sink.Write(&Entry{Level: FatalLevel, Message: "Fatal Message 1", Time: time.Now(), Data: data}))
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, "key", events[0].RoutingKey)
+	assert.Equal(t, "trigger", events[0].EventAction)
+	assert.Equal(t, "db-down", events[0].DedupKey)
+	assert.Equal(t, "critical", events[0].Payload.Severity)
+	assert.Equal(t, "Fatal Message 1", events[0].Payload.Summary)
+	assert.Equal(t, map[string]interface{}{"field1": "value1"}, events[0].Payload.CustomDetails)
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"regexp"
+)
+
+// Replacements of the personal data masked by WithPIIMasking.
+const (
+	MaskedEmail = "[EMAIL]"
+	MaskedCard  = "[CARD]"
+	MaskedPhone = "[PHONE]"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)\s?|\b\d{3}[ .-])\d{3}[ .-]\d{4}\b`)
+)
+
+// WithPIIMasking masks email addresses, credit card numbers that pass the Luhn
+// check, and phone numbers written with separators, such as 555-123-4567 or
+// +1 (555) 123-4567, in messages and string field values.
+func WithPIIMasking() Option {
+	return withTextScrubbers(
+		textScrubber{pattern: emailPattern, replace: func(string) string { return MaskedEmail }},
+		textScrubber{pattern: cardPattern, replace: maskCard},
+		textScrubber{pattern: phonePattern, replace: func(string) string { return MaskedPhone }},
+	)
+}
+
+func maskCard(match string) string {
+	if luhn(match) {
+		return MaskedCard
+	}
+	return match
+}
+
+// luhn reports whether the digits in s have a valid Luhn checksum.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
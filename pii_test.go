@@ -0,0 +1,37 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLuhn(t *testing.T) {
+	assert.True(t, luhn("4111 1111 1111 1111"))
+	assert.True(t, luhn("5500-0000-0000-0004"))
+	assert.False(t, luhn("4111 1111 1111 1112"))
+}
+
+func TestWithPIIMasking(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithPIIMasking())
+	defer Configure(func(c *config) { c.scrubbers = nil })
+
+	Info(context.Background(), "order by jane.doe+shop@mail.example.com paid with 4111 1111 1111 1111",
+		Field("phone", "+1 (555) 123-4567"),
+		Field("contact", map[string]interface{}{"phone": "555.123.4567", "id": "1234567890"}),
+		Field("order", "1234567812345678"),
+		Field("at", "2024-01-02 10:11:12"),
+	)
+
+	entry := sink.entries[0]
+	assert.Equal(t, "order by [EMAIL] paid with [CARD]", entry.Message)
+	assert.Equal(t, MaskedPhone, entry.Data["phone"])
+	assert.Equal(t, map[string]interface{}{"phone": MaskedPhone, "id": "1234567890"}, entry.Data["contact"])
+	assert.Equal(t, "1234567812345678", entry.Data["order"], "fails the Luhn check")
+	assert.Equal(t, "2024-01-02 10:11:12", entry.Data["at"])
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Bound is a set of fields prepared by Prebind.
+type Bound struct {
+	fields logrus.Fields
+	keys   []string // in the order they were bound
+}
+
+// Prebind prepares fields logged many times with the same values, such as the
+// dimensions of a tight loop: values other than strings, numbers and booleans
+// are encoded to JSON once, and the JSON formatter and JSON sinks reuse the
+// encoding on every entry. Such values reach formatters and sinks wrapped in a
+// value that marshals to the encoding and prints like the original.
+//
+//	dims := log.Prebind(log.Field("job", job), log.Field("shard", shard))
+//	for _, item := range items {
+//		log.Debug(ctx, "processing", dims, log.Field("item", item.ID))
+//	}
+func Prebind(flds ...Fld) Bound {
+	fields := make(logrus.Fields, len(flds))
+	keys := make([]string, 0, len(flds))
+	for _, f := range flds {
+		f.apply(fields)
+		switch f := f.(type) {
+		case *fld:
+			keys = append(keys, f.key)
+		case Bound:
+			keys = append(keys, f.keys...)
+		}
+	}
+	for k, v := range fields {
+		switch v.(type) {
+		case nil, string, bool, int, int64, int32, uint, uint64, float64, float32:
+			continue
+		}
+		if data, err := appendJSON(nil, v); err == nil {
+			fields[k] = &encodedJSON{value: v, data: data}
+		}
+	}
+	return Bound{fields: fields, keys: keys}
+}
+
+func (b Bound) apply(fields logrus.Fields) {
+	for k, v := range b.fields {
+		fields[k] = v
+	}
+}
+
+// encodedJSON is a field value with its JSON encoding.
+type encodedJSON struct {
+	value interface{}
+	data  []byte
+}
+
+func (e *encodedJSON) MarshalJSON() ([]byte, error) {
+	return e.data, nil
+}
+
+func (e *encodedJSON) String() string {
+	return fmt.Sprint(e.value)
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrebind(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(os.Stderr)
+
+	dims := Prebind(Field("order", testOrder()), Field("shard", 3), Field("job", "sync"))
+	assert.IsType(t, &encodedJSON{}, dims.fields["order"])
+	assert.Equal(t, 3, dims.fields["shard"])
+	Info(context.Background(), "processing", dims, Field("item", 1))
+
+	var logged map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &logged))
+	assert.Equal(t, "sync", logged["job"])
+	assert.Equal(t, 3.0, logged["shard"])
+	assert.Equal(t, 1.0, logged["item"])
+	order, _ := json.Marshal(testOrder())
+	var want interface{}
+	assert.NoError(t, json.Unmarshal(order, &want))
+	assert.Equal(t, want, logged["order"])
+
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Info(context.Background(), "processing", Prebind(Field("ids", []int{1, 2})))
+	assert.Equal(t, "[1 2]", sink.entries[0].Data["ids"].(*encodedJSON).String())
+}
+
+func BenchmarkPrebind(b *testing.B) {
+	order := testOrder()
+	ctx := context.Background()
+	f := new(jsonFormatter)
+	b.Run("Field", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			e := newEntry(ctx, []Fld{Field("order", order), Field("item", i)})
+			e.Message = "processing"
+			_, _ = f.Format(e)
+		}
+	})
+	b.Run("Prebind", func(b *testing.B) {
+		dims := Prebind(Field("order", order))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			e := newEntry(ctx, []Fld{dims, Field("item", i)})
+			e.Message = "processing"
+			_, _ = f.Format(e)
+		}
+	})
+}
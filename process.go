@@ -0,0 +1,56 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// The fields WithProcessInfo adds.
+const (
+	HostnameKey = "hostname"
+	PIDKey      = "pid"
+	ServiceKey  = "service"
+	VersionKey  = "version"
+)
+
+// WithProcessInfo adds the hostname, the process ID, the service name and
+// the service version to every entry. The service name and version are taken
+// from the SERVICE_NAME and SERVICE_VERSION environment variables unless set
+// with WithService; the name defaults to the executable name and the version
+// is left out if unknown. Fields of a call or its context take precedence.
+func WithProcessInfo() Option {
+	return func(c *config) {
+		fields := map[string]interface{}{PIDKey: os.Getpid()}
+		if host, err := os.Hostname(); err == nil {
+			fields[HostnameKey] = host
+		}
+		if _, ok := c.static[ServiceKey]; !ok {
+			if name := os.Getenv("SERVICE_NAME"); name != "" {
+				fields[ServiceKey] = name
+			} else {
+				fields[ServiceKey] = filepath.Base(os.Args[0])
+			}
+		}
+		if _, ok := c.static[VersionKey]; !ok {
+			if version := os.Getenv("SERVICE_VERSION"); version != "" {
+				fields[VersionKey] = version
+			}
+		}
+		c.setStatic(fields)
+	}
+}
+
+// WithService sets the service name and version WithProcessInfo adds, in
+// place of the environment. Empty values are not set.
+func WithService(name, version string) Option {
+	return func(c *config) {
+		fields := map[string]interface{}{}
+		if name != "" {
+			fields[ServiceKey] = name
+		}
+		if version != "" {
+			fields[VersionKey] = version
+		}
+		c.setStatic(fields)
+	}
+}
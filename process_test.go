@@ -0,0 +1,43 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithProcessInfo(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(func(c *config) { c.static = nil })
+
+	t.Setenv("SERVICE_NAME", "")
+	t.Setenv("SERVICE_VERSION", "")
+	Configure(WithProcessInfo())
+	Info(ctx, "Informational Message 1")
+	host, _ := os.Hostname()
+	assert.Equal(t, host, sink.entries[0].Data[HostnameKey])
+	assert.Equal(t, os.Getpid(), sink.entries[0].Data[PIDKey])
+	assert.Equal(t, filepath.Base(os.Args[0]), sink.entries[0].Data[ServiceKey])
+	assert.NotContains(t, sink.entries[0].Data, VersionKey)
+
+	Configure(func(c *config) { c.static = nil })
+	t.Setenv("SERVICE_NAME", "billing")
+	t.Setenv("SERVICE_VERSION", "1.2.3")
+	Configure(WithProcessInfo())
+	Info(ctx, "Informational Message 2", Field(ServiceKey, "override"))
+	assert.Equal(t, "override", sink.entries[1].Data[ServiceKey])
+	assert.Equal(t, "1.2.3", sink.entries[1].Data[VersionKey])
+
+	Configure(func(c *config) { c.static = nil })
+	Configure(WithService("api", ""), WithProcessInfo())
+	Info(ctx, "Informational Message 3")
+	assert.Equal(t, "api", sink.entries[2].Data[ServiceKey])
+	assert.Equal(t, "1.2.3", sink.entries[2].Data[VersionKey])
+}
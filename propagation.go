@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PropagationPrefix is prepended to the key of a propagated field to name the
+// HTTP header or gRPC metadata key it is carried in, so the tenant field
+// travels as X-Log-Tenant.
+const PropagationPrefix = "X-Log-"
+
+// WithPropagatedFields sets the context fields the Inject functions copy into
+// outgoing requests and the Extract functions read back on the other side,
+// such as a request ID or tenant, so they are logged by every service a
+// request passes through. Both the context fields given to Init and those
+// added with ContextWithFields are carried; values are sent as strings.
+func WithPropagatedFields(keys ...string) Option {
+	return func(c *config) {
+		c.propagated = append([]string(nil), keys...)
+	}
+}
+
+// InjectHTTPHeaders sets the propagated fields of ctx as headers of an
+// outgoing request.
+func InjectHTTPHeaders(ctx context.Context, h http.Header) {
+	for k, v := range propagatedFields(ctx) {
+		h.Set(PropagationPrefix+k, url.QueryEscape(v))
+	}
+}
+
+// ExtractHTTPHeaders returns ctx with the propagated fields found in the
+// headers of an incoming request, added with ContextWithFields.
+func ExtractHTTPHeaders(ctx context.Context, h http.Header) context.Context {
+	return extractFields(ctx, func(k string) []string {
+		return h.Values(PropagationPrefix + k)
+	})
+}
+
+// InjectMetadata sets the propagated fields of ctx in the metadata of an
+// outgoing gRPC call. It takes a google.golang.org/grpc/metadata.MD, whose
+// keys are lower case, without depending on gRPC:
+//
+//	md, _ := metadata.FromOutgoingContext(ctx)
+//	md = md.Copy()
+//	log.InjectMetadata(ctx, md)
+//	ctx = metadata.NewOutgoingContext(ctx, md)
+func InjectMetadata(ctx context.Context, md map[string][]string) {
+	for k, v := range propagatedFields(ctx) {
+		md[strings.ToLower(PropagationPrefix+k)] = []string{url.QueryEscape(v)}
+	}
+}
+
+// ExtractMetadata returns ctx with the propagated fields found in the
+// metadata of an incoming gRPC call, such as from
+// metadata.FromIncomingContext, added with ContextWithFields.
+func ExtractMetadata(ctx context.Context, md map[string][]string) context.Context {
+	return extractFields(ctx, func(k string) []string {
+		return md[strings.ToLower(PropagationPrefix+k)]
+	})
+}
+
+// propagatedFields returns the values of the propagated fields of ctx.
+func propagatedFields(ctx context.Context) map[string]string {
+	keys := currentConfig().propagated
+	if len(keys) == 0 || ctx == nil {
+		return nil
+	}
+	values := make(map[string]string, len(keys))
+	bound := contextFields(ctx)
+	for _, k := range keys {
+		if v, ok := bound.fields[k]; ok {
+			values[k] = stringValue(v)
+		}
+	}
+	for _, f := range ctxFields {
+		k := fmt.Sprintf("%v", f)
+		if _, ok := values[k]; ok || !propagated(keys, k) {
+			continue
+		}
+		if v, ok := ctx.Value(f).(string); ok {
+			values[k] = v
+		}
+	}
+	return values
+}
+
+func propagated(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// extractFields adds the propagated fields get returns values for to ctx.
+func extractFields(ctx context.Context, get func(key string) []string) context.Context {
+	var flds []Fld
+	for _, k := range currentConfig().propagated {
+		vs := get(k)
+		if len(vs) == 0 {
+			continue
+		}
+		v, err := url.QueryUnescape(vs[0])
+		if err != nil {
+			v = vs[0]
+		}
+		flds = append(flds, Field(k, v))
+	}
+	if flds == nil {
+		return ctx
+	}
+	return ContextWithFields(ctx, flds...)
+}
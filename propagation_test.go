@@ -0,0 +1,49 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPropagation(t *testing.T) {
+	Init(JSONFormatter, InfoLevel, key("requestId"))
+	defer Init(JSONFormatter, InfoLevel)
+	ctx := context.WithValue(context.Background(), key("requestId"), "req-1")
+	ctx = ContextWithFields(ctx, Field("tenant", "acme corp"), Field("shard", 3), Field("user", "jane"))
+
+	h := http.Header{}
+	InjectHTTPHeaders(ctx, h)
+	assert.Empty(t, h)
+
+	Configure(WithPropagatedFields("requestId", "tenant", "shard"))
+	defer Configure(WithPropagatedFields())
+	InjectHTTPHeaders(ctx, h)
+	assert.Equal(t, http.Header{
+		"X-Log-Requestid": {"req-1"},
+		"X-Log-Tenant":    {"acme+corp"},
+		"X-Log-Shard":     {"3"},
+	}, h)
+	md := map[string][]string{"authorization": {"token"}}
+	InjectMetadata(ctx, md)
+	assert.Equal(t, map[string][]string{
+		"authorization":   {"token"},
+		"x-log-requestid": {"req-1"},
+		"x-log-tenant":    {"acme+corp"},
+		"x-log-shard":     {"3"},
+	}, md)
+
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Info(ExtractHTTPHeaders(context.Background(), h), "Informational Message 1")
+	Info(ExtractMetadata(context.Background(), md), "Informational Message 2")
+	for _, e := range sink.entries {
+		assert.Equal(t, map[string]interface{}{"requestId": "req-1", "tenant": "acme corp", "shard": "3"}, map[string]interface{}(e.Data))
+	}
+
+	bg := context.Background()
+	assert.Equal(t, bg, ExtractHTTPHeaders(bg, http.Header{}))
+}
@@ -0,0 +1,147 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPubSubURL is the Pub/Sub API endpoint. Ordered delivery requires a
+	// regional endpoint such as https://us-east1-pubsub.googleapis.com.
+	DefaultPubSubURL = "https://pubsub.googleapis.com"
+
+	pubSubMaxBatchMessages = 1000
+)
+
+// PubSubConfig configures a Google Pub/Sub sink.
+type PubSubConfig struct {
+	// ProjectID and Topic name the topic to publish to.
+	ProjectID string
+	Topic     string
+	// OrderingKeyField names the entry field used as ordering key.
+	OrderingKeyField string
+	// AttributeFields names entry fields copied to message attributes.
+	AttributeFields []string
+	// TokenSource returns an OAuth2 access token.
+	TokenSource func() (string, error)
+	// Formatter renders message data and defaults to JSON.
+	Formatter logrus.Formatter
+	// URL defaults to DefaultPubSubURL.
+	URL string
+	// BatchSize defaults to DefaultBatchSize and is capped at 1000.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// PubSubSink publishes entries to a Pub/Sub topic in batches.
+type PubSubSink struct {
+	cfg     PubSubConfig
+	url     string
+	batcher *batcher
+}
+
+type pubSubMessage struct {
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+// NewPubSubSink creates a Pub/Sub sink.
+func NewPubSubSink(cfg PubSubConfig) *PubSubSink {
+	if cfg.Formatter == nil {
+		cfg.Formatter = new(logrus.JSONFormatter)
+	}
+	if cfg.URL == "" {
+		cfg.URL = DefaultPubSubURL
+	}
+	if cfg.BatchSize > pubSubMaxBatchMessages {
+		cfg.BatchSize = pubSubMaxBatchMessages
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+	s := &PubSubSink{
+		cfg: cfg,
+		url: strings.TrimSuffix(cfg.URL, "/") + "/v1/projects/" + cfg.ProjectID + "/topics/" + cfg.Topic + ":publish",
+	}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *PubSubSink) Write(entry *Entry) error {
+	data, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	msg := pubSubMessage{Data: bytes.TrimRight(data, "\n")}
+	if v, ok := entry.Data[s.cfg.OrderingKeyField]; ok && s.cfg.OrderingKeyField != "" {
+		msg.OrderingKey = stringValue(v)
+	}
+	for _, k := range s.cfg.AttributeFields {
+		if v, ok := entry.Data[k]; ok {
+			if msg.Attributes == nil {
+				msg.Attributes = map[string]string{}
+			}
+			msg.Attributes[k] = stringValue(v)
+		}
+	}
+	encoded, err := json.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{key: msg.OrderingKey, time: entry.Time, data: encoded})
+	return nil
+}
+
+func (s *PubSubSink) send(items []batchItem) error {
+	messages := make([]json.RawMessage, len(items))
+	for i, item := range items {
+		messages[i] = item.data
+	}
+	body, err := json.Marshal(map[string]interface{}{"messages": messages})
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if s.cfg.TokenSource != nil {
+		token, err := s.cfg.TokenSource()
+		if err != nil {
+			return err
+		}
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return post(s.cfg.Client, s.url, header, body, s.cfg.MaxRetries)
+}
+
+func (s *PubSubSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *PubSubSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *PubSubSink) Status() SinkHealth {
+	return s.batcher.status()
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSubSink(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		body struct {
+			Messages []pubSubMessage `json:"messages"`
+		}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/v1/projects/proj/topics/logs:publish", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer srv.Close()
+
+	sink := NewPubSubSink(PubSubConfig{
+		ProjectID:        "proj",
+		Topic:            "logs",
+		OrderingKeyField: "tenant",
+		AttributeFields:  []string{"service"},
+		URL:              srv.URL,
+	})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("pubsub", sink)
+	Info(context.Background(), "Informational Message 1", Field("tenant", "acme"), Field("service", "api"))
+	assert.NoError(t, RemoveSink("pubsub"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, body.Messages, 1)
+	assert.Equal(t, "acme", body.Messages[0].OrderingKey)
+	assert.Equal(t, map[string]string{"service": "api"}, body.Messages[0].Attributes)
+	assert.Contains(t, string(body.Messages[0].Data), "Informational Message 1")
+}
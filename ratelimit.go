@@ -0,0 +1,104 @@
+package log
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SuppressedKey is the field on a rate limited entry counting the entries
+// suppressed under its key since the previous one.
+const SuppressedKey = "suppressed"
+
+// rateLimitField carries the limit from RateLimited to emit, which removes it.
+const rateLimitField = "\x00rate_limit"
+
+type rateLimit struct {
+	key  string
+	rate float64
+}
+
+func (r *rateLimit) apply(fields logrus.Fields) {
+	fields[rateLimitField] = r
+}
+
+// RateLimited limits the entries logged with it under key to rate per second,
+// with bursts up to the rate, so a tight retry loop cannot emit thousands of
+// identical errors. Entries over the limit are counted as dropped and reported
+// in the suppressed field of the next entry let through.
+//
+//	log.Error(ctx, err, log.RateLimited("db-reconnect", 1))
+func RateLimited(key string, rate float64) Fld {
+	return &rateLimit{key: key, rate: rate}
+}
+
+// limiter is a token bucket.
+type limiter struct {
+	tokens     float64
+	last       time.Time
+	refill     time.Duration // until the bucket is full again
+	suppressed uint64
+}
+
+// limiterSweepInterval is how often idle limiters are removed, and how long a
+// limiter with suppressed entries to report is kept after it refilled.
+const limiterSweepInterval = time.Minute
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*limiter{}
+	lastSweep  time.Time
+	limitNow   = time.Now
+)
+
+// takeRateLimit removes the limit set with RateLimited from the entry.
+func takeRateLimit(entry *Entry) *rateLimit {
+	r, ok := entry.Data[rateLimitField].(*rateLimit)
+	if ok {
+		delete(entry.Data, rateLimitField)
+	}
+	return r
+}
+
+// allow reports whether an entry may pass and how many were suppressed before.
+func (r *rateLimit) allow() (bool, uint64) {
+	burst := math.Max(1, math.Ceil(r.rate))
+	now := limitNow()
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if now.Sub(lastSweep) >= limiterSweepInterval {
+		sweepLimiters(now)
+	}
+	l, ok := limiters[r.key]
+	if !ok {
+		l = &limiter{tokens: burst, last: now}
+		limiters[r.key] = l
+	}
+	l.tokens = math.Min(burst, l.tokens+now.Sub(l.last).Seconds()*r.rate)
+	l.last = now
+	l.refill = time.Duration((burst - l.tokens + 1) / r.rate * float64(time.Second))
+	if l.tokens < 1 {
+		l.suppressed++
+		return false, 0
+	}
+	l.tokens--
+	suppressed := l.suppressed
+	l.suppressed = 0
+	return true, suppressed
+}
+
+// sweepLimiters removes the limiters that have been idle long enough to be
+// full again, as a new one would be, so that keys used once do not pile up.
+// Limiters with suppressed entries to report are kept a while longer. It must
+// be called with limitersMu held.
+func sweepLimiters(now time.Time) {
+	lastSweep = now
+	for key, l := range limiters {
+		idle := now.Sub(l.last)
+		if idle >= l.refill && (l.suppressed == 0 || idle >= l.refill+limiterSweepInterval) {
+			delete(limiters, key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimited(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	SetDropSummaryInterval(0)
+	defer SetDropSummaryInterval(DefaultDropSummaryInterval)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	now := time.Unix(100, 0)
+	limitNow = func() time.Time { return now }
+	defer func() { limitNow = time.Now }()
+	limitersMu.Lock()
+	limiters = map[string]*limiter{}
+	limitersMu.Unlock()
+
+	total := DroppedEntries()
+	err := errors.New("connection refused")
+	for i := 0; i < 10; i++ {
+		Error(context.Background(), err, RateLimited("reconnect", 2))
+	}
+	assert.Len(t, sink.entries, 2)
+	assert.Equal(t, total+8, DroppedEntries())
+	assert.NotContains(t, sink.entries[0].Data, rateLimitField)
+
+	now = now.Add(time.Second / 2)
+	Error(context.Background(), err, RateLimited("reconnect", 2))
+	Error(context.Background(), err, RateLimited("other", 2))
+	assert.Len(t, sink.entries, 4)
+	assert.Equal(t, uint64(8), sink.entries[2].Data[SuppressedKey])
+	assert.NotContains(t, sink.entries[3].Data, SuppressedKey)
+}
+
+func TestLimiterSweep(t *testing.T) {
+	now := time.Unix(100, 0)
+	limitNow = func() time.Time { return now }
+	defer func() { limitNow = time.Now }()
+	limitersMu.Lock()
+	limiters = map[string]*limiter{}
+	lastSweep = now
+	limitersMu.Unlock()
+
+	(&rateLimit{key: "once", rate: 1}).allow()
+	(&rateLimit{key: "slow", rate: 0.001}).allow()
+	(&rateLimit{key: "busy", rate: 1}).allow()
+	(&rateLimit{key: "busy", rate: 1}).allow()
+	now = now.Add(limiterSweepInterval)
+	(&rateLimit{key: "new", rate: 1}).allow()
+	assert.Len(t, limiters, 3)
+	assert.NotContains(t, limiters, "once")
+	assert.Contains(t, limiters, "slow", "not refilled yet")
+	assert.Contains(t, limiters, "busy", "suppressed entries to report")
+
+	now = now.Add(2 * limiterSweepInterval)
+	allowed, suppressed := (&rateLimit{key: "new", rate: 1}).allow()
+	assert.True(t, allowed)
+	assert.Zero(t, suppressed)
+	assert.Len(t, limiters, 2)
+	assert.NotContains(t, limiters, "busy")
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultFlightRecorderSize is the number of entries the flight recorder keeps.
+const DefaultFlightRecorderSize = 1000
+
+// flightRecorder keeps the most recent entries in a ring buffer.
+type flightRecorder struct {
+	output io.Writer
+
+	mu      sync.Mutex
+	entries []*Entry
+	next    int
+	full    bool
+}
+
+var recorder atomic.Value // *flightRecorder
+
+// EnableFlightRecorder keeps the last size entries of every level, including
+// those below the logger's level, and writes them to w when a Fatal entry is
+// logged, Recover catches a panic or DumpFlightRecorder is called, so the
+// lead-up to a crash is always captured. A zero size means
+// DefaultFlightRecorderSize and a nil w the logger's output. Dumped entries
+// carry the field flight_recorder=true.
+func EnableFlightRecorder(size int, w io.Writer) {
+	if size <= 0 {
+		size = DefaultFlightRecorderSize
+	}
+	recorder.Store(&flightRecorder{output: w, entries: make([]*Entry, size)})
+}
+
+// DisableFlightRecorder stops recording and discards the recorded entries.
+func DisableFlightRecorder() {
+	recorder.Store((*flightRecorder)(nil))
+}
+
+// DumpFlightRecorder writes the recorded entries, oldest first, and clears them.
+func DumpFlightRecorder() error {
+	if r := currentRecorder(); r != nil {
+		return r.dump()
+	}
+	return nil
+}
+
+func currentRecorder() *flightRecorder {
+	r, _ := recorder.Load().(*flightRecorder)
+	return r
+}
+
+func (r *flightRecorder) record(entry *Entry, level Level, msg string) {
+	e := &Entry{Logger: logger, Data: entry.Data, Time: entry.Time, Level: level, Message: msg, Context: entry.Context, Caller: callerFrame(entry.Context)}
+	r.mu.Lock()
+	r.entries[r.next] = e
+	if r.next++; r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+func (r *flightRecorder) dump() error {
+	r.mu.Lock()
+	var entries []*Entry
+	if r.full {
+		entries = append(entries, r.entries[r.next:]...)
+	}
+	entries = append(entries, r.entries[:r.next]...)
+	for i := range r.entries {
+		r.entries[i] = nil
+	}
+	r.next, r.full = 0, false
+	r.mu.Unlock()
+
+	out := r.output
+	if out == nil {
+		out = logger.Out
+	}
+	for _, e := range entries {
+		data := make(logrus.Fields, len(e.Data)+1)
+		for k, v := range e.Data {
+			data[k] = v
+		}
+		data["flight_recorder"] = true
+		e.Data = data
+		b, err := logger.Formatter.Format(e)
+		if err != nil {
+			return err
+		}
+		if _, err = out.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
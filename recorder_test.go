@@ -0,0 +1,46 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	ctx := context.Background()
+	Init(SimpleFormatter, InfoLevel)
+	out := &bytes.Buffer{}
+	dump := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(os.Stderr)
+	EnableFlightRecorder(3, dump)
+	defer DisableFlightRecorder()
+
+	Debug(ctx, "Debug Message 1")
+	Debug(ctx, "Debug Message 2")
+	Debugf(ctx, "Debug Message %d", 3)
+	Info(ctx, "Informational Message 1", Field("field1", "value1"))
+	assert.Equal(t, "Informational Message 1 | field1=value1\n", out.String())
+	assert.Empty(t, dump.String())
+
+	assert.NoError(t, DumpFlightRecorder())
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "Debug Message 2 | "))
+	assert.Contains(t, lines[0], "flight_recorder=true")
+	assert.True(t, strings.HasPrefix(lines[2], "Informational Message 1 | "))
+
+	// dumped before the fatal entry
+	dump.Reset()
+	SetExitFunc(func(int) {})
+	defer SetExitFunc(nil)
+	Debug(ctx, "Debug Message 4")
+	Fatal(ctx, errors.New("Fatal Message 1"))
+	assert.True(t, strings.HasPrefix(dump.String(), "Debug Message 4 | "))
+	assert.True(t, strings.HasSuffix(out.String(), "Fatal Message 1\n"))
+}
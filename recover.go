@@ -0,0 +1,76 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// PanicKey is the field holding the value passed to panic. Recover logs it
+// with the GoroutineKey and StackKey fields.
+const PanicKey = "panic"
+
+// panicStackFrames is how deep the stack of a recovered panic is logged.
+const panicStackFrames = 64
+
+// Recover logs a panic at Error level, with the panic value, the goroutine
+// and the stack where it happened, and stops it, so a failing goroutine does
+// not take the program down. It must be deferred directly:
+//
+//	go func() {
+//		defer log.Recover(ctx, log.Field("job", id))
+//		...
+//	}()
+//
+// The flight recorder, if enabled, is dumped before the entry.
+func Recover(ctx context.Context, flds ...Fld) {
+	if v := recover(); v != nil {
+		logPanic(ctx, v, flds)
+	}
+}
+
+// RecoverAndRepanic logs a panic like Recover, flushes the output and sinks,
+// then panics again with the same value. It must be deferred directly.
+func RecoverAndRepanic(ctx context.Context, flds ...Fld) {
+	if v := recover(); v != nil {
+		logPanic(ctx, v, flds)
+		if err := Flush(context.Background()); err != nil {
+			reportError(err)
+		}
+		panic(v)
+	}
+}
+
+func logPanic(ctx context.Context, v interface{}, flds []Fld) {
+	if err := DumpFlightRecorder(); err != nil {
+		reportError(err)
+	}
+	if !logged(ctx, ErrorLevel) {
+		return
+	}
+	entry := newEntry(ctx, flds)
+	entry.Data[PanicKey] = fmt.Sprint(v)
+	entry.Data[GoroutineKey] = goroutineID()
+	entry.Data[StackKey] = formatStack(panicStack())
+	addErrorFields(entry, v)
+	emit(entry, ErrorLevel, "panic: "+fmt.Sprint(v))
+}
+
+// panicStack returns the stack of a panicking goroutine from a deferred call,
+// starting at the function that panicked.
+func panicStack() (stack []runtime.Frame) {
+	pcs := make([]uintptr, panicStackFrames+maxInternalFrames)
+	// skip panicStack, logPanic and Recover, then the frames of the runtime
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(4, pcs)])
+	skipping := true
+	for {
+		f, more := frames.Next()
+		if pkg, _ := splitFunctionName(f.Function); !skipping || pkg != "runtime" {
+			skipping = false
+			stack = append(stack, f)
+		}
+		if !more || len(stack) == panicStackFrames {
+			return stack
+		}
+	}
+}
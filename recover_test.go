@@ -0,0 +1,57 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func panicking() {
+	var m map[string]int
+	m["x"] = 1
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer Recover(ctx, Field("job", 7))
+		panicking()
+	}()
+	wg.Wait()
+
+	assert.Len(t, sink.entries, 1)
+	e := sink.entries[0]
+	assert.Equal(t, ErrorLevel, e.Level)
+	assert.Equal(t, "panic: assignment to entry in nil map", e.Message)
+	assert.Equal(t, "assignment to entry in nil map", e.Data[PanicKey])
+	assert.Equal(t, 7, e.Data["job"])
+	assert.NotZero(t, e.Data[GoroutineKey])
+	assert.NotEqual(t, goroutineID(), e.Data[GoroutineKey])
+	stack := e.Data[StackKey].(string)
+	assert.True(t, strings.HasPrefix(stack, "github.com/andyday/go-log.panicking\n\t"), stack)
+
+	// nothing to recover
+	func() {
+		defer Recover(ctx)
+	}()
+	assert.Len(t, sink.entries, 1)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer RecoverAndRepanic(ctx)
+		panic("boom")
+	})
+	assert.Len(t, sink.entries, 2)
+	assert.Equal(t, "boom", sink.entries[1].Data[PanicKey])
+	assert.True(t, strings.HasPrefix(sink.entries[1].Data[StackKey].(string), "github.com/andyday/go-log.TestRecover.func"))
+}
@@ -0,0 +1,150 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedValue replaces the values of redacted fields.
+const RedactedValue = "[REDACTED]"
+
+// WithRedactedKeys replaces the values of fields with the given names, compared
+// case-insensitively, with RedactedValue. Keys nested in maps, slices and
+// structs are redacted too, as they appear in the JSON encoding. Redaction
+// happens before entries are recorded, formatted or handed to sinks.
+func WithRedactedKeys(keys ...string) Option {
+	return func(c *config) {
+		c.redactedKeys = addKeys(c.redactedKeys, keys)
+	}
+}
+
+// scrubs reports whether entries need scrubbing.
+func (c *config) scrubs() bool {
+	return len(c.redactedKeys) > 0 || len(c.scrubbers) > 0 || len(c.hashedKeys) > 0 || c.aead != nil || c.allowedKeys != nil
+}
+
+// scrubEntry removes sensitive data from the fields of the entry, which must
+// not be shared, and returns the message without it.
+func (c *config) scrubEntry(entry *Entry, msg string) string {
+	if c.allowedKeys != nil {
+		c.dropDisallowed(entry)
+	}
+	for k, v := range entry.Data {
+		if v, changed := c.scrubField(k, v); changed {
+			entry.Data[k] = v
+		}
+	}
+	msg, _ = c.scrubText(msg)
+	return msg
+}
+
+func (c *config) scrubField(key string, v interface{}) (interface{}, bool) {
+	lower := strings.ToLower(key)
+	if c.redactedKeys[lower] {
+		return RedactedValue, true
+	}
+	if c.hashedKeys[lower] && v != nil {
+		return c.hashValue(v), true
+	}
+	return c.scrubValue(v)
+}
+
+// scrubValue returns v without sensitive data, and whether that changed it.
+// Maps and slices are copied rather than modified.
+func (c *config) scrubValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		return c.scrubText(v)
+	case *encryptedValue:
+		return c.encrypt(v), true
+	case nil, bool, int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, float64, float32, json.Number:
+		return v, false
+	case logrus.Fields:
+		return c.scrubMap(v)
+	case namespace:
+		if scrubbed, changed := c.scrubMap(v); changed {
+			return namespace(scrubbed.(map[string]interface{})), true
+		}
+		return v, false
+	case map[string]interface{}:
+		return c.scrubMap(v)
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		if scrubbed, changed := c.scrubMap(m); changed {
+			return scrubbed, true
+		}
+		return v, false
+	case []interface{}:
+		var scrubbed []interface{}
+		for i, e := range v {
+			if e, changed := c.scrubValue(e); changed {
+				if scrubbed == nil {
+					scrubbed = append([]interface{}(nil), v...)
+				}
+				scrubbed[i] = e
+			}
+		}
+		if scrubbed == nil {
+			return v, false
+		}
+		return scrubbed, true
+	}
+	// anything else is scrubbed as it would be logged, as JSON
+	data, err := appendJSON(nil, v)
+	if err != nil || !c.mayNeedScrubbing(data) {
+		return v, false
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return v, false
+	}
+	if scrubbed, changed := c.scrubValue(generic); changed {
+		return scrubbed, true
+	}
+	return v, false
+}
+
+func (c *config) scrubMap(m map[string]interface{}) (interface{}, bool) {
+	var scrubbed map[string]interface{}
+	for k, v := range m {
+		if v, changed := c.scrubField(k, v); changed {
+			if scrubbed == nil {
+				scrubbed = make(map[string]interface{}, len(m))
+				for k, v := range m {
+					scrubbed[k] = v
+				}
+			}
+			scrubbed[k] = v
+		}
+	}
+	if scrubbed == nil {
+		return m, false
+	}
+	return scrubbed, true
+}
+
+// mayNeedScrubbing cheaply rules out JSON encodings without anything to scrub.
+func (c *config) mayNeedScrubbing(data []byte) bool {
+	for _, sc := range c.scrubbers {
+		if sc.pattern.Match(data) {
+			return true
+		}
+	}
+	lower := bytes.ToLower(data)
+	for _, keys := range []map[string]bool{c.redactedKeys, c.hashedKeys} {
+		for k := range keys {
+			if bytes.Contains(lower, []byte(`"`+k+`":`)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type redactCredentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func TestWithRedactedKeys(t *testing.T) {
+	Init(JSONFormatter, InfoLevel, key("authorization"))
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithRedactedKeys("Password", "authorization"), WithRedactedKeys("ssn"))
+	defer Configure(func(c *config) { c.redactedKeys = nil })
+
+	nested := map[string]interface{}{"SSN": "123-45-6789", "name": "jane"}
+	creds := &redactCredentials{User: "jane", Password: "hunter2"}
+	ctx := context.WithValue(context.Background(), key("authorization"), "Bearer abc")
+	Info(ctx, "login",
+		Field("password", "hunter2"),
+		Field("profile", nested),
+		Field("labels", map[string]string{"ssn": "1", "team": "a"}),
+		Field("list", []interface{}{nested, 1}),
+		Field("creds", creds),
+		Field("order", testOrder()),
+	)
+
+	data := sink.entries[0].Data
+	assert.Equal(t, RedactedValue, data["password"])
+	assert.Equal(t, RedactedValue, data["authorization"])
+	assert.Equal(t, map[string]interface{}{"SSN": RedactedValue, "name": "jane"}, data["profile"])
+	assert.Equal(t, "123-45-6789", nested["SSN"], "the logged map is not modified")
+	assert.Equal(t, map[string]interface{}{"ssn": RedactedValue, "team": "a"}, data["labels"])
+	assert.Equal(t, RedactedValue, data["list"].([]interface{})[0].(map[string]interface{})["SSN"])
+	assert.Equal(t, map[string]interface{}{"user": "jane", "password": RedactedValue}, data["creds"])
+	assert.Equal(t, testOrder(), data["order"])
+
+	entry := logger.WithFields(logrus.Fields{"other": 1})
+	assert.Equal(t, "msg", currentConfig().scrubEntry(entry, "msg"))
+	assert.Equal(t, logrus.Fields{"other": 1}, entry.Data)
+}
@@ -0,0 +1,403 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SinkFactory creates a sink from string options, as read from a config file.
+type SinkFactory func(options map[string]string) (Sink, error)
+
+var (
+	factoriesMu   sync.RWMutex
+	sinkFactories = map[string]SinkFactory{}
+)
+
+// RegisterSink makes a sink type available by name to NewSink. It panics if the
+// name is registered twice or the factory is nil.
+func RegisterSink(name string, factory SinkFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("log: RegisterSink factory is nil")
+	}
+	if _, dup := sinkFactories[name]; dup {
+		panic("log: RegisterSink called twice for sink " + name)
+	}
+	sinkFactories[name] = factory
+}
+
+// NewSink creates a sink of a registered type. All built-in sinks configured by
+// plain values are registered, named after their type in lower case, with
+// options named after their config fields in snake case; lists and maps are
+// comma separated. Sinks that need a client or a callback can only be created
+// in code: Kafka, Kinesis, NATS, Pub/Sub, and Cloud Logging in API mode.
+func NewSink(name string, options map[string]string) (Sink, error) {
+	factoriesMu.RLock()
+	factory, ok := sinkFactories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type %q", name)
+	}
+	return factory(options)
+}
+
+// SinkTypes returns the names of the registered sink types, sorted.
+func SinkTypes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(sinkFactories))
+	for name := range sinkFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sinkOptions reads typed values from factory options, remembering the first
+// malformed one.
+type sinkOptions struct {
+	values map[string]string
+	err    error
+}
+
+func (o *sinkOptions) str(key string) string {
+	return o.values[key]
+}
+
+func (o *sinkOptions) bool(key string) bool {
+	v, ok := o.values[key]
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	o.fail(key, err)
+	return b
+}
+
+func (o *sinkOptions) int(key string) int {
+	v, ok := o.values[key]
+	if !ok {
+		return 0
+	}
+	i, err := strconv.Atoi(v)
+	o.fail(key, err)
+	return i
+}
+
+func (o *sinkOptions) duration(key string) time.Duration {
+	v, ok := o.values[key]
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	o.fail(key, err)
+	return d
+}
+
+func (o *sinkOptions) level(key string) Level {
+	v, ok := o.values[key]
+	if !ok {
+		return 0
+	}
+	l, err := logrus.ParseLevel(v)
+	o.fail(key, err)
+	return l
+}
+
+// list reads a comma separated list.
+func (o *sinkOptions) list(key string) []string {
+	v, ok := o.values[key]
+	if !ok || v == "" {
+		return nil
+	}
+	items := strings.Split(v, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// dict reads a comma separated list of key=value pairs.
+func (o *sinkOptions) dict(key string) map[string]string {
+	items := o.list(key)
+	if items == nil {
+		return nil
+	}
+	m := make(map[string]string, len(items))
+	for _, item := range items {
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			o.fail(key, fmt.Errorf("missing '=' in %q", item))
+			continue
+		}
+		m[item[:i]] = item[i+1:]
+	}
+	return m
+}
+
+func (o *sinkOptions) fail(key string, err error) {
+	if err != nil && o.err == nil {
+		o.err = fmt.Errorf("option %s: %w", key, err)
+	}
+}
+
+var facilityNames = map[string]Facility{
+	"user": FacilityUser, "mail": FacilityMail, "daemon": FacilityDaemon, "auth": FacilityAuth,
+	"syslog": FacilitySyslog, "lpr": FacilityLpr, "news": FacilityNews, "uucp": FacilityUucp,
+	"cron": FacilityCron, "authpriv": FacilityAuthPriv, "ftp": FacilityFtp,
+	"local0": FacilityLocal0, "local1": FacilityLocal1, "local2": FacilityLocal2, "local3": FacilityLocal3,
+	"local4": FacilityLocal4, "local5": FacilityLocal5, "local6": FacilityLocal6, "local7": FacilityLocal7,
+}
+
+func init() {
+	RegisterSink("syslog", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := SyslogConfig{
+			Network:          o.str("network"),
+			Addr:             o.str("addr"),
+			AppName:          o.str("app_name"),
+			Hostname:         o.str("hostname"),
+			StructuredDataID: o.str("structured_data_id"),
+			Timeout:          o.duration("timeout"),
+		}
+		if name := o.str("facility"); name != "" {
+			f, ok := facilityNames[strings.ToLower(name)]
+			if !ok {
+				o.fail("facility", fmt.Errorf("unknown facility %q", name))
+			}
+			cfg.Facility = f
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewSyslogSink(cfg)
+	})
+	RegisterSink("gelf", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := GELFConfig{
+			Network:   o.str("network"),
+			Addr:      o.str("addr"),
+			Host:      o.str("host"),
+			ChunkSize: o.int("chunk_size"),
+			Compress:  o.bool("compress"),
+			Timeout:   o.duration("timeout"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewGELFSink(cfg)
+	})
+	RegisterSink("loki", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := LokiConfig{
+			URL:           o.str("url"),
+			TenantID:      o.str("tenant_id"),
+			Labels:        o.dict("labels"),
+			LabelFields:   o.list("label_fields"),
+			BatchSize:     o.int("batch_size"),
+			FlushInterval: o.duration("flush_interval"),
+			MaxRetries:    o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewLokiSink(cfg), nil
+	})
+	RegisterSink("elasticsearch", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := ElasticsearchConfig{
+			URL:           o.str("url"),
+			Index:         o.str("index"),
+			Username:      o.str("username"),
+			Password:      o.str("password"),
+			APIKey:        o.str("api_key"),
+			BatchSize:     o.int("batch_size"),
+			FlushInterval: o.duration("flush_interval"),
+			QueueSize:     o.int("queue_size"),
+			MaxRetries:    o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewElasticsearchSink(cfg), nil
+	})
+	RegisterSink("splunk", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := SplunkConfig{
+			URL:           o.str("url"),
+			Token:         o.str("token"),
+			Index:         o.str("index"),
+			Source:        o.str("source"),
+			SourceType:    o.str("sourcetype"),
+			Host:          o.str("host"),
+			Gzip:          o.bool("gzip"),
+			BatchSize:     o.int("batch_size"),
+			FlushInterval: o.duration("flush_interval"),
+			QueueSize:     o.int("queue_size"),
+			MaxRetries:    o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewSplunkSink(cfg), nil
+	})
+	RegisterSink("datadog", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := DatadogConfig{
+			URL:           o.str("url"),
+			APIKey:        o.str("api_key"),
+			Service:       o.str("service"),
+			Source:        o.str("source"),
+			Tags:          o.list("tags"),
+			TagFields:     o.list("tag_fields"),
+			Hostname:      o.str("hostname"),
+			Gzip:          o.bool("gzip"),
+			BatchSize:     o.int("batch_size"),
+			FlushInterval: o.duration("flush_interval"),
+			QueueSize:     o.int("queue_size"),
+			MaxRetries:    o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewDatadogSink(cfg), nil
+	})
+	RegisterSink("webhook", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := WebhookConfig{
+			URL:           o.str("url"),
+			BatchSize:     o.int("batch_size"),
+			FlushInterval: o.duration("flush_interval"),
+			QueueSize:     o.int("queue_size"),
+			MaxRetries:    o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewWebhookSink(cfg), nil
+	})
+	RegisterSink("fluent", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := FluentConfig{
+			Network:       o.str("network"),
+			Addr:          o.str("addr"),
+			Tag:           o.str("tag"),
+			BatchSize:     o.int("batch_size"),
+			FlushInterval: o.duration("flush_interval"),
+			QueueSize:     o.int("queue_size"),
+			MaxRetries:    o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewFluentSink(cfg), nil
+	})
+	RegisterSink("otlp", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := OTLPConfig{
+			URL:                o.str("url"),
+			ServiceName:        o.str("service_name"),
+			ResourceAttributes: o.dict("resource_attributes"),
+			BatchSize:          o.int("batch_size"),
+			FlushInterval:      o.duration("flush_interval"),
+			QueueSize:          o.int("queue_size"),
+			MaxRetries:         o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewOTLPSink(cfg), nil
+	})
+	RegisterSink("sentry", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := SentryConfig{
+			DSN:         o.str("dsn"),
+			Environment: o.str("environment"),
+			Release:     o.str("release"),
+			ServerName:  o.str("server_name"),
+			TagFields:   o.list("tag_fields"),
+			MaxRetries:  o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewSentrySink(cfg)
+	})
+	RegisterSink("pagerduty", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := PagerDutyConfig{
+			RoutingKey:    o.str("routing_key"),
+			DedupKeyField: o.str("dedup_key_field"),
+			DetailFields:  o.list("detail_fields"),
+			Source:        o.str("source"),
+			Component:     o.str("component"),
+			Group:         o.str("group"),
+			MaxRetries:    o.int("max_retries"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewPagerDutySink(cfg), nil
+	})
+	RegisterSink("email", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := EmailConfig{
+			Addr:     o.str("addr"),
+			Username: o.str("username"),
+			Password: o.str("password"),
+			From:     o.str("from"),
+			To:       o.list("to"),
+			Subject:  o.str("subject"),
+			Interval: o.duration("interval"),
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewEmailSink(cfg), nil
+	})
+	RegisterSink("cloudlogging", func(options map[string]string) (Sink, error) {
+		o := &sinkOptions{values: options}
+		cfg := CloudLoggingConfig{
+			ProjectID: o.str("project_id"),
+			LogName:   o.str("log_name"),
+			Labels:    o.dict("labels"),
+		}
+		switch output := o.str("output"); output {
+		case "stdout":
+			cfg.Output = os.Stdout
+		case "stderr":
+			cfg.Output = os.Stderr
+		default:
+			o.fail("output", fmt.Errorf("want stdout or stderr, got %q; API mode is only available in code", output))
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return NewCloudLoggingSink(cfg), nil
+	})
+	for name, service := range map[string]NotifyService{"slack": Slack, "teams": Teams} {
+		service := service
+		RegisterSink(name, func(options map[string]string) (Sink, error) {
+			o := &sinkOptions{values: options}
+			cfg := NotifyConfig{
+				Service:    service,
+				URL:        o.str("url"),
+				Level:      o.level("level"),
+				Interval:   o.duration("interval"),
+				MaxRetries: o.int("max_retries"),
+			}
+			if o.err != nil {
+				return nil, o.err
+			}
+			return NewNotifySink(cfg), nil
+		})
+	}
+}
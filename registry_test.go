@@ -0,0 +1,58 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterSink(t *testing.T) {
+	RegisterSink("memory", func(options map[string]string) (Sink, error) {
+		return &memorySink{}, nil
+	})
+	defer func() {
+		factoriesMu.Lock()
+		delete(sinkFactories, "memory")
+		factoriesMu.Unlock()
+	}()
+	assert.Panics(t, func() {
+		RegisterSink("memory", func(options map[string]string) (Sink, error) { return nil, nil })
+	})
+	assert.Contains(t, SinkTypes(), "memory")
+	assert.Contains(t, SinkTypes(), "loki")
+
+	sink, err := NewSink("memory", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &memorySink{}, sink)
+
+	_, err = NewSink("unknown", nil)
+	assert.EqualError(t, err, `unknown sink type "unknown"`)
+}
+
+func TestNewSinkOptions(t *testing.T) {
+	sink, err := NewSink("loki", map[string]string{
+		"url":            "http://loki:3100",
+		"labels":         "service=api,env=prod",
+		"label_fields":   "level, tenant",
+		"flush_interval": "5s",
+	})
+	assert.NoError(t, err)
+	loki := sink.(*LokiSink)
+	assert.Equal(t, map[string]string{"service": "api", "env": "prod"}, loki.cfg.Labels)
+	assert.Equal(t, []string{"level", "tenant"}, loki.cfg.LabelFields)
+	assert.NoError(t, loki.Close())
+
+	sink, err = NewSink("email", map[string]string{"addr": "localhost:25", "to": "ops@example.com, dev@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, sink.(*EmailSink).cfg.To)
+	sink, err = NewSink("cloudlogging", map[string]string{"output": "stdout", "project_id": "p"})
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Close())
+
+	_, err = NewSink("cloudlogging", map[string]string{"project_id": "p"})
+	assert.Error(t, err)
+	_, err = NewSink("webhook", map[string]string{"batch_size": "many"})
+	assert.Error(t, err)
+	_, err = NewSink("slack", map[string]string{"level": "loud"})
+	assert.Error(t, err)
+}
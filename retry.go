@@ -0,0 +1,91 @@
+package log
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how often network sinks retry a failed delivery.
+	DefaultMaxRetries = 5
+
+	minRetryDelay = 100 * time.Millisecond
+	maxRetryDelay = 10 * time.Second
+)
+
+// retryable reports whether a failed delivery is worth repeating. Errors decide
+// for themselves by implementing Retryable, also when wrapped; anything else,
+// such as a transport error, is assumed to be transient.
+func retryable(err error) bool {
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	return true
+}
+
+// retryDelay is slept after the given failed attempt: exponential backoff capped
+// at maxRetryDelay, of which the upper half is randomized so that many senders
+// recovering from the same outage do not retry in lockstep.
+func retryDelay(attempt int) time.Duration {
+	delay := maxRetryDelay
+	if attempt < 16 {
+		if d := minRetryDelay << uint(attempt); d < maxRetryDelay {
+			delay = d
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+var sleep = time.Sleep
+
+// withRetries calls fn until it succeeds, fails permanently or has been retried
+// maxRetries times, backing off between attempts.
+func withRetries(maxRetries int, fn func() error) (err error) {
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil || !retryable(err) || attempt >= maxRetries {
+			return err
+		}
+		sleep(retryDelay(attempt))
+	}
+}
+
+// RetrySink retries failed writes of a sink that does not retry by itself, such
+// as the syslog or GELF sink. Retries happen in the logging goroutine, so
+// combine it with a sink that cannot block for long.
+type RetrySink struct {
+	sink       Sink
+	maxRetries int
+}
+
+// NewRetrySink wraps sink so that each write is retried up to maxRetries times.
+// A zero maxRetries means DefaultMaxRetries.
+func NewRetrySink(sink Sink, maxRetries int) *RetrySink {
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	return &RetrySink{sink: sink, maxRetries: maxRetries}
+}
+
+func (s *RetrySink) Write(entry *Entry) error {
+	return withRetries(s.maxRetries, func() error {
+		return s.sink.Write(entry)
+	})
+}
+
+func (s *RetrySink) Flush() error {
+	return withRetries(s.maxRetries, s.sink.Flush)
+}
+
+func (s *RetrySink) Close() error {
+	return s.sink.Close()
+}
+
+// Status reports the wrapped sink's status, if it has one.
+func (s *RetrySink) Status() SinkHealth {
+	if r, ok := s.sink.(StatusReporter); ok {
+		return r.Status()
+	}
+	return SinkHealth{}
+}
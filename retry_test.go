@@ -0,0 +1,60 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type flakySink struct {
+	memorySink
+	failures int
+}
+
+func (f *flakySink) Write(entry *Entry) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("unavailable")
+	}
+	return f.memorySink.Write(entry)
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		d := retryDelay(attempt)
+		assert.True(t, d >= want/2 && d <= want, "attempt %d: %v", attempt, d)
+	}
+	assert.True(t, retryDelay(100) <= maxRetryDelay)
+	assert.True(t, retryDelay(100) >= maxRetryDelay/2)
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, retryable(errors.New("connection refused")))
+	assert.True(t, retryable(&httpStatusError{code: 503}))
+	assert.False(t, retryable(&httpStatusError{code: 400}))
+	assert.False(t, retryable(fmt.Errorf("circuit opened: %w", &httpStatusError{code: 400})))
+}
+
+func TestRetrySink(t *testing.T) {
+	var delays []time.Duration
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	defer func() { sleep = time.Sleep }()
+
+	flaky := &flakySink{failures: 2}
+	sink := NewRetrySink(flaky, 0)
+	assert.NoError(t, sink.Write(logrus.NewEntry(logrus.New())))
+	assert.Len(t, flaky.entries, 1)
+	assert.Len(t, delays, 2)
+
+	flaky.failures = 5
+	delays = nil
+	assert.EqualError(t, NewRetrySink(flaky, 3).Write(logrus.NewEntry(logrus.New())), "unavailable")
+	assert.Len(t, delays, 3)
+
+	assert.NoError(t, sink.Close())
+	assert.True(t, flaky.closed)
+}
@@ -0,0 +1,220 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRotationSize is the size at which a RotatingFile is rotated.
+const DefaultRotationSize = 100 << 20
+
+// segmentTimeLayout names rotated segments so that they sort by age.
+const segmentTimeLayout = "20060102T150405.000000000"
+
+// RotationConfig configures a RotatingFile.
+type RotationConfig struct {
+	// Path is the file entries are written to. Rotated segments are kept
+	// beside it, named after it with the time of the rotation appended.
+	Path string
+	// MaxSize is the size in bytes at which the file is rotated; zero means
+	// DefaultRotationSize.
+	MaxSize int64
+	// MaxSegments is how many rotated segments are kept, the oldest being
+	// removed; zero keeps all.
+	MaxSegments int
+	// Compress gzips rotated segments in the background.
+	Compress bool
+	// Uncompressed is how many of the most recent segments are left
+	// uncompressed, for quick grepping, when Compress is set.
+	Uncompressed int
+}
+
+// RotatingFile is a log file that is rotated when it reaches a size: the
+// file is renamed to a segment and a new one started. Old segments are
+// compressed and removed in the background as configured. Pass it to
+// SetOutput and Close it on shutdown.
+type RotatingFile struct {
+	cfg  RotationConfig
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	tidyMu sync.Mutex // serializes tidy
+	wg     sync.WaitGroup
+}
+
+// OpenRotatingFile opens or creates the file at cfg.Path, appending to it.
+func OpenRotatingFile(cfg RotationConfig) (*RotatingFile, error) {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultRotationSize
+	}
+	f := &RotatingFile{cfg: cfg}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write writes p to the file, rotating it first if p would take it past the
+// maximum size. Entries are not split across segments.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.cfg.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate starts a new file regardless of the size of the current one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	segment := f.cfg.Path + "." + time.Now().UTC().Format(segmentTimeLayout)
+	if err := os.Rename(f.cfg.Path, segment); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.tidy()
+	}()
+	return nil
+}
+
+// Sync commits the file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file after waiting for the background compression of
+// segments.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	file := f.file
+	f.file = nil
+	f.mu.Unlock()
+	f.wg.Wait()
+	if file == nil {
+		return os.ErrClosed
+	}
+	return file.Close()
+}
+
+// segments returns the rotated segments, newest first.
+func (f *RotatingFile) segments() ([]string, error) {
+	matches, err := filepath.Glob(f.cfg.Path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	segments := matches[:0]
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, f.cfg.Path+"."), ".gz")
+		if _, err := time.Parse(segmentTimeLayout, stamp); err == nil {
+			segments = append(segments, m)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(segments)))
+	return segments, nil
+}
+
+// tidy removes the segments beyond MaxSegments and compresses those beyond
+// the uncompressed ones.
+func (f *RotatingFile) tidy() {
+	f.tidyMu.Lock()
+	defer f.tidyMu.Unlock()
+	segments, err := f.segments()
+	if err != nil {
+		reportError(err)
+		return
+	}
+	for i, s := range segments {
+		switch {
+		case f.cfg.MaxSegments > 0 && i >= f.cfg.MaxSegments:
+			err = os.Remove(s)
+		case f.cfg.Compress && i >= f.cfg.Uncompressed && !strings.HasSuffix(s, ".gz"):
+			err = compressFile(s)
+		default:
+			continue
+		}
+		if err != nil {
+			reportError(err)
+		}
+	}
+}
+
+// compressFile replaces the file at path with a gzipped copy at path.gz.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := path + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenRotatingFile(RotationConfig{Path: path, MaxSize: 10, MaxSegments: 3, Compress: true, Uncompressed: 1})
+	assert.NoError(t, err)
+	for _, line := range []string{"entry 1\n", "entry 2\n", "entry 3\n", "entry 4\n", "entry 5\n"} {
+		_, err = f.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+	_, err = f.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+
+	current, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "entry 5\n", string(current))
+	segments, err := f.segments()
+	assert.NoError(t, err)
+	assert.Len(t, segments, 3)
+	assert.False(t, strings.HasSuffix(segments[0], ".gz"), segments[0])
+	var contents []string
+	for i, s := range segments {
+		var data []byte
+		if i == 0 {
+			data, err = os.ReadFile(s)
+		} else {
+			assert.True(t, strings.HasSuffix(s, ".gz"), s)
+			data, err = readGzip(s)
+		}
+		assert.NoError(t, err)
+		contents = append(contents, string(data))
+	}
+	assert.Equal(t, []string{"entry 4\n", "entry 3\n", "entry 2\n"}, contents)
+
+	f, err = OpenRotatingFile(RotationConfig{Path: path, MaxSize: 10})
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("entry 6\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Rotate())
+	assert.NoError(t, f.Close())
+	segments, _ = f.segments()
+	assert.Len(t, segments, 5)
+}
+
+func readGzip(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
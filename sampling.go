@@ -0,0 +1,142 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SampleRateKey is the field recording the fraction an entry was sampled at,
+// so counts can be scaled back up.
+const SampleRateKey = "sample_rate"
+
+var random = rand.Float64
+
+// WithSampling emits only the given fraction of the entries of an Info, Debug
+// or Trace level, chosen at random; Warn and above are always kept. A fraction
+// of 1 or more turns sampling for the level off again.
+func WithSampling(level Level, fraction float64) Option {
+	return func(c *config) {
+		if level <= WarnLevel {
+			return
+		}
+		if fraction >= 1 {
+			delete(c.fractions, level)
+			return
+		}
+		if fraction < 0 {
+			fraction = 0
+		}
+		c.fractions[level] = fraction
+	}
+}
+
+// WithSampler limits the entries of a level per message and second: the first
+// entries pass, after that only every thereafter-th, so repetitive hot-path
+// logging does not dominate the volume. A thereafter of zero drops all entries
+// beyond the first; a first of zero and thereafter of zero removes the sampler.
+func WithSampler(level Level, first, thereafter int) Option {
+	return func(c *config) {
+		if first <= 0 && thereafter <= 0 {
+			delete(c.samplers, level)
+			return
+		}
+		c.samplers[level] = &sampler{first: first, thereafter: thereafter, now: time.Now}
+	}
+}
+
+// sampler counts entries per message within the current second.
+type sampler struct {
+	first      int
+	thereafter int
+	now        func() time.Time
+
+	mu     sync.Mutex
+	second int64
+	counts map[string]int
+}
+
+func (s *sampler) sample(msg string) bool {
+	now := s.now().Unix()
+	s.mu.Lock()
+	if now != s.second || s.counts == nil {
+		s.second = now
+		s.counts = map[string]int{}
+	}
+	n := s.counts[msg] + 1
+	s.counts[msg] = n
+	s.mu.Unlock()
+	if n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
+
+// minAdaptiveFraction is the smallest fraction adaptive sampling keeps.
+const minAdaptiveFraction = 0.001
+
+// WithAdaptiveSampling keeps the volume near budget bytes per second, as
+// estimated from messages and fields: each second the fraction of Info, Debug
+// and Trace entries kept shrinks in proportion when the budget was exceeded,
+// and doubles again while the volume stays below half of it. Warn and above
+// are always kept but count towards the budget. Zero turns it off.
+func WithAdaptiveSampling(budget int) Option {
+	return func(c *config) {
+		if budget <= 0 {
+			c.adaptive = nil
+			return
+		}
+		c.adaptive = &adaptiveSampler{budget: float64(budget), fraction: 1, now: time.Now}
+	}
+}
+
+type adaptiveSampler struct {
+	budget float64
+	now    func() time.Time
+
+	mu       sync.Mutex
+	second   int64
+	bytes    int
+	fraction float64
+}
+
+// current returns the fraction of entries to keep in this second.
+func (a *adaptiveSampler) current() float64 {
+	now := a.now().Unix()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if now != a.second {
+		if a.second != 0 {
+			a.adjust(float64(a.bytes) / float64(now-a.second))
+		}
+		a.second = now
+		a.bytes = 0
+	}
+	return a.fraction
+}
+
+func (a *adaptiveSampler) adjust(rate float64) {
+	switch {
+	case rate > a.budget:
+		a.fraction = math.Max(minAdaptiveFraction, a.fraction*a.budget/rate)
+	case rate < a.budget/2:
+		a.fraction = math.Min(1, a.fraction*2)
+	}
+}
+
+func (a *adaptiveSampler) add(entry *Entry, msg string) {
+	n := len(msg)
+	for k, v := range entry.Data {
+		n += len(k)
+		if s, ok := v.(string); ok {
+			n += len(s)
+		} else {
+			n += len(fmt.Sprint(v))
+		}
+	}
+	a.mu.Lock()
+	a.bytes += n
+	a.mu.Unlock()
+}
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	now := time.Unix(100, 0)
+	s := &sampler{first: 2, thereafter: 3, now: func() time.Time { return now }}
+	var passed []int
+	for i := 1; i <= 10; i++ {
+		if s.sample("hot") {
+			passed = append(passed, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, passed)
+	assert.True(t, s.sample("other"))
+
+	now = now.Add(time.Second)
+	assert.True(t, s.sample("hot"))
+}
+
+func TestWithSampler(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	SetDropSummaryInterval(0)
+	defer SetDropSummaryInterval(DefaultDropSummaryInterval)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithSampler(InfoLevel, 1, 0))
+	defer Configure(WithSampler(InfoLevel, 0, 0))
+
+	total := DroppedEntries()
+	for i := 0; i < 5; i++ {
+		Info(context.Background(), "hot path")
+		Warn(context.Background(), "not sampled")
+	}
+	assert.Len(t, sink.entries, 6)
+	assert.Equal(t, total+4, DroppedEntries())
+
+	Configure(WithSampler(InfoLevel, 0, 0))
+	Info(context.Background(), "hot path")
+	assert.Len(t, sink.entries, 7)
+}
+
+func TestWithSampling(t *testing.T) {
+	Init(JSONFormatter, DebugLevel)
+	defer Init(JSONFormatter, InfoLevel)
+	SetDropSummaryInterval(0)
+	defer SetDropSummaryInterval(DefaultDropSummaryInterval)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	rolls := []float64{0.1, 0.5, 0.9}
+	random = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+	defer func() { random = rand.Float64 }()
+	Configure(WithSampling(DebugLevel, 0.5), WithSampling(WarnLevel, 0.5))
+	defer Configure(WithSampling(DebugLevel, 1))
+
+	for i := 0; i < 3; i++ {
+		Debug(context.Background(), "sampled")
+	}
+	Warn(context.Background(), "always kept")
+	assert.Len(t, sink.entries, 2)
+	assert.Equal(t, 0.5, sink.entries[0].Data[SampleRateKey])
+	assert.Nil(t, sink.entries[1].Data[SampleRateKey])
+}
+
+func TestAdaptiveSampler(t *testing.T) {
+	now := time.Unix(100, 0)
+	a := &adaptiveSampler{budget: 100, fraction: 1, now: func() time.Time { return now }}
+	entry := logger.WithField("k", "v")
+	msg := string(make([]byte, 48))
+
+	assert.Equal(t, 1.0, a.current())
+	for i := 0; i < 8; i++ {
+		a.add(entry, msg) // 50 bytes each
+	}
+	now = now.Add(time.Second)
+	assert.Equal(t, 0.25, a.current())
+
+	a.add(entry, msg)
+	now = now.Add(time.Second)
+	assert.Equal(t, 0.25, a.current())
+	now = now.Add(time.Second)
+	assert.Equal(t, 0.5, a.current())
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, 1.0, a.current())
+}
+
+func TestWithAdaptiveSampling(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	SetDropSummaryInterval(0)
+	defer SetDropSummaryInterval(DefaultDropSummaryInterval)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithAdaptiveSampling(1), WithSampling(InfoLevel, 0.5))
+	defer Configure(WithAdaptiveSampling(0), WithSampling(InfoLevel, 1))
+	a := currentConfig().adaptive
+	a.fraction = 0.5
+	random = func() float64 { return 0.2 }
+	defer func() { random = rand.Float64 }()
+
+	Info(context.Background(), "kept")
+	Warn(context.Background(), "always kept")
+	random = func() float64 { return 0.3 }
+	Info(context.Background(), "dropped")
+	assert.Len(t, sink.entries, 2)
+	assert.Equal(t, 0.25, sink.entries[0].Data[SampleRateKey])
+	assert.NotContains(t, sink.entries[1].Data, SampleRateKey)
+}
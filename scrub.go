@@ -0,0 +1,60 @@
+package log
+
+import (
+	"regexp"
+)
+
+// ScrubbedValue replaces the matches of scrubbing patterns.
+const ScrubbedValue = "[SCRUBBED]"
+
+// Patterns for common credentials, scrubbed by WithScrubbing by default.
+var (
+	BearerTokenPattern  = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`)
+	AWSAccessKeyPattern = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)
+	AWSSecretKeyPattern = regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?[a-z0-9/+=]{40}`)
+	JWTPattern          = regexp.MustCompile(`\beyJ[a-zA-Z0-9_-]+\.eyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]*`)
+)
+
+// WithScrubbing replaces the matches of the patterns in messages and string
+// field values, nested ones included, with ScrubbedValue before entries are
+// recorded, formatted or handed to sinks. Without patterns it scrubs bearer
+// tokens, AWS keys and JWTs.
+func WithScrubbing(patterns ...*regexp.Regexp) Option {
+	if len(patterns) == 0 {
+		patterns = []*regexp.Regexp{BearerTokenPattern, AWSAccessKeyPattern, AWSSecretKeyPattern, JWTPattern}
+	}
+	scrubbers := make([]textScrubber, len(patterns))
+	for i, p := range patterns {
+		scrubbers[i] = textScrubber{pattern: p}
+	}
+	return withTextScrubbers(scrubbers...)
+}
+
+// textScrubber replaces matches of a pattern, with ScrubbedValue if replace
+// is nil.
+type textScrubber struct {
+	pattern *regexp.Regexp
+	replace func(match string) string
+}
+
+func withTextScrubbers(scrubbers ...textScrubber) Option {
+	return func(c *config) {
+		c.scrubbers = append(c.scrubbers[:len(c.scrubbers):len(c.scrubbers)], scrubbers...)
+	}
+}
+
+// scrubText returns s with the matches of the scrubbing patterns replaced.
+func (c *config) scrubText(s string) (string, bool) {
+	scrubbed := s
+	for _, sc := range c.scrubbers {
+		if !sc.pattern.MatchString(scrubbed) {
+			continue
+		}
+		if sc.replace == nil {
+			scrubbed = sc.pattern.ReplaceAllLiteralString(scrubbed, ScrubbedValue)
+		} else {
+			scrubbed = sc.pattern.ReplaceAllStringFunc(scrubbed, sc.replace)
+		}
+	}
+	return scrubbed, scrubbed != s
+}
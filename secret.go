@@ -0,0 +1,76 @@
+package log
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretValue replaces known secrets.
+const SecretValue = "[SECRET]"
+
+// minEnvSecretLen is the shortest value WithSecretsFromEnv takes from variables
+// found by name, to skip flags such as "true".
+const minEnvSecretLen = 6
+
+// secretEnvSuffixes are the names of variables WithSecretsFromEnv loads by
+// default.
+var secretEnvSuffixes = []string{"SECRET", "TOKEN", "PASSWORD", "PASSWD", "API_KEY", "PRIVATE_KEY", "CREDENTIALS"}
+
+// WithSecretValues guarantees that the given strings, such as passwords and
+// API keys the service was configured with, never appear in messages or string
+// field values: wherever they occur they are replaced with SecretValue.
+func WithSecretValues(vals ...string) Option {
+	var alternatives []string
+	seen := map[string]bool{}
+	for _, v := range vals {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		alternatives = append(alternatives, regexp.QuoteMeta(v))
+		// as it appears in JSON, where scrubbing looks for it in other values
+		if quoted := string(appendJSONString(nil, v)); quoted[1:len(quoted)-1] != v {
+			alternatives = append(alternatives, regexp.QuoteMeta(quoted[1:len(quoted)-1]))
+		}
+	}
+	if len(alternatives) == 0 {
+		return func(*config) {}
+	}
+	// longest first, so a secret containing another is replaced whole
+	sort.SliceStable(alternatives, func(i, j int) bool { return len(alternatives[i]) > len(alternatives[j]) })
+	pattern := regexp.MustCompile(strings.Join(alternatives, "|"))
+	return withTextScrubbers(textScrubber{pattern: pattern, replace: func(string) string { return SecretValue }})
+}
+
+// WithSecretsFromEnv is WithSecretValues for the values of the named
+// environment variables. Without names it takes the variables whose names end
+// in SECRET, TOKEN, PASSWORD, PASSWD, API_KEY, PRIVATE_KEY or CREDENTIALS,
+// ignoring values shorter than six characters.
+func WithSecretsFromEnv(names ...string) Option {
+	var vals []string
+	if len(names) > 0 {
+		for _, name := range names {
+			vals = append(vals, os.Getenv(name))
+		}
+		return WithSecretValues(vals...)
+	}
+	for _, kv := range os.Environ() {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+		name, val := strings.ToUpper(kv[:i]), kv[i+1:]
+		if len(val) < minEnvSecretLen {
+			continue
+		}
+		for _, suffix := range secretEnvSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				vals = append(vals, val)
+				break
+			}
+		}
+	}
+	return WithSecretValues(vals...)
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type secretConfig struct {
+	DSN string
+}
+
+func TestWithSecretValues(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(func(c *config) { c.scrubbers = nil })
+	Configure(WithSecretValues("s3cr3t", "s3cr3t-extended", `quo"te`, ""))
+
+	Infof(context.Background(), "connecting with s3cr3t-extended and s3cr3t")
+	Info(context.Background(), "config",
+		Field("password", "xs3cr3tx"),
+		Field("config", &secretConfig{DSN: `user:quo"te@db`}),
+	)
+	assert.Equal(t, "connecting with [SECRET] and [SECRET]", sink.entries[0].Message)
+	assert.Equal(t, "x[SECRET]x", sink.entries[1].Data["password"])
+	assert.Equal(t, map[string]interface{}{"DSN": "user:[SECRET]@db"}, sink.entries[1].Data["config"])
+}
+
+func TestWithSecretsFromEnv(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(func(c *config) { c.scrubbers = nil })
+	for k, v := range map[string]string{"TEST_DB_PASSWORD": "hunter22", "TEST_API_TOKEN": "tok", "TEST_CUSTOM": "custom-value"} {
+		t.Setenv(k, v)
+	}
+
+	Configure(WithSecretsFromEnv())
+	Info(context.Background(), "hunter22 tok custom-value")
+	Configure(WithSecretsFromEnv("TEST_CUSTOM"))
+	Info(context.Background(), "hunter22 tok custom-value")
+	assert.Equal(t, "[SECRET] tok custom-value", sink.entries[0].Message)
+	assert.Equal(t, "[SECRET] tok [SECRET]", sink.entries[1].Message)
+}
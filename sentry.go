@@ -0,0 +1,202 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const sentryMaxFrames = 50
+
+// SentryConfig configures a Sentry sink.
+type SentryConfig struct {
+	// DSN is the project's client key, e.g. https://key@o1.ingest.sentry.io/42.
+	DSN string
+	// Environment and Release are attached to every event when not empty.
+	Environment string
+	Release     string
+	// ServerName defaults to os.Hostname.
+	ServerName string
+	// TagFields names entry fields sent as tags. All other fields are sent as
+	// extras.
+	TagFields []string
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// SentrySink converts Error, Fatal and Panic entries into Sentry events with a
+// stack trace of the logging call. Lower levels are ignored. Events are sent in
+// the background, except for Fatal and Panic entries, which are sent together
+// with anything pending before the entry is written so the process cannot exit
+// first.
+type SentrySink struct {
+	cfg     SentryConfig
+	url     string
+	header  http.Header
+	tagKeys map[string]bool
+	batcher *batcher
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Message     string                 `json:"message"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Threads     []sentryThread         `json:"threads,omitempty"`
+}
+
+type sentryThread struct {
+	Current    bool `json:"current"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+// NewSentrySink creates a Sentry sink for the given DSN.
+func NewSentrySink(cfg SentryConfig) (*SentrySink, error) {
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if dsn.User == nil || dsn.User.Username() == "" {
+		return nil, errors.New("sentry dsn without public key")
+	}
+	i := strings.LastIndexByte(dsn.Path, '/')
+	if i < 0 || i == len(dsn.Path)-1 {
+		return nil, errors.New("sentry dsn without project id")
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName, _ = os.Hostname()
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+
+	s := &SentrySink{
+		cfg: cfg,
+		url: dsn.Scheme + "://" + dsn.Host + dsn.Path[:i] + "/api/" + dsn.Path[i+1:] + "/store/",
+		header: http.Header{
+			"Content-Type":  {"application/json"},
+			"X-Sentry-Auth": {"Sentry sentry_version=7, sentry_client=go-log/1.0, sentry_key=" + dsn.User.Username()},
+		},
+		tagKeys: map[string]bool{},
+	}
+	for _, k := range cfg.TagFields {
+		s.tagKeys[k] = true
+	}
+	s.batcher = newBatcher(0, 0, DefaultQueueSize, nil, s.send)
+	return s, nil
+}
+
+func (s *SentrySink) Write(entry *Entry) error {
+	if entry.Level > ErrorLevel {
+		return nil
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	event := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   entry.Time.UTC().Format(time.RFC3339Nano),
+		Level:       entry.Level.String(),
+		Platform:    "go",
+		Logger:      "go-log",
+		ServerName:  s.cfg.ServerName,
+		Environment: s.cfg.Environment,
+		Release:     s.cfg.Release,
+		Message:     entry.Message,
+	}
+	for k, v := range entry.Data {
+		if s.tagKeys[k] {
+			if event.Tags == nil {
+				event.Tags = map[string]string{}
+			}
+			event.Tags[k] = stringValue(v)
+		} else {
+			if event.Extra == nil {
+				event.Extra = map[string]interface{}{}
+			}
+			event.Extra[k] = v
+		}
+	}
+	thread := sentryThread{Current: true}
+	thread.Stacktrace.Frames = sentryFrames(entry)
+	event.Threads = []sentryThread{thread}
+
+	data, err := json.Marshal(&event)
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{time: entry.Time, data: data})
+	if entry.Level <= FatalLevel {
+		return s.batcher.flush()
+	}
+	return nil
+}
+
+func (s *SentrySink) send(items []batchItem) error {
+	for _, item := range items {
+		if err := post(s.cfg.Client, s.url, s.header, item.data, s.cfg.MaxRetries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SentrySink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *SentrySink) Close() error {
+	return s.batcher.close()
+}
+
+// sentryFrames returns the stack of the logging call, oldest frame first as
+// Sentry expects.
+func sentryFrames(entry *Entry) []sentryFrame {
+	stack := loggingStack(entry, 1, sentryMaxFrames)
+	frames := make([]sentryFrame, len(stack))
+	for i, f := range stack {
+		module, function := splitFunctionName(f.Function)
+		frames[len(stack)-1-i] = sentryFrame{
+			Function: function,
+			Module:   module,
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    inApp(module),
+		}
+	}
+	return frames
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *SentrySink) Status() SinkHealth {
+	return s.batcher.status()
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentrySink(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []sentryEvent
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/api/42/store/", r.URL.Path)
+		assert.Contains(t, r.Header.Get("X-Sentry-Auth"), "sentry_key=public")
+		var event sentryEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+	}))
+	defer srv.Close()
+
+	sink, err := NewSentrySink(SentryConfig{
+		DSN:         strings.Replace(srv.URL, "://", "://public@", 1) + "/42",
+		Environment: "test",
+		TagFields:   []string{"tenant"},
+	})
+	assert.NoError(t, err)
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("sentry", sink)
+	ctx := context.Background()
+	Warn(ctx, "Warning Message 1")
+	Error(ctx, "Error Message 1", Field("tenant", "acme"), Field("field1", "value1"))
+	assert.NoError(t, RemoveSink("sentry"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, events, 1)
+	event := events[0]
+	assert.Len(t, event.EventID, 32)
+	assert.Equal(t, "error", event.Level)
+	assert.Equal(t, "test", event.Environment)
+	assert.Equal(t, "Error Message 1", event.Message)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, event.Tags)
+	assert.Equal(t, map[string]interface{}{"field1": "value1"}, event.Extra)
+	frames := event.Threads[0].Stacktrace.Frames
+	assert.NotEmpty(t, frames)
+	assert.Equal(t, "tRunner", frames[len(frames)-1].Function)
+}
+
+func TestSentryDSN(t *testing.T) {
+	_, err := NewSentrySink(SentryConfig{DSN: "https://o1.ingest.sentry.io/42"})
+	assert.Error(t, err)
+	_, err = NewSentrySink(SentryConfig{DSN: "https://key@o1.ingest.sentry.io/"})
+	assert.Error(t, err)
+}
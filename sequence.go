@@ -0,0 +1,22 @@
+package log
+
+import "sync/atomic"
+
+// SequenceKey is the field holding the sequence number of an entry.
+const SequenceKey = "seq"
+
+var sequence uint64 // accessed atomically
+
+// WithSequenceNumbers numbers the entries written, starting at 1, in the
+// order they were logged, so entries reordered by async sinks or collectors
+// can be put back in order and missing ones noticed. Entries dropped by
+// sampling, rate limits or deduplication are not numbered.
+func WithSequenceNumbers() Option {
+	return func(c *config) {
+		c.sequence = true
+	}
+}
+
+func nextSequence() uint64 {
+	return atomic.AddUint64(&sequence, 1)
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSequenceNumbers(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+
+	Info(ctx, "Informational Message 1")
+	assert.NotContains(t, sink.entries[0].Data, SequenceKey)
+
+	Configure(WithSequenceNumbers())
+	defer Configure(func(c *config) { c.sequence = false })
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Info(ctx, "Informational Message 2")
+		}()
+	}
+	wg.Wait()
+	Debug(ctx, "Debug Message 1")
+	Info(ctx, "Informational Message 3")
+
+	assert.Len(t, sink.entries, 12)
+	seen := map[uint64]bool{}
+	for _, e := range sink.entries[1:] {
+		seen[e.Data[SequenceKey].(uint64)] = true
+	}
+	assert.Len(t, seen, 11)
+	last := sink.entries[11].Data[SequenceKey].(uint64)
+	for n := last - 10; n <= last; n++ {
+		assert.True(t, seen[n], n)
+	}
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is a single log entry as handed to sinks and formatters.
+type Entry = logrus.Entry
+
+// Sink receives every entry emitted through the package logger in addition to
+// the primary output.
+type Sink interface {
+	// Write delivers a single entry. The entry must not be retained after Write
+	// returns; sinks that buffer should copy what they need.
+	Write(entry *Entry) error
+	// Flush delivers anything the sink has buffered.
+	Flush() error
+	// Close flushes and releases the sink's resources.
+	Close() error
+}
+
+type sinkHook struct {
+	dropped uint64 // accessed atomically, first for 64-bit alignment
+	name    string
+	sink    Sink
+	levels  []Level
+
+	mu        sync.Mutex
+	lastErr   error
+	lastErrAt time.Time
+}
+
+func (h *sinkHook) Levels() []Level {
+	return h.levels
+}
+
+func (h *sinkHook) Fire(entry *Entry) error {
+	if err := h.sink.Write(entry); err != nil {
+		h.mu.Lock()
+		h.lastErr, h.lastErrAt = err, time.Now()
+		h.mu.Unlock()
+		atomic.AddUint64(&h.dropped, 1)
+		reportError(fmt.Errorf("sink %s: %w", h.name, err))
+	}
+	return nil
+}
+
+var (
+	sinksMu      sync.Mutex
+	sinks        []*sinkHook
+	errorHandler = func(err error) {
+		fmt.Fprintf(os.Stderr, "log: %v\n", err)
+	}
+)
+
+// AddSink attaches a sink under the given name. The sink receives entries at the
+// given levels, or at every level if none are given.
+func AddSink(name string, sink Sink, levels ...Level) {
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = append(sinks, &sinkHook{name: name, sink: sink, levels: levels})
+	replaceHooks()
+}
+
+// RemoveSink detaches the named sink and closes it.
+func RemoveSink(name string) error {
+	sinksMu.Lock()
+	var removed []*sinkHook
+	kept := sinks[:0]
+	for _, h := range sinks {
+		if h.name == name {
+			removed = append(removed, h)
+		} else {
+			kept = append(kept, h)
+		}
+	}
+	sinks = kept
+	replaceHooks()
+	sinksMu.Unlock()
+
+	var err error
+	for _, h := range removed {
+		if cerr := h.sink.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func replaceHooks() {
+	hooks := make(logrus.LevelHooks)
+	hooks.Add(callerHook{})
+	for _, h := range sinks {
+		hooks.Add(h)
+	}
+	logger.ReplaceHooks(hooks)
+}
+
+// SetErrorHandler sets the function called when the logger itself fails, for
+// example when a sink cannot deliver an entry. By default errors are printed to
+// stderr.
+func SetErrorHandler(handler func(err error)) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	errorHandler = handler
+}
+
+func reportError(err error) {
+	sinksMu.Lock()
+	handler := errorHandler
+	sinksMu.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+func stringValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return jsonString(v)
+}
+
+// templateValue resolves a placeholder of a sink name template: the entry level
+// for "level", otherwise the entry field of that name.
+func templateValue(entry *Entry, name string) (string, bool) {
+	if name == "level" {
+		return entry.Level.String(), true
+	}
+	v, ok := entry.Data[name]
+	if !ok {
+		return "", false
+	}
+	return stringValue(v), true
+}
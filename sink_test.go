@@ -0,0 +1,74 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	entries []*Entry
+	err     error
+	closed  bool
+}
+
+func (m *memorySink) Write(entry *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry.Dup())
+	m.entries[len(m.entries)-1].Message = entry.Message
+	m.entries[len(m.entries)-1].Level = entry.Level
+	m.entries[len(m.entries)-1].Caller = entry.Caller
+	return m.err
+}
+
+func (m *memorySink) Flush() error {
+	return nil
+}
+
+func (m *memorySink) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestSinks(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, DebugLevel)
+
+	all := &memorySink{}
+	errs := &memorySink{}
+	AddSink("all", all)
+	AddSink("errors", errs, ErrorLevel)
+
+	Info(ctx, "Informational Message 1", Field("field1", "value1"))
+	Error(ctx, "Error Message 1")
+
+	assert.Len(t, all.entries, 2)
+	assert.Equal(t, "value1", all.entries[0].Data["field1"])
+	assert.Len(t, errs.entries, 1)
+	assert.Equal(t, "Error Message 1", errs.entries[0].Message)
+
+	assert.NoError(t, RemoveSink("all"))
+	assert.NoError(t, RemoveSink("errors"))
+	assert.True(t, all.closed)
+	Error(ctx, "Error Message 2")
+	assert.Len(t, all.entries, 2)
+}
+
+func TestSinkErrorHandler(t *testing.T) {
+	var reported []error
+	SetErrorHandler(func(err error) { reported = append(reported, err) })
+	defer SetErrorHandler(nil)
+
+	Init(JSONFormatter, DebugLevel)
+	AddSink("failing", &memorySink{err: errors.New("boom")})
+	defer func() { _ = RemoveSink("failing") }()
+
+	Info(context.Background(), "Informational Message 1")
+	assert.Len(t, reported, 1)
+	assert.EqualError(t, reported[0], "sink failing: boom")
+}
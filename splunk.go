@@ -0,0 +1,142 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SplunkConfig configures a Splunk HTTP Event Collector sink.
+type SplunkConfig struct {
+	// URL is the HEC base URL, e.g. https://splunk:8088.
+	URL string
+	// Token is the HEC token.
+	Token string
+	// Index, Source and SourceType are set on every event when not empty.
+	Index      string
+	Source     string
+	SourceType string
+	// Host defaults to os.Hostname.
+	Host string
+	// Gzip compresses request bodies.
+	Gzip bool
+	// Formatter renders events and defaults to JSON.
+	Formatter logrus.Formatter
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// SplunkSink batches entries and sends them to a Splunk HTTP Event Collector.
+type SplunkSink struct {
+	cfg     SplunkConfig
+	url     string
+	header  http.Header
+	batcher *batcher
+}
+
+type splunkEvent struct {
+	Time       float64         `json:"time"`
+	Host       string          `json:"host,omitempty"`
+	Index      string          `json:"index,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	SourceType string          `json:"sourcetype,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// NewSplunkSink creates a Splunk HEC sink.
+func NewSplunkSink(cfg SplunkConfig) *SplunkSink {
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+	if cfg.Formatter == nil {
+		cfg.Formatter = new(logrus.JSONFormatter)
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+
+	header := http.Header{
+		"Authorization": {"Splunk " + cfg.Token},
+		"Content-Type":  {"application/json"},
+	}
+	if cfg.Gzip {
+		header.Set("Content-Encoding", "gzip")
+	}
+	s := &SplunkSink{cfg: cfg, url: strings.TrimSuffix(cfg.URL, "/") + "/services/collector/event", header: header}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *SplunkSink) Write(entry *Entry) error {
+	data, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	data = bytes.TrimRight(data, "\n")
+	if !json.Valid(data) {
+		data = []byte(jsonString(string(data)))
+	}
+	event, err := json.Marshal(&splunkEvent{
+		Time:       float64(entry.Time.UnixNano()/int64(time.Millisecond)) / 1e3,
+		Host:       s.cfg.Host,
+		Index:      s.cfg.Index,
+		Source:     s.cfg.Source,
+		SourceType: s.cfg.SourceType,
+		Event:      data,
+	})
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{time: entry.Time, data: event})
+	return nil
+}
+
+// send posts the events concatenated, as HEC expects for batches.
+func (s *SplunkSink) send(items []batchItem) (err error) {
+	body := bytes.Buffer{}
+	for _, item := range items {
+		body.Write(item.data)
+		body.WriteByte('\n')
+	}
+	data := body.Bytes()
+	if s.cfg.Gzip {
+		if data, err = gzipBytes(data); err != nil {
+			return err
+		}
+	}
+	return post(s.cfg.Client, s.url, s.header, data, s.cfg.MaxRetries)
+}
+
+func (s *SplunkSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *SplunkSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *SplunkSink) Status() SinkHealth {
+	return s.batcher.status()
+}
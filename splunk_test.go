@@ -0,0 +1,53 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplunkSink(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/services/collector/event", r.URL.Path)
+		assert.Equal(t, "Splunk token", r.Header.Get("Authorization"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		zr, err := gzip.NewReader(r.Body)
+		assert.NoError(t, err)
+		dec := json.NewDecoder(zr)
+		for dec.More() {
+			var event map[string]interface{}
+			assert.NoError(t, dec.Decode(&event))
+			events = append(events, event)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewSplunkSink(SplunkConfig{URL: srv.URL, Token: "token", Index: "main", SourceType: "_json", Gzip: true})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("splunk", sink)
+	Info(context.Background(), "Informational Message 1", Field("field1", "value1"))
+	Warn(context.Background(), "Warning Message 1")
+	assert.NoError(t, RemoveSink("splunk"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, events, 2)
+	assert.Equal(t, "main", events[0]["index"])
+	assert.Equal(t, "_json", events[0]["sourcetype"])
+	event := events[0]["event"].(map[string]interface{})
+	assert.Equal(t, "Informational Message 1", event["msg"])
+	assert.Equal(t, "value1", event["field1"])
+}
@@ -0,0 +1,176 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSpoolSize caps the size of a spool file.
+const DefaultSpoolSize = 64 << 20
+
+// spoolMagic starts every spool record, so that reading can resynchronize after
+// a damaged record.
+var spoolMagic = []byte("LGSP")
+
+const spoolHeaderSize = 12 // magic, payload length, payload CRC-32
+
+// errSpoolFull is returned when a batch does not fit into the spool.
+var errSpoolFull = errors.New("spool full")
+
+// Spool is an on-disk queue for batches a sink failed to deliver. Set it in the
+// config of a batching sink: the sink then writes undeliverable batches to the
+// spool instead of dropping them, and resends them before anything else once
+// delivery succeeds again, including after a restart. Each sink needs its own
+// spool, as records are stored in the sink's wire encoding.
+//
+// Records carry a checksum; damaged or truncated records, e.g. from a crash
+// while writing, are skipped.
+type Spool struct {
+	dropped  uint64 // accessed atomically, first for 64-bit alignment
+	path     string
+	maxBytes int64
+	mu       sync.Mutex
+	size     int64
+}
+
+// OpenSpool opens or creates the spool file at path. Once the file has reached
+// maxBytes further batches are dropped; zero means DefaultSpoolSize.
+func OpenSpool(path string, maxBytes int64) (*Spool, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultSpoolSize
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &Spool{path: path, maxBytes: maxBytes, size: info.Size()}, nil
+}
+
+// Dropped returns how many entries were dropped because the spool was full.
+func (s *Spool) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Size returns the size of the spool file in bytes.
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func (s *Spool) append(items []batchItem) error {
+	buf := bytes.Buffer{}
+	for _, item := range items {
+		payload := make([]byte, 0, 10+len(item.key)+len(item.data))
+		payload = appendUint16(payload, uint16(len(item.key)))
+		payload = append(payload, item.key...)
+		payload = appendUint64(payload, uint64(item.time.UnixNano()))
+		payload = append(payload, item.data...)
+
+		buf.Write(spoolMagic)
+		var header [8]byte
+		binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+		buf.Write(header[:])
+		buf.Write(payload)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size+int64(buf.Len()) > s.maxBytes {
+		atomic.AddUint64(&s.dropped, uint64(len(items)))
+		return errSpoolFull
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	n, err := f.Write(buf.Bytes())
+	s.size += int64(n)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// load reads every intact record.
+func (s *Spool) load() ([]batchItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size == 0 {
+		return nil, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSpool(data), nil
+}
+
+// replace rewrites the spool with the given items.
+func (s *Spool) replace(items []batchItem) error {
+	s.mu.Lock()
+	err := os.Truncate(s.path, 0)
+	if err == nil {
+		s.size = 0
+	}
+	s.mu.Unlock()
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	return s.append(items)
+}
+
+func decodeSpool(data []byte) []batchItem {
+	var items []batchItem
+	for len(data) > 0 {
+		i := bytes.Index(data, spoolMagic)
+		if i < 0 {
+			break
+		}
+		data = data[i:]
+		item, n, ok := decodeSpoolRecord(data)
+		if !ok {
+			data = data[len(spoolMagic):]
+			continue
+		}
+		items = append(items, item)
+		data = data[n:]
+	}
+	return items
+}
+
+func decodeSpoolRecord(data []byte) (batchItem, int, bool) {
+	if len(data) < spoolHeaderSize {
+		return batchItem{}, 0, false
+	}
+	n := int(binary.BigEndian.Uint32(data[4:8]))
+	if n < 10 || n > len(data)-spoolHeaderSize {
+		return batchItem{}, 0, false
+	}
+	payload := data[spoolHeaderSize : spoolHeaderSize+n]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[8:12]) {
+		return batchItem{}, 0, false
+	}
+	keyLen := int(binary.BigEndian.Uint16(payload))
+	if 2+keyLen+8 > n {
+		return batchItem{}, 0, false
+	}
+	item := batchItem{
+		key:  string(payload[2 : 2+keyLen]),
+		time: time.Unix(0, int64(binary.BigEndian.Uint64(payload[2+keyLen:]))),
+		data: payload[2+keyLen+8:],
+	}
+	return item, spoolHeaderSize + n, true
+}
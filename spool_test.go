@@ -0,0 +1,62 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+	spool, err := OpenSpool(path, 0)
+	assert.NoError(t, err)
+	now := time.Unix(1622548800, 123)
+	assert.NoError(t, spool.append([]batchItem{
+		{key: "k1", time: now, data: []byte("first")},
+		{key: "k2", time: now, data: []byte("second")},
+	}))
+
+	reopened, err := OpenSpool(path, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, spool.Size(), reopened.Size())
+	items, err := reopened.load()
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "k2", items[1].key)
+	assert.Equal(t, "second", string(items[1].data))
+	assert.True(t, now.Equal(items[1].time))
+
+	assert.NoError(t, reopened.replace(items[1:]))
+	items, _ = reopened.load()
+	assert.Len(t, items, 1)
+	assert.Equal(t, "second", string(items[0].data))
+}
+
+func TestSpoolCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+	spool, _ := OpenSpool(path, 0)
+	assert.NoError(t, spool.append([]batchItem{{data: []byte("first")}, {data: []byte("second")}, {data: []byte("third")}}))
+
+	data, _ := os.ReadFile(path)
+	data[spoolHeaderSize+12] ^= 0xff            // damage the first payload
+	data = append(data, spoolMagic...)          // and leave a truncated record
+	data = append(data, 0, 0, 0xff, 0xff, 1, 2) // behind the last one
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	spool, _ = OpenSpool(path, 0)
+	items, err := spool.load()
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "second", string(items[0].data))
+	assert.Equal(t, "third", string(items[1].data))
+}
+
+func TestSpoolFull(t *testing.T) {
+	spool, _ := OpenSpool(filepath.Join(t.TempDir(), "spool"), 40)
+	assert.NoError(t, spool.append([]batchItem{{data: []byte("first")}}))
+	assert.Equal(t, errSpoolFull, spool.append([]batchItem{{data: []byte("second")}}))
+	assert.Equal(t, uint64(1), spool.Dropped())
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StackKey is the field holding the stack trace attached by WithStackTraces.
+const StackKey = "stack"
+
+// maxInternalFrames is how many frames of the logging machinery may precede
+// the caller's when capturing a stack.
+const maxInternalFrames = 16
+
+// WithStackTraces attaches the stack of the logging call, at most maxFrames
+// deep, to entries of the level or more severe, such as ErrorLevel for Error,
+// Fatal and Panic entries. It is written as the stack field, in the format of
+// a goroutine trace. A maxFrames of zero turns stack traces off again.
+func WithStackTraces(level Level, maxFrames int) Option {
+	return func(c *config) {
+		if maxFrames < 0 {
+			maxFrames = 0
+		}
+		c.stackLevel = level
+		c.stackFrames = maxFrames
+	}
+}
+
+// attachStack adds the stack field to entries of the configured levels. Skip
+// is the number of frames between the caller of attachStack and the logging
+// machinery.
+func (c *config) attachStack(entry *Entry, level Level, skip int) {
+	if c.stackFrames == 0 || level > c.stackLevel {
+		return
+	}
+	if _, ok := entry.Data[StackKey]; ok {
+		return
+	}
+	entry.Data[StackKey] = formatStack(loggingStack(entry, skip+1, c.stackFrames))
+}
+
+var (
+	// packagePath and logrusPackage identify the frames of the logging machinery
+	// when walking the stack of a logging call.
+	packagePath   = reflect.TypeOf(fld{}).PkgPath()
+	logrusPackage = reflect.TypeOf(logrus.Entry{}).PkgPath()
+)
+
+// splitFunctionName splits a qualified function name such as
+// "github.com/a/b.(*T).M" into its package path and function name.
+func splitFunctionName(name string) (pkg, function string) {
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+2+dot:]
+}
+
+// inApp reports whether a package belongs to the application rather than the
+// standard library.
+func inApp(pkg string) bool {
+	first := strings.SplitN(pkg, "/", 2)[0]
+	return pkg == "main" || strings.Contains(first, ".")
+}
+
+// loggingStack returns the stack of the logging call, innermost frame first,
+// without the frames of logrus and this package's wrappers. Skip is the number of
+// frames between the caller of loggingStack and the logging machinery. Entries
+// written asynchronously carry the stack captured when they were queued.
+func loggingStack(entry *Entry, skip, maxFrames int) (stack []runtime.Frame) {
+	var pcs []uintptr
+	if entry.Context != nil {
+		pcs, _ = entry.Context.Value(callerStackKey{}).([]uintptr)
+	}
+	if pcs == nil {
+		pcs = make([]uintptr, maxFrames+maxInternalFrames)
+		pcs = pcs[:runtime.Callers(skip+2, pcs)]
+	}
+	frames := runtime.CallersFrames(pcs)
+	internal := true
+	for {
+		f, more := frames.Next()
+		pkg, _ := splitFunctionName(f.Function)
+		if !internal || (pkg != logrusPackage && pkg != packagePath) {
+			internal = false
+			stack = append(stack, f)
+		}
+		if !more || len(stack) == maxFrames {
+			return stack
+		}
+	}
+}
+
+// formatStack renders frames like a goroutine trace, one function and location
+// pair per frame.
+func formatStack(frames []runtime.Frame) string {
+	b := strings.Builder{}
+	for _, f := range frames {
+		b.WriteString(f.Function)
+		b.WriteString("\n\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
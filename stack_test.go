@@ -0,0 +1,49 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFunctionName(t *testing.T) {
+	pkg, fn := splitFunctionName("github.com/andyday/go-log.(*SentrySink).Write")
+	assert.Equal(t, "github.com/andyday/go-log", pkg)
+	assert.Equal(t, "(*SentrySink).Write", fn)
+
+	pkg, fn = splitFunctionName("main.main.func1")
+	assert.Equal(t, "main", pkg)
+	assert.Equal(t, "main.func1", fn)
+
+	assert.Equal(t, "github.com/andyday/go-log", packagePath)
+	assert.True(t, inApp("main"))
+	assert.True(t, inApp("github.com/x/y"))
+	assert.False(t, inApp("net/http"))
+}
+
+func TestWithStackTraces(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, DebugLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(WithStackTraces(0, 0))
+
+	Error(ctx, "no stack")
+	assert.NotContains(t, sink.entries[0].Data, StackKey)
+
+	Configure(WithStackTraces(WarnLevel, 2))
+	Warn(ctx, "warning")
+	Info(ctx, "info")
+	Errorf(ctx, "error %d", 1)
+	assert.Len(t, sink.entries, 4)
+	for i, want := range []bool{false, true, false, true} {
+		assert.Equal(t, want, sink.entries[i].Data[StackKey] != nil, sink.entries[i].Message)
+	}
+	// the test function is in this package, so the stack starts at its caller
+	stack := sink.entries[3].Data[StackKey].(string)
+	assert.True(t, strings.HasPrefix(stack, "testing.tRunner\n\t"), stack)
+	assert.Equal(t, 4, strings.Count(stack, "\n"))
+}
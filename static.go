@@ -0,0 +1,25 @@
+package log
+
+import "github.com/sirupsen/logrus"
+
+// WithStaticFields adds flds to every entry, for dimensions of the deployment
+// such as the environment or region, set once at startup instead of at every
+// call site. Fields set before under the same keys are replaced; fields of a
+// call or its context take precedence.
+func WithStaticFields(flds ...Fld) Option {
+	return func(c *config) {
+		fields := make(logrus.Fields, len(flds))
+		for _, f := range flds {
+			f.apply(fields)
+		}
+		delete(fields, rateLimitField)
+		c.setStatic(fields)
+	}
+}
+
+// SetGlobalFields adds flds to every entry, like Configure(WithStaticFields(flds...)).
+//
+//	log.SetGlobalFields(log.Field("env", "prod"), log.Field("region", "us-east-1"))
+func SetGlobalFields(flds ...Fld) {
+	Configure(WithStaticFields(flds...))
+}
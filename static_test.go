@@ -0,0 +1,26 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetGlobalFields(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(func(c *config) { c.static = nil })
+
+	SetGlobalFields(Field("env", "prod"), Field("region", "us-east-1"), RateLimited("k", 1))
+	Configure(WithStaticFields(Prebind(Field("region", "eu-west-1"), Field("tier", 2))))
+	Info(ctx, "Informational Message 1")
+	Info(ctx, "Informational Message 2", Field("env", "staging"))
+
+	assert.Equal(t, logrus.Fields{"env": "prod", "region": "eu-west-1", "tier": 2}, sink.entries[0].Data)
+	assert.Equal(t, "staging", sink.entries[1].Data["env"])
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SinkHealth describes the delivery state of a sink.
+type SinkHealth struct {
+	// Name is the name the sink was added under.
+	Name string
+	// LastError is the most recent write or delivery error, and LastErrorAt when
+	// it happened.
+	LastError   error
+	LastErrorAt time.Time
+	// Queued is the number of entries waiting to be sent.
+	Queued int
+	// Spooled is the size in bytes of the sink's spool.
+	Spooled int64
+	// Dropped counts entries that were lost, e.g. because a write failed, the
+	// queue overflowed or a batch could not be delivered.
+	Dropped uint64
+	// LastFlush is when entries were last delivered successfully.
+	LastFlush time.Time
+}
+
+// StatusReporter is implemented by sinks that can report more than write
+// errors, such as the batching sinks.
+type StatusReporter interface {
+	Status() SinkHealth
+}
+
+// SinkStatus returns the health of every attached sink, in the order they were
+// added.
+func SinkStatus() []SinkHealth {
+	sinksMu.Lock()
+	hooks := append([]*sinkHook(nil), sinks...)
+	sinksMu.Unlock()
+
+	status := make([]SinkHealth, len(hooks))
+	for i, h := range hooks {
+		if r, ok := h.sink.(StatusReporter); ok {
+			status[i] = r.Status()
+		}
+		status[i].Name = h.name
+		status[i].Dropped += atomic.LoadUint64(&h.dropped)
+		h.mu.Lock()
+		if h.lastErrAt.After(status[i].LastErrorAt) {
+			status[i].LastError, status[i].LastErrorAt = h.lastErr, h.lastErrAt
+		}
+		h.mu.Unlock()
+	}
+	return status
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSinkStatus(t *testing.T) {
+	ctx := context.Background()
+	Init(JSONFormatter, DebugLevel)
+	SetErrorHandler(func(error) {})
+	defer SetErrorHandler(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	webhook := NewWebhookSink(WebhookConfig{URL: srv.URL})
+	failing := &memorySink{err: errors.New("disk full")}
+	AddSink("webhook", webhook)
+	AddSink("failing", failing)
+	defer func() {
+		_ = RemoveSink("webhook")
+		_ = RemoveSink("failing")
+	}()
+
+	Info(ctx, "Informational Message 1")
+	Info(ctx, "Informational Message 2")
+	status := SinkStatus()
+	assert.Len(t, status, 2)
+	assert.Equal(t, "webhook", status[0].Name)
+	assert.Equal(t, 2, status[0].Queued)
+	assert.Equal(t, "failing", status[1].Name)
+	assert.EqualError(t, status[1].LastError, "disk full")
+	assert.Equal(t, uint64(2), status[1].Dropped)
+
+	assert.Error(t, webhook.Flush())
+	status = SinkStatus()
+	assert.Equal(t, 0, status[0].Queued)
+	assert.Equal(t, uint64(2), status[0].Dropped)
+	assert.Contains(t, status[0].LastError.Error(), "unexpected status 400")
+	assert.True(t, status[0].LastFlush.IsZero())
+}
@@ -0,0 +1,329 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Facility is a syslog facility code as defined by RFC 5424.
+type Facility int
+
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFtp
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// syslog severities, RFC 5424 section 6.2.1
+const (
+	severityEmergency = iota
+	severityAlert
+	severityCritical
+	severityError
+	severityWarning
+	severityNotice
+	severityInfo
+	severityDebug
+)
+
+// DefaultStructuredDataID is the SD-ID under which entry fields are encoded. 32473
+// is the private enterprise number reserved for documentation by RFC 5612.
+const DefaultStructuredDataID = "fields@32473"
+
+// SyslogLayout sets the header and structured data of the lines the RFC 5424
+// formatter writes.
+type SyslogLayout struct {
+	// Facility defaults to FacilityUser.
+	Facility Facility
+	// AppName defaults to the executable name.
+	AppName string
+	// Hostname defaults to os.Hostname.
+	Hostname string
+	// StructuredDataID defaults to DefaultStructuredDataID.
+	StructuredDataID string
+}
+
+// WithSyslogLayout sets the header and structured data of the lines the RFC
+// 5424 formatter writes.
+func WithSyslogLayout(layout SyslogLayout) Option {
+	return func(c *config) {
+		c.syslogLayout = layout
+	}
+}
+
+var (
+	hostnameOnce sync.Once
+	hostname     string
+)
+
+// syslogFormatter writes entries as RFC 5424 lines, for a file or writer read
+// by a syslog relay rather than sent to a daemon by SyslogSink. The fields are
+// the structured data; line breaks in the message are escaped as \n so that
+// each entry stays on one line.
+type syslogFormatter struct{}
+
+func (f *syslogFormatter) Format(entry *Entry) ([]byte, error) {
+	layout := currentConfig().syslogLayout
+	if layout.Facility == FacilityKern {
+		layout.Facility = FacilityUser
+	}
+	if layout.AppName == "" {
+		layout.AppName = filepath.Base(os.Args[0])
+	}
+	if layout.Hostname == "" {
+		hostnameOnce.Do(func() { hostname, _ = os.Hostname() })
+		layout.Hostname = hostname
+	}
+	if layout.StructuredDataID == "" {
+		layout.StructuredDataID = DefaultStructuredDataID
+	}
+	msg := formatRFC5424(entry, layout.Facility, layout.Hostname, layout.AppName, layout.StructuredDataID)
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	syslogLineEscaper.WriteString(b, msg)
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+var syslogLineEscaper = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// SyslogConfig configures a syslog sink.
+type SyslogConfig struct {
+	// Network is one of "udp", "tcp", "unix" or "unixgram". When empty the local
+	// syslog socket is used.
+	Network string
+	// Addr is the remote address or socket path.
+	Addr string
+	// Facility defaults to FacilityUser. FacilityKern is reserved for the kernel
+	// and cannot be selected.
+	Facility Facility
+	// AppName defaults to the executable name.
+	AppName string
+	// Hostname defaults to os.Hostname.
+	Hostname string
+	// StructuredDataID defaults to DefaultStructuredDataID.
+	StructuredDataID string
+	// Timeout bounds connecting and writing and defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// SyslogSink writes RFC 5424 messages to a syslog daemon.
+type SyslogSink struct {
+	cfg  SyslogConfig
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink connects to the syslog daemon described by cfg.
+func NewSyslogSink(cfg SyslogConfig) (*SyslogSink, error) {
+	if cfg.Facility == FacilityKern {
+		cfg.Facility = FacilityUser
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = filepath.Base(os.Args[0])
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.StructuredDataID == "" {
+		cfg.StructuredDataID = DefaultStructuredDataID
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	}
+	s := &SyslogSink{cfg: cfg}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyslogSink) connect() (err error) {
+	if s.cfg.Network != "" {
+		s.conn, err = net.DialTimeout(s.cfg.Network, s.cfg.Addr, s.cfg.Timeout)
+		return err
+	}
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+			if s.conn, err = net.DialTimeout(network, path, s.cfg.Timeout); err == nil {
+				return nil
+			}
+		}
+	}
+	return errors.New("unix syslog delivery error")
+}
+
+func (s *SyslogSink) Write(entry *Entry) error {
+	msg := formatRFC5424(entry, s.cfg.Facility, s.cfg.Hostname, s.cfg.AppName, s.cfg.StructuredDataID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		if err := s.write(msg); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	return s.write(msg)
+}
+
+// write sends msg over the connection, framed as the connection needs. The
+// caller holds s.mu.
+func (s *SyslogSink) write(msg string) error {
+	if s.stream() {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.cfg.Timeout))
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
+
+// stream reports whether messages need octet-counting framing (RFC 6587).
+func (s *SyslogSink) stream() bool {
+	if s.conn == nil {
+		return false
+	}
+	switch s.conn.LocalAddr().Network() {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+func (s *SyslogSink) Flush() error {
+	return nil
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func syslogSeverity(level Level) int {
+	switch level {
+	case PanicLevel:
+		return severityEmergency
+	case FatalLevel:
+		return severityCritical
+	case ErrorLevel:
+		return severityError
+	case WarnLevel:
+		return severityWarning
+	case InfoLevel:
+		return severityInfo
+	default:
+		return severityDebug
+	}
+}
+
+func formatRFC5424(entry *Entry, facility Facility, hostname, appName, sdID string) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - ",
+		int(facility)*8+syslogSeverity(entry.Level),
+		entry.Time.Format(time.RFC3339Nano),
+		syslogHeaderField(hostname, 255),
+		syslogHeaderField(appName, 48),
+		os.Getpid())
+
+	if len(entry.Data) == 0 && !entry.HasCaller() {
+		b.WriteByte('-')
+	} else {
+		b.WriteByte('[')
+		b.WriteString(sdID)
+		if entry.HasCaller() {
+			b.WriteString(` caller="`)
+			b.WriteString(sdParamValue(callerLocation(entry)))
+			b.WriteByte('"')
+		}
+		for _, k := range fieldKeys(entry) {
+			b.WriteByte(' ')
+			b.WriteString(sdParamName(k))
+			b.WriteString(`="`)
+			b.WriteString(sdParamValue(stringValue(entry.Data[k])))
+			b.WriteByte('"')
+		}
+		b.WriteByte(']')
+	}
+	if entry.Message != "" {
+		b.WriteByte(' ')
+		b.WriteString(entry.Message)
+	}
+	return b.String()
+}
+
+// syslogHeaderField restricts a header field to printable US-ASCII.
+func syslogHeaderField(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	return s
+}
+
+func sdParamName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	return s
+}
+
+var sdValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func sdParamValue(s string) string {
+	return sdValueEscaper.Replace(s)
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		Facility: FacilityLocal0,
+		AppName:  "app",
+		Hostname: "host",
+	})
+	assert.NoError(t, err)
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("syslog", sink)
+	defer func() { _ = RemoveSink("syslog") }()
+
+	Warn(context.Background(), "Warning Message 1", Field("quote", `a"b]`), Field("n", 2))
+
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^<132>1 \S+ host app \d+ - \[fields@32473 n="2" quote="a\\"b\\]"\] Warning Message 1$`), string(buf[:n]))
+}
+
+func TestSyslogSinkTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		// accept, but never read
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "tcp", Addr: ln.Addr().String(), Timeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	start := time.Now()
+	entry := &Entry{Time: start, Message: strings.Repeat("x", 16<<20)}
+	assert.Error(t, sink.Write(entry))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestSyslogFormatter(t *testing.T) {
+	assert.Equal(t, SyslogFormatter, FormatterFromName("Syslog"))
+	defer Configure(WithSyslogLayout(SyslogLayout{}))
+	Configure(WithSyslogLayout(SyslogLayout{Facility: FacilityLocal3, AppName: "my app", Hostname: "host"}))
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{"user": "jane", "path": `C:\a`})
+	entry.Time = time.Date(2024, 5, 6, 7, 8, 9, 5000, time.UTC)
+	entry.Level = ErrorLevel
+	entry.Message = "failed\nat main.go"
+	got, err := new(syslogFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^<155>1 2024-05-06T07:08:09.000005Z host myapp \d+ - \[fields@32473 path="C:\\\\a" user="jane"\] failed\\nat main.go\n$`), string(got))
+
+	Configure(WithSyslogLayout(SyslogLayout{StructuredDataID: "app@1"}))
+	entry.Data = logrus.Fields{}
+	entry.Message = ""
+	got, err = new(syslogFormatter).Format(entry)
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^<11>1 \S+ \S+ \S+ \d+ - -\n$`), string(got))
+}
+
+func TestSyslogSinkReconnectFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	received := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 1024)
+				n, _ := conn.Read(buf)
+				received <- string(buf[:n])
+			}()
+		}
+	}()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "tcp", Addr: ln.Addr().String(), AppName: "app", Hostname: "host"})
+	assert.NoError(t, err)
+	// a write that failed leaves the sink without a connection
+	assert.NoError(t, sink.Close())
+	assert.NoError(t, sink.Write(&Entry{Time: time.Now(), Level: InfoLevel, Message: "again"}))
+	defer sink.Close()
+
+	msg := <-received
+	if msg == "" {
+		msg = <-received
+	}
+	assert.Regexp(t, regexp.MustCompile(`^\d+ <14>1 \S+ host app \d+ - - again$`), msg)
+}
@@ -0,0 +1,95 @@
+package log
+
+import "time"
+
+// DurationFormat is how time.Duration values are written.
+type DurationFormat int
+
+const (
+	// DurationNanos writes durations as integer nanoseconds, as encoding/json
+	// does. Text formatters print them with time.Duration.String.
+	DurationNanos DurationFormat = iota
+	// DurationMillis writes durations as fractional milliseconds, such as 1.5.
+	DurationMillis
+	// DurationString writes durations as strings, such as "1.2s".
+	DurationString
+)
+
+// EpochMillis is a layout for WithTimeFormat that writes times as Unix
+// milliseconds.
+const EpochMillis = "epochmillis"
+
+// WithDurationFormat sets how durations in fields are written, by every
+// formatter and sink, including durations inside structs, maps and slices
+// encoded as JSON.
+func WithDurationFormat(format DurationFormat) Option {
+	return func(c *config) {
+		c.durationFormat = format
+	}
+}
+
+// WithTimeFormat sets the layout, such as time.RFC3339 or EpochMillis, that
+// times in fields are written with, by every formatter and sink, including
+// times inside structs, maps and slices encoded as JSON. The entry timestamp is
+// not affected. An empty layout restores the default, RFC3339 with
+// nanoseconds.
+func WithTimeFormat(layout string) Option {
+	return func(c *config) {
+		c.timeFormat = layout
+	}
+}
+
+// WithLocation sets the time zone of entry timestamps, as written by every
+// formatter and sink. Nil restores the local time zone.
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) {
+		c.location = loc
+	}
+}
+
+// WithUTC writes entry timestamps in UTC.
+func WithUTC() Option {
+	return WithLocation(time.UTC)
+}
+
+// now returns the current time in the configured time zone.
+func (c *config) now() time.Time {
+	if c.location != nil {
+		return time.Now().In(c.location)
+	}
+	return time.Now()
+}
+
+// formatTimes replaces the durations and times in the fields of the entry,
+// which must not be shared, with their formatted values.
+func (c *config) formatTimes(entry *Entry) {
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case time.Duration:
+			if c.durationFormat != DurationNanos {
+				entry.Data[k] = formatDuration(v, c.durationFormat)
+			}
+		case time.Time:
+			if c.timeFormat != "" {
+				entry.Data[k] = formatTime(v, c.timeFormat)
+			}
+		}
+	}
+}
+
+func formatDuration(d time.Duration, format DurationFormat) interface{} {
+	switch format {
+	case DurationMillis:
+		return float64(d) / float64(time.Millisecond)
+	case DurationString:
+		return d.String()
+	}
+	return int64(d)
+}
+
+func formatTime(t time.Time, layout string) interface{} {
+	if layout == EpochMillis {
+		return t.UnixMilli()
+	}
+	return t.Format(layout)
+}
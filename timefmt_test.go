@@ -0,0 +1,79 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDurationFormat(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(WithDurationFormat(DurationNanos))
+
+	d := 1500 * time.Millisecond
+	nested := map[string]interface{}{"d": d}
+	Info(context.Background(), "nanos", Field("took", d))
+	assert.Equal(t, d, sink.entries[0].Data["took"])
+	assert.Equal(t, `{"d":1500000000}`, jsonString(nested))
+
+	Configure(WithDurationFormat(DurationMillis))
+	Info(context.Background(), "millis", Field("took", d))
+	assert.Equal(t, 1500.0, sink.entries[1].Data["took"])
+	assert.Equal(t, `{"d":1500}`, jsonString(nested))
+	assert.Equal(t, `[0.25]`, jsonString([]time.Duration{250 * time.Microsecond}))
+
+	Configure(WithDurationFormat(DurationString))
+	Info(context.Background(), "string", Field("took", d))
+	assert.Equal(t, "1.5s", sink.entries[2].Data["took"])
+	assert.Equal(t, `{"D":"1.5s"}`, jsonString(struct{ D time.Duration }{d}))
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(WithTimeFormat(""))
+
+	at := time.Date(2024, 5, 6, 7, 8, 9, 10_000_000, time.UTC)
+	nested := struct{ At time.Time }{at}
+	Configure(WithTimeFormat(time.RFC3339))
+	Info(context.Background(), "rfc3339", Field("at", at), Field("took", time.Second))
+	assert.Equal(t, "2024-05-06T07:08:09Z", sink.entries[0].Data["at"])
+	assert.Equal(t, time.Second, sink.entries[0].Data["took"])
+	assert.Equal(t, `{"At":"2024-05-06T07:08:09Z"}`, jsonString(nested))
+
+	Configure(WithTimeFormat(EpochMillis))
+	Info(context.Background(), "millis", Field("at", at))
+	assert.Equal(t, at.UnixMilli(), sink.entries[1].Data["at"])
+	assert.Equal(t, `{"At":1714979289010}`, jsonString(nested))
+
+	Configure(WithTimeFormat(""))
+	assert.Equal(t, `{"At":"2024-05-06T07:08:09.01Z"}`, jsonString(nested))
+}
+
+func TestWithLocation(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	defer Configure(WithLocation(nil))
+
+	Configure(WithUTC())
+	Info(context.Background(), "utc")
+	assert.Equal(t, time.UTC, sink.entries[0].Time.Location())
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	Configure(WithLocation(tokyo))
+	Info(context.Background(), "tokyo")
+	assert.Equal(t, tokyo, sink.entries[1].Time.Location())
+
+	Configure(WithLocation(nil))
+	Info(context.Background(), "local")
+	assert.Equal(t, time.Local, sink.entries[2].Time.Location())
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// TruncatedKey is the field set to true on entries that were shortened to
+// fit the size limits.
+const TruncatedKey = "truncated"
+
+// SizeLimits caps the size of entries, in bytes; zero means no limit.
+type SizeLimits struct {
+	// Message caps the message.
+	Message int
+	// Field caps each field value, as a string or JSON encoding.
+	Field int
+	// Entry caps the message and all field names and values together. The
+	// largest fields are cut first, then the message.
+	Entry int
+}
+
+// WithSizeLimits truncates messages and field values beyond the limits and
+// marks such entries with truncated=true, so oversized entries don't break
+// downstream collectors. Truncated values that are not strings become
+// strings of their truncated JSON encoding.
+func WithSizeLimits(limits SizeLimits) Option {
+	return func(c *config) {
+		if limits == (SizeLimits{}) {
+			c.limits = nil
+			return
+		}
+		c.limits = &limits
+	}
+}
+
+// apply truncates the entry's fields and returns the truncated message.
+func (l *SizeLimits) apply(entry *Entry, msg string) string {
+	truncated := false
+	if l.Message > 0 && len(msg) > l.Message {
+		msg, truncated = truncateString(msg, l.Message), true
+	}
+	type sized struct {
+		key  string
+		text string
+	}
+	var fields []sized
+	total := len(msg)
+	for k, v := range entry.Data {
+		text, ok := v.(string)
+		if !ok {
+			switch v.(type) {
+			case nil, bool, int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, float64, float32:
+				total += len(k) + 8
+				continue
+			}
+			text = jsonString(v)
+		}
+		if l.Field > 0 && len(text) > l.Field {
+			text, truncated = truncateString(text, l.Field), true
+			entry.Data[k] = text
+		}
+		total += len(k) + len(text)
+		fields = append(fields, sized{key: k, text: text})
+	}
+	if excess := total - l.Entry; l.Entry > 0 && excess > 0 {
+		truncated = true
+		sort.Slice(fields, func(i, j int) bool { return len(fields[i].text) > len(fields[j].text) })
+		for _, f := range fields {
+			if excess <= 0 {
+				break
+			}
+			cut := len(f.text) - excess
+			if cut < 0 {
+				cut = 0
+			}
+			text := truncateString(f.text, cut)
+			excess -= len(f.text) - len(text)
+			entry.Data[f.key] = text
+		}
+		if excess > 0 {
+			msg = truncateString(msg, len(msg)-excess)
+		}
+	}
+	if truncated {
+		entry.Data[TruncatedKey] = true
+	}
+	return msg
+}
+
+// truncateString cuts s to at most n bytes without splitting a character.
+func truncateString(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "abc", truncateString("abc", 5))
+	assert.Equal(t, "ab", truncateString("abc", 2))
+	assert.Equal(t, "a", truncateString("aé", 2))
+	assert.Equal(t, "", truncateString("abc", -1))
+}
+
+func TestWithSizeLimits(t *testing.T) {
+	Init(JSONFormatter, InfoLevel)
+	sink := &memorySink{}
+	AddSink("memory", sink)
+	defer func() { _ = RemoveSink("memory") }()
+	Configure(WithSizeLimits(SizeLimits{Message: 10, Field: 8}))
+	defer Configure(WithSizeLimits(SizeLimits{}))
+
+	Info(context.Background(), "a rather long message", Field("body", "0123456789"), Field("list", []int{1, 2, 3, 4}), Field("n", 5))
+	Info(context.Background(), "short", Field("body", "0123"))
+
+	first := sink.entries[0]
+	assert.Equal(t, "a rather l", first.Message)
+	assert.Equal(t, "01234567", first.Data["body"])
+	assert.Equal(t, "[1,2,3,4", first.Data["list"])
+	assert.Equal(t, 5, first.Data["n"])
+	assert.Equal(t, true, first.Data[TruncatedKey])
+	assert.NotContains(t, sink.entries[1].Data, TruncatedKey)
+
+	Configure(WithSizeLimits(SizeLimits{Entry: 30}))
+	Info(context.Background(), "message", Field("big", strings.Repeat("x", 40)), Field("small", "yyyy"))
+	last := sink.entries[2]
+	assert.Equal(t, "message", last.Message)
+	assert.Equal(t, "yyyy", last.Data["small"])
+	assert.Equal(t, strings.Repeat("x", 11), last.Data["big"])
+	assert.Equal(t, true, last.Data[TruncatedKey])
+}
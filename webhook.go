@@ -0,0 +1,93 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookConfig configures a generic HTTP sink.
+type WebhookConfig struct {
+	// URL receives the batches.
+	URL string
+	// Header is sent with every request, e.g. for authentication.
+	Header http.Header
+	// Formatter renders the lines and defaults to JSON.
+	Formatter logrus.Formatter
+	// BatchSize defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+	// QueueSize defaults to DefaultQueueSize.
+	QueueSize int
+	// MaxRetries defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// Spool, if set, keeps batches that could not be delivered for later.
+	Spool *Spool
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+}
+
+// WebhookSink posts batches of entries as newline delimited JSON to an arbitrary
+// endpoint.
+type WebhookSink struct {
+	cfg     WebhookConfig
+	header  http.Header
+	batcher *batcher
+}
+
+// NewWebhookSink creates a webhook sink.
+func NewWebhookSink(cfg WebhookConfig) *WebhookSink {
+	if cfg.Formatter == nil {
+		cfg.Formatter = new(logrus.JSONFormatter)
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+	header := http.Header{"Content-Type": {"application/x-ndjson"}}
+	for k, v := range cfg.Header {
+		header[k] = v
+	}
+	s := &WebhookSink{cfg: cfg, header: header}
+	s.batcher = newBatcher(cfg.BatchSize, cfg.FlushInterval, cfg.QueueSize, cfg.Spool, s.send)
+	return s
+}
+
+func (s *WebhookSink) Write(entry *Entry) error {
+	line, err := s.cfg.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	s.batcher.add(batchItem{time: entry.Time, data: bytes.TrimRight(line, "\n")})
+	return nil
+}
+
+func (s *WebhookSink) send(items []batchItem) error {
+	body := bytes.Buffer{}
+	for _, item := range items {
+		body.Write(item.data)
+		body.WriteByte('\n')
+	}
+	return post(s.cfg.Client, s.cfg.URL, s.header, body.Bytes(), s.cfg.MaxRetries)
+}
+
+func (s *WebhookSink) Flush() error {
+	return s.batcher.flush()
+}
+
+func (s *WebhookSink) Close() error {
+	return s.batcher.close()
+}
+
+// Status reports the sink's queue for SinkStatus.
+func (s *WebhookSink) Status() SinkHealth {
+	return s.batcher.status()
+}
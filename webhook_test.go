@@ -0,0 +1,49 @@
+package log
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(WebhookConfig{
+		URL:       srv.URL,
+		Header:    http.Header{"Authorization": {"Bearer token"}},
+		BatchSize: 2,
+	})
+
+	Init(JSONFormatter, InfoLevel)
+	AddSink("webhook", sink)
+	ctx := context.Background()
+	Info(ctx, "Informational Message 1")
+	Info(ctx, "Informational Message 2")
+	Info(ctx, "Informational Message 3")
+	assert.NoError(t, RemoveSink("webhook"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	lines := strings.Split(strings.Join(bodies, ""), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[2], "Informational Message 3")
+}